@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"go.uber.org/dig"
+	"go.uber.org/fx"
+)
+
+// populate 只使用配置、日志和给定的模块构建依赖，并填充到targets中
+// 子命令通过它按需构建依赖，例如迁移命令不会连接Redis或SMTP，也只校验options.Sections中的配置。
+// 依赖构建后会执行生命周期的启动钩子，调用方结束时必须调用返回的stop，执行关闭数据库连接等停止钩子
+func populate(options *config.Options, modules fx.Option, targets ...interface{}) (stop func(), err error) {
+	app := fx.New(
+		fx.Supply(options),
+		config.Module,
+		logger.Module,
+		modules,
+		fx.NopLogger,
+		fx.Populate(targets...),
+	)
+	if err := app.Err(); err != nil {
+		// 只保留根本原因，省略依赖注入的调用链
+		return nil, dig.RootCause(err)
+	}
+
+	startCtx, cancel := context.WithTimeout(context.Background(), app.StartTimeout())
+	defer cancel()
+	if err := app.Start(startCtx); err != nil {
+		return nil, err
+	}
+	return func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+		defer cancel()
+		if err := app.Stop(stopCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "关闭失败: %v\n", err)
+		}
+	}, nil
+}
+
+// fail 输出错误信息并返回失败的退出码
+func fail(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	return 1
+}
+
+// overrideFlag 可以重复指定的 -set 参数
+type overrideFlag []string
+
+// String 实现flag.Value
+func (f *overrideFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set 实现flag.Value
+func (f *overrideFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// configFlags 注册配置相关的命令行参数，返回的选项在解析参数后生效。
+// sections为子命令用到的配置节，加载时只校验这些配置节，为空时校验全部
+func configFlags(flags *flag.FlagSet, sections ...string) *config.Options {
+	options := &config.Options{Sections: sections}
+	flags.StringVar(&options.File, "config", "", "配置文件路径（YAML/TOML/JSON），默认读取CONFIG_FILE")
+	flags.Var((*overrideFlag)(&options.Overrides), "set", "覆盖配置项，可重复指定，例如 -set logger.level=debug")
+	return options
+}
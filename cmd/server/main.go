@@ -1,29 +1,51 @@
 package main
 
 import (
-	"context"
-	"github.com/cheel98/flashcard-backend/internal/app"
-	"log"
-
-	"go.uber.org/fx"
+	"fmt"
+	"os"
+	"strings"
 )
 
+const usage = `用法: server [command] [flags]
+
+命令:
+  serve    启动服务（默认）
+  migrate  管理数据库迁移
+  seed     导入示例词典和演示用户
+  user     用户管理 (create|disable|enable|set-role|reset-password)
+  token    令牌调试 (issue)
+
+所有命令都支持 -config <文件> 和 -set key=value（可重复）指定配置，只校验命令用到的配置项
+使用 "server <command> -h" 查看命令的详细用法
+`
+
 func main() {
-	app := fx.New(
-		app.Module,
-		fx.Invoke(func(lc fx.Lifecycle, server *app.Server) {
-			lc.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
-					log.Println("Starting flashcard backend server...")
-					return server.Start()
-				},
-				OnStop: func(ctx context.Context) error {
-					log.Println("Stopping flashcard backend server...")
-					return server.Stop()
-				},
-			})
-		}),
-	)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 根据第一个参数分发子命令，返回进程退出码
+func run(args []string) int {
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
 
-	app.Run()
+	switch command {
+	case "serve":
+		return runServe(args)
+	case "migrate":
+		return runMigrate(args)
+	case "seed":
+		return runSeed(args)
+	case "user":
+		return runUser(args)
+	case "token":
+		return runToken(args)
+	case "help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", command, usage)
+		return 2
+	}
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/internal/database"
+	"go.uber.org/fx"
+)
+
+// defaultMigrationDir 新建迁移文件的默认目录
+const defaultMigrationDir = "internal/database/migrations"
+
+const migrateUsage = `用法: server migrate <command> [flags]
+
+命令:
+  up      执行未执行的迁移 (-steps N 只执行N个)
+  down    回滚已执行的迁移 (-steps N 回滚N个，默认1个)
+  status  查看迁移状态
+  create  创建新的迁移文件 (create [-dir 目录] <名称>)
+`
+
+// runMigrate 执行 migrate 子命令，返回进程退出码
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+	switch command {
+	case "up", "down", "status", "create":
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	options := configFlags(flags, config.SectionDatabase, config.SectionLogger)
+	steps := flags.Int("steps", 0, "迁移的数量")
+	dir := flags.String("dir", defaultMigrationDir, "迁移文件目录（仅create使用）")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if command == "create" {
+		if flags.NArg() != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		upPath, downPath, err := database.CreateMigration(*dir, flags.Arg(0))
+		if err != nil {
+			return fail("创建迁移文件失败: %v", err)
+		}
+		fmt.Printf("已创建 %s\n已创建 %s\n", upPath, downPath)
+		return 0
+	}
+
+	var migrator *database.Migrator
+	stop, err := populate(options, fx.Options(fx.Provide(database.Open, database.NewMigrator), database.CloseOnStop), &migrator)
+	if err != nil {
+		return fail("初始化迁移失败: %v", err)
+	}
+	defer stop()
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx, *steps)
+		fmt.Printf("已执行 %d 个迁移\n", applied)
+		if err != nil {
+			return fail("迁移失败: %v", err)
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		fmt.Printf("已回滚 %d 个迁移\n", reverted)
+		if err != nil {
+			return fail("回滚失败: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fail("查询迁移状态失败: %v", err)
+		}
+		printMigrationStatus(statuses)
+	}
+	return 0
+}
+
+// printMigrationStatus 以表格形式输出迁移状态
+func printMigrationStatus(statuses []*database.MigrationStatus) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		if status.AppliedAt != nil {
+			state = "applied"
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if status.Missing {
+			state = "missing"
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	_ = writer.Flush()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/internal/database"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/internal/seed"
+	"go.uber.org/fx"
+)
+
+// runSeed 导入示例词典和演示用户
+func runSeed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	options := configFlags(flags, config.SectionDatabase, config.SectionLogger)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var seeder *seed.Seeder
+	modules := fx.Options(
+		database.Module,
+		repository.Module,
+		fx.Provide(seed.NewSeeder),
+	)
+	stop, err := populate(options, modules, &seeder)
+	if err != nil {
+		return fail("初始化失败: %v", err)
+	}
+	defer stop()
+
+	result, err := seeder.Run(context.Background())
+	if err != nil {
+		return fail("导入示例数据失败: %v", err)
+	}
+
+	fmt.Printf("词典: 新增 %d 条，跳过 %d 条\n", result.DictionariesCreated, result.DictionariesSkipped)
+	if result.DemoUserCreated {
+		fmt.Printf("演示用户: %s (ID: %s)，密码 demo123456\n", seed.DemoEmail, result.DemoUserID)
+	} else {
+		fmt.Printf("演示用户已存在: %s (ID: %s)\n", seed.DemoEmail, result.DemoUserID)
+	}
+	return 0
+}
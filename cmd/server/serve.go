@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/app"
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"go.uber.org/dig"
+	"go.uber.org/fx"
+)
+
+// runServe 启动完整的应用
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	options := configFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	app := fx.New(
+		fx.Supply(options),
+		// 服务器按配置的时间优雅关闭，之后还要关闭数据库和Redis，留出余量
+		fx.StopTimeout(time.Duration(config.MaxShutdownSeconds)*time.Second+30*time.Second),
+		app.Module,
+		fx.Invoke(func(lc fx.Lifecycle, server *app.Server) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					log.Println("Starting flashcard backend server...")
+					return server.Start()
+				},
+				OnStop: func(ctx context.Context) error {
+					log.Println("Stopping flashcard backend server...")
+					return server.Stop(ctx)
+				},
+			})
+		}),
+	)
+
+	// 与app.Run相同，但启动或停止失败时返回非0的退出码，而不是在fx内部直接退出进程
+	startCtx, cancel := context.WithTimeout(context.Background(), app.StartTimeout())
+	defer cancel()
+	if err := app.Start(startCtx); err != nil {
+		return fail("启动失败: %v", dig.RootCause(err))
+	}
+
+	signal := <-app.Wait()
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), app.StopTimeout())
+	defer cancel()
+	if err := app.Stop(stopCtx); err != nil {
+		return fail("停止失败: %v", err)
+	}
+	return signal.ExitCode
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/internal/database"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/jwt"
+	"go.uber.org/fx"
+)
+
+const tokenUsage = `用法: server token issue [-ttl 时长] <邮箱>
+
+为用户签发访问令牌，仅用于调试
+`
+
+// runToken 执行令牌调试子命令
+func runToken(args []string) int {
+	if len(args) == 0 || args[0] != "issue" {
+		fmt.Fprint(os.Stderr, tokenUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("token issue", flag.ContinueOnError)
+	options := configFlags(flags, config.SectionDatabase, config.SectionLogger, config.SectionJWT)
+	ttl := flags.Duration("ttl", 0, "令牌有效期，默认使用配置中的访问令牌有效期")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, tokenUsage)
+		return 2
+	}
+
+	var cfg *config.Config
+	var userRepo repository.UserRepository
+	modules := fx.Options(
+		database.Module,
+		fx.Provide(repository.NewUserRepository),
+	)
+	stop, err := populate(options, modules, &cfg, &userRepo)
+	if err != nil {
+		return fail("初始化失败: %v", err)
+	}
+	defer stop()
+
+	ctx := context.Background()
+	user, err := userRepo.GetUserByEmail(ctx, flags.Arg(0))
+	if err != nil {
+		return fail("查询用户失败: %v", err)
+	}
+	if user.Disabled {
+		return fail("用户已被禁用: %s", user.Email)
+	}
+
+	manager := jwt.NewJWTManagerFromConfig(cfg)
+	if *ttl > 0 {
+		manager = jwt.NewJWTManager(cfg.JWT.SecretKey, *ttl, time.Duration(cfg.JWT.RefreshTokenDuration)*time.Hour)
+	}
+	token, err := manager.GenerateAccessToken(user.ID, user.Email)
+	if err != nil {
+		return fail("签发令牌失败: %v", err)
+	}
+	fmt.Println(token)
+	return 0
+}
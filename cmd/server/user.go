@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/internal/database"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"go.uber.org/fx"
+)
+
+const userUsage = `用法: server user <command> [flags]
+
+命令:
+  create          创建用户 (create -email 邮箱 -name 用户名 -password-hash 密码哈希 [-role user|admin])
+  disable         禁用用户 (disable <邮箱>)
+  enable          启用用户 (enable <邮箱>)
+  set-role        设置角色 (set-role <邮箱> <user|admin>)
+  reset-password  重置密码 (reset-password <邮箱> <密码哈希>)
+
+密码哈希与客户端登录时提交的 password_hash 格式一致
+`
+
+// runUser 执行用户管理子命令
+func runUser(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, userUsage)
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+	options := configFlags(flags, config.SectionDatabase, config.SectionLogger)
+	email := flags.String("email", "", "邮箱（仅create使用）")
+	name := flags.String("name", "", "用户名（仅create使用）")
+	passwordHash := flags.String("password-hash", "", "密码哈希（仅create使用）")
+	role := flags.String("role", model.RoleUser, "角色（仅create使用）")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	// 校验参数后再连接数据库
+	var expectedArgs int
+	switch command {
+	case "create":
+		if *email == "" || *name == "" || *passwordHash == "" {
+			fmt.Fprint(os.Stderr, userUsage)
+			return 2
+		}
+		if !model.IsValidRole(*role) {
+			return fail("无效的角色: %s", *role)
+		}
+	case "disable", "enable":
+		expectedArgs = 1
+	case "set-role", "reset-password":
+		expectedArgs = 2
+	default:
+		fmt.Fprint(os.Stderr, userUsage)
+		return 2
+	}
+	if flags.NArg() != expectedArgs {
+		fmt.Fprint(os.Stderr, userUsage)
+		return 2
+	}
+	if command == "set-role" && !model.IsValidRole(flags.Arg(1)) {
+		return fail("无效的角色: %s", flags.Arg(1))
+	}
+
+	var userRepo repository.UserRepository
+	modules := fx.Options(
+		database.Module,
+		fx.Provide(repository.NewUserRepository),
+	)
+	stop, err := populate(options, modules, &userRepo)
+	if err != nil {
+		return fail("初始化失败: %v", err)
+	}
+	defer stop()
+
+	ctx := context.Background()
+	if command == "create" {
+		user, err := userRepo.Create(ctx, &model.User{
+			Name:         *name,
+			Email:        *email,
+			PasswordHash: *passwordHash,
+			Role:         *role,
+		})
+		if err != nil {
+			return fail("创建用户失败: %v", err)
+		}
+		fmt.Printf("用户创建成功: %s (ID: %s)\n", user.Email, user.ID)
+		return 0
+	}
+
+	user, err := userRepo.GetUserByEmail(ctx, flags.Arg(0))
+	if err != nil {
+		return fail("查询用户失败: %v", err)
+	}
+
+	switch command {
+	case "disable", "enable":
+		err = userRepo.SetUserDisabled(ctx, user.ID, command == "disable")
+	case "set-role":
+		err = userRepo.SetUserRole(ctx, user.ID, flags.Arg(1))
+	case "reset-password":
+		err = userRepo.UpdatePasswordHash(ctx, user.ID, flags.Arg(1))
+	}
+	if err != nil {
+		return fail("操作失败: %v", err)
+	}
+	fmt.Printf("用户 %s 已更新\n", user.Email)
+	return 0
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cheel98/flashcard-backend/internal/middleware"
+	"github.com/cheel98/flashcard-backend/swagger"
+	"github.com/swaggest/swgui/v5emb"
+)
+
+// API文档的路径
+const (
+	openAPIPath = "/openapi.json"
+	docsPath    = "/docs/"
+)
+
+// bearerSecurityName OpenAPI文档中JWT认证方式的名称
+const bearerSecurityName = "Bearer"
+
+// errorResponseDefinition 网关错误响应在OpenAPI文档中的定义名称，结构见 gatewayError
+const errorResponseDefinition = "ErrorResponse"
+
+// buildOpenAPISpec 在生成的文档基础上补充统一的标题、JWT认证方式和网关的错误响应结构。
+// 除AuthMiddleware中的公开方法外，所有接口都需要在Authorization请求头中携带 Bearer 令牌
+func buildOpenAPISpec(raw []byte, publicMethods []string) ([]byte, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	spec["info"] = map[string]interface{}{
+		"title":       "Flashcard Backend API",
+		"description": "Flashcard后端HTTP接口，由gRPC服务通过grpc-gateway转换而来",
+		"version":     "1.0",
+	}
+	spec["securityDefinitions"] = map[string]interface{}{
+		bearerSecurityName: map[string]interface{}{
+			"type":        "apiKey",
+			"name":        "Authorization",
+			"in":          "header",
+			"description": "JWT访问令牌，格式为 Bearer <access_token>，通过登录接口获取",
+		},
+	}
+	spec["security"] = []interface{}{
+		map[string]interface{}{bearerSecurityName: []interface{}{}},
+	}
+
+	definitions, _ := spec["definitions"].(map[string]interface{})
+	if definitions == nil {
+		definitions = make(map[string]interface{})
+		spec["definitions"] = definitions
+	}
+	definitions[errorResponseDefinition] = errorResponseSchema()
+	// 网关不返回google.rpc.Status，错误响应统一替换为ErrorResponse
+	delete(definitions, "rpcStatus")
+	delete(definitions, "protobufAny")
+
+	public := make(map[string]bool, len(publicMethods))
+	for _, method := range publicMethods {
+		public[operationID(method)] = true
+	}
+
+	paths, _ := spec["paths"].(map[string]interface{})
+	for _, path := range paths {
+		operations, _ := path.(map[string]interface{})
+		for _, op := range operations {
+			operation, ok := op.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if id, _ := operation["operationId"].(string); public[id] {
+				operation["security"] = []interface{}{}
+			}
+			if responses, ok := operation["responses"].(map[string]interface{}); ok {
+				responses["default"] = map[string]interface{}{
+					"description": "错误响应",
+					"schema":      map[string]interface{}{"$ref": "#/definitions/" + errorResponseDefinition},
+				}
+			}
+		}
+	}
+
+	return json.Marshal(spec)
+}
+
+// operationID 将gRPC完整方法名转换为openapiv2生成的operationId，例如 /user.UserService/Login 对应 UserService_Login
+func operationID(fullMethod string) string {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndex(service, "."); i >= 0 {
+		service = service[i+1:]
+	}
+	return service + "_" + method
+}
+
+// errorResponseSchema 网关错误响应的结构
+func errorResponseSchema() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"error": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code":       map[string]interface{}{"type": "integer", "format": "int32", "description": "HTTP状态码"},
+					"status":     map[string]interface{}{"type": "string", "description": "gRPC状态码名称，例如 NOT_FOUND"},
+					"reason":     map[string]interface{}{"type": "string", "description": "错误原因，例如 USER_NOT_FOUND，客户端应据此判断错误类型"},
+					"message":    map[string]interface{}{"type": "string", "description": "按请求语言本地化的错误描述"},
+					"metadata":   map[string]interface{}{"type": "object", "additionalProperties": str},
+					"request_id": str,
+					"field_violations": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"field":       str,
+								"description": str,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// registerDocs 在mux上挂载 /openapi.json 和 /docs/（Swagger UI，静态资源内嵌在二进制中）
+func registerDocs(mux *http.ServeMux) error {
+	spec, err := buildOpenAPISpec(swagger.Spec, middleware.PublicMethods())
+	if err != nil {
+		return err
+	}
+
+	mux.HandleFunc(openAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(spec)
+	})
+	mux.Handle(docsPath, v5emb.New("Flashcard Backend API", openAPIPath, docsPath))
+	mux.Handle(strings.TrimSuffix(docsPath, "/"), http.RedirectHandler(docsPath, http.StatusMovedPermanently))
+	return nil
+}
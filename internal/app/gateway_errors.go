@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cheel98/flashcard-backend/internal/middleware"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayError HTTP网关返回的错误结构
+type gatewayError struct {
+	Code            int                     `json:"code"`
+	Status          string                  `json:"status"`
+	Reason          string                  `json:"reason,omitempty"`
+	Message         string                  `json:"message"`
+	Metadata        map[string]string       `json:"metadata,omitempty"`
+	FieldViolations []gatewayFieldViolation `json:"field_violations,omitempty"`
+	RequestID       string                  `json:"request_id,omitempty"`
+}
+
+// gatewayFieldViolation 请求字段错误
+type gatewayFieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// gatewayErrorHandler 将gRPC状态转换为统一的JSON错误响应，HTTP状态码与gRPC状态码的映射与grpc-gateway一致
+func gatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	st := status.Convert(err)
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+
+	body := gatewayError{
+		Code:    httpStatus,
+		Status:  codeName(st.Code()),
+		Message: st.Message(),
+	}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			body.Reason = d.GetReason()
+			body.Metadata = d.GetMetadata()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				body.FieldViolations = append(body.FieldViolations, gatewayFieldViolation{
+					Field:       v.GetField(),
+					Description: v.GetDescription(),
+				})
+			}
+		}
+	}
+
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		// 错误响应同样带上gRPC响应头，例如限流的 Retry-After
+		for key, values := range md.HeaderMD {
+			if name, ok := outgoingHeaderMatcher(key); ok {
+				for _, value := range values {
+					w.Header().Add(name, value)
+				}
+			}
+		}
+		if values := md.HeaderMD.Get(middleware.RequestIDHeader); len(values) > 0 {
+			body.RequestID = values[0]
+		}
+	}
+
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	w.Header().Set("Content-Type", "application/json")
+	if st.Code() == codes.Unauthenticated {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(map[string]gatewayError{"error": body})
+}
+
+// codeName 返回gRPC状态码的大写名称，例如 NOT_FOUND
+func codeName(code codes.Code) string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// codeNames gRPC状态码的规范名称
+var codeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
@@ -0,0 +1,302 @@
+package app
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/cheel98/flashcard-backend/internal/config"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/rs/cors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// minCompressSize 小于该长度的响应不压缩，压缩收益不足以抵消开销
+const minCompressSize = 1024
+
+// translateImagePath 拍照翻译的HTTP路径，请求体中的图片使用Base64编码
+const translateImagePath = "/api/v1/translation/image"
+
+// imageBodyOverhead 拍照翻译请求体中翻译选项和每个分片的JSON结构占用的余量
+const imageBodyOverhead = 64 * 1024
+
+// wrapGateway 按配置为网关加上访问日志、跨域、响应压缩和请求体大小限制，顺序由外到内。
+// 拍照翻译的请求体上限按maxImageSize（字节）经过Base64编码后的大小放宽
+func wrapGateway(handler http.Handler, cfg config.GatewayConfig, maxImageSize int, logger *zap.Logger) http.Handler {
+	if limit := int64(cfg.MaxRequestBody) * 1024; limit > 0 {
+		imageLimit := int64(base64.StdEncoding.EncodedLen(maxImageSize) + imageBodyOverhead)
+		handler = limitRequestBody(handler, limit, map[string]int64{
+			translateImagePath: max(limit, imageLimit),
+		})
+	}
+	if cfg.Compression {
+		handler = compressResponse(handler)
+	}
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		handler = cors.New(cors.Options{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}).Handler(handler)
+	}
+	if cfg.AccessLog {
+		handler = accessLog(handler, logger)
+	}
+	return handler
+}
+
+// grpcWebHandler gRPC-Web请求转换为gRPC请求后直接交给gRPC服务器处理，与原生gRPC调用经过相同的拦截器，
+// 支持服务端流式接口；其余请求交给next。跨域由wrapGateway统一处理，不使用grpcweb自带的CORS
+func grpcWebHandler(server *grpc.Server, next http.Handler) http.Handler {
+	wrapped := grpcweb.WrapServer(server)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wrapped.IsGrpcWebRequest(r) {
+			wrapped.HandleGrpcWebRequest(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitRequestBody 限制请求体大小，pathLimits按路径覆盖上限。声明的Content-Length超过上限时直接返回413，
+// 未声明长度的请求读取超过上限后解码失败
+func limitRequestBody(next http.Handler, defaultLimit int64, pathLimits map[string]int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultLimit
+		if pathLimit, ok := pathLimits[r.URL.Path]; ok {
+			limit = pathLimit
+		}
+		if r.ContentLength > limit {
+			writeRequestBodyTooLarge(w, r, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRequestBodyTooLarge 以网关统一的错误结构返回413，错误描述按Accept-Language本地化
+func writeRequestBodyTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	err := errorsvar.ErrRequestBodyTooLarge
+	tag, _ := i18n.Match(r.Header.Get(i18n.AcceptLanguageHeader))
+	body := gatewayError{
+		Code:     http.StatusRequestEntityTooLarge,
+		Status:   codeName(err.Code.GRPCCode()),
+		Reason:   err.Reason,
+		Message:  i18n.Text(tag, err.Reason, err.Message),
+		Metadata: map[string]string{"limit": strconv.FormatInt(limit, 10)},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(map[string]gatewayError{"error": body})
+}
+
+// compressResponse 按Accept-Encoding使用br或gzip压缩响应。
+// 已经设置了Content-Encoding的响应（例如内嵌的Swagger UI静态资源）、无法压缩的内容类型和过小的响应保持原样
+func compressResponse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding 选择客户端接受的压缩算法，br优先，q=0表示不接受
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	for _, encoding := range []string{"br", "gzip"} {
+		if accepted[encoding] || accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressWriter 在写入响应头时决定是否压缩
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     io.WriteCloser
+	wroteHeader bool
+	small       bool
+}
+
+// WriteHeader 根据状态码和响应头决定是否压缩
+func (c *compressWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+
+	header := c.Header()
+	if !c.small && compressible(code, header) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", c.encoding)
+		if c.encoding == "br" {
+			c.encoder = brotli.NewWriterLevel(c.ResponseWriter, brotli.DefaultCompression)
+		} else {
+			c.encoder = gzip.NewWriter(c.ResponseWriter)
+		}
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write 写入响应体，未设置Content-Type时按内容推断，与net/http的行为一致
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		if c.Header().Get("Content-Type") == "" {
+			c.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		// 一元接口的响应一次性写入，首次写入的长度即为响应大小
+		c.small = len(p) < minCompressSize
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.encoder != nil {
+		return c.encoder.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Flush 将已压缩的数据发送给客户端，流式接口依赖该方法逐条推送
+func (c *compressWriter) Flush() {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := c.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close 结束压缩流
+func (c *compressWriter) Close() error {
+	if c.encoder == nil {
+		return nil
+	}
+	return c.encoder.Close()
+}
+
+// Unwrap 供http.ResponseController访问底层的ResponseWriter
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// compressible 响应是否需要压缩
+func compressible(code int, header http.Header) bool {
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if length := header.Get("Content-Length"); length != "" {
+		if n, err := strconv.Atoi(length); err == nil && n < minCompressSize {
+			return false
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "json"),
+		strings.HasSuffix(mediaType, "javascript"),
+		strings.HasSuffix(mediaType, "xml"),
+		mediaType == "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// accessLog 记录每个HTTP请求的方法、路径、状态码、响应大小和耗时
+func accessLog(next http.Handler, logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		logger.Info("HTTP request",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", rw.status),
+			zap.Int64("bytes", rw.bytes),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("remote_addr", r.RemoteAddr),
+			zap.String("user_agent", r.UserAgent()),
+			zap.String("request_id", w.Header().Get("X-Request-Id")),
+		)
+	})
+}
+
+// statusRecorder 记录响应的状态码和写入的字节数
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// WriteHeader 记录状态码
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Write 记录写入的字节数
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Flush 透传给底层的ResponseWriter
+func (s *statusRecorder) Flush() {
+	s.wroteHeader = true
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap 供http.ResponseController访问底层的ResponseWriter
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -5,8 +5,13 @@ import (
 	"github.com/cheel98/flashcard-backend/internal/database"
 	"github.com/cheel98/flashcard-backend/internal/grpc"
 	"github.com/cheel98/flashcard-backend/internal/handler"
+	"github.com/cheel98/flashcard-backend/internal/health"
+	"github.com/cheel98/flashcard-backend/internal/job"
+	"github.com/cheel98/flashcard-backend/internal/metrics"
 	"github.com/cheel98/flashcard-backend/internal/middleware"
+	"github.com/cheel98/flashcard-backend/internal/ocr"
 	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/internal/tracing"
 	"github.com/cheel98/flashcard-backend/pkg"
 	"go.uber.org/fx"
 )
@@ -17,13 +22,24 @@ var Module = fx.Options(
 	config.Module,
 	// 数据库模块
 	database.Module,
+	// 基础组件模块，其中的Redis需要在服务器之前启动、之后关闭
+	pkg.Module,
 	// 仓储模块
 	repository.Module,
 	// 中间件模块
 	middleware.Module,
 	// 处理器模块
 	handler.Module,
-	pkg.Module,
+	// 后台任务模块
+	job.Module,
+	// 依赖健康检查模块
+	health.Module,
+	// Prometheus指标模块
+	metrics.Module,
+	// 链路追踪模块
+	tracing.Module,
+	// 图片翻译引擎模块
+	ocr.Module,
 	// 服务器模块
 	grpc.Module,
 	fx.Provide(NewServer),
@@ -2,15 +2,26 @@ package app
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cheel98/flashcard-backend/internal/config"
-	grpcOptimizer "github.com/cheel98/flashcard-backend/int
+	grpcOptimizer "github.com/cheel98/flashcard-backend/internal/grpc"
 	"github.com/cheel98/flashcard-backend/internal/handler"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
+	"github.com/cheel98/flashcard-backend/internal/metrics"
 	"github.com/cheel98/flashcard-backend/internal/middleware"
+	"github.com/cheel98/flashcard-backend/internal/tracing"
+	"github.com/cheel98/flashcard-backend/pkg/tlsutil"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	// gRPC-Gateway 相关导入
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
@@ -22,9 +33,15 @@ import (
 	translationPb "github.com/cheel98/flashcard-backend/proto/generated/translation"
 	userPb "github.com/cheel98/flashcard-backend/proto/generated/user"
 
+	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	// 注册gRPC内置的gzip压缩器，客户端以gzip压缩请求时响应也使用gzip压缩
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // Server 服务器结构体
@@ -33,41 +50,87 @@ type Server struct {
 	logger            *zap.Logger
 	grpcServer        *grpc.Server
 	httpServer        *http.Server
+	gatewayConn       *grpc.ClientConn
+	inProcessLis      *bufconn.Listener
+	adminServer       *http.Server
 	handler           *handler.Handler
 	connectionPool    *grpcOptimizer.ConnectionPool
 	workerPool        *grpcOptimizer.WorkerPool
 	protobufOptimizer *grpcOptimizer.ProtobufOptimizer
 	authMiddleware    *middleware.AuthMiddleware
+	metrics           *metrics.Metrics
+	logLevel          zap.AtomicLevel
+	shutdowner        fx.Shutdowner
+	certReloader      *tlsutil.CertReloader
+	clientTLS         *tlsutil.ClientTLS
 }
 
+// inProcessBufferSize 进程内网关连接的缓冲区大小
+const inProcessBufferSize = 1 << 20
+
 // NewServer 创建新的服务器实例
 func NewServer(
 	cfg *config.Config,
 	logger *zap.Logger,
 	handler *handler.Handler,
 	authMiddleware *middleware.AuthMiddleware,
+	loggingMiddleware *middleware.LoggingMiddleware,
+	errorMiddleware *middleware.ErrorMiddleware,
+	validationMiddleware *middleware.ValidationMiddleware,
+	localeMiddleware *middleware.LocaleMiddleware,
+	idempotencyMiddleware *middleware.IdempotencyMiddleware,
+	rateLimitMiddleware *middleware.RateLimitMiddleware,
+	m *metrics.Metrics,
+	logLevel zap.AtomicLevel,
+	shutdowner fx.Shutdowner,
+	certReloader *tlsutil.CertReloader,
+	clientTLS *tlsutil.ClientTLS,
+	workerPool *grpcOptimizer.WorkerPool,
 ) *Server {
 	// 创建性能优化配置
 	perfConfig := grpcOptimizer.DefaultPerformanceConfig()
 
-	// 创建优化的gRPC服务器，集成JWT中间件
+	// 创建优化的gRPC服务器，集成链路追踪、请求日志、语言、错误转换、Prometheus指标、请求统计、JWT、限流、请求校验和幂等键中间件
+	healthServer := handler.GetHealthServer()
 	grpcServer := grpcOptimizer.CreateOptimizedServerWithInterceptors(
 		perfConfig,
 		logger,
-		authMiddleware.UnaryInterceptor(),
-		authMiddleware.StreamInterceptor(),
+		[]grpc.UnaryServerInterceptor{
+			tracing.UnaryServerInterceptor(),
+			loggingMiddleware.UnaryInterceptor(),
+			localeMiddleware.UnaryInterceptor(),
+			errorMiddleware.UnaryInterceptor(),
+			m.UnaryInterceptor(),
+			healthServer.UnaryInterceptor(),
+			authMiddleware.UnaryInterceptor(),
+			rateLimitMiddleware.UnaryInterceptor(),
+			localeMiddleware.UserUnaryInterceptor(),
+			validationMiddleware.UnaryInterceptor(),
+			idempotencyMiddleware.UnaryInterceptor(),
+		},
+		[]grpc.StreamServerInterceptor{
+			tracing.StreamServerInterceptor(),
+			loggingMiddleware.StreamInterceptor(),
+			localeMiddleware.StreamInterceptor(),
+			errorMiddleware.StreamInterceptor(),
+			m.StreamInterceptor(),
+			healthServer.StreamInterceptor(),
+			authMiddleware.StreamInterceptor(),
+			rateLimitMiddleware.StreamInterceptor(),
+			localeMiddleware.UserStreamInterceptor(),
+			validationMiddleware.StreamInterceptor(),
+		},
 	)
 
 	// 创建连接池
 	connPoolConfig := grpcOptimizer.DefaultConnectionPoolConfig()
+	connPoolConfig.TLS = clientTLS.Config
 	connectionPool := grpcOptimizer.NewConnectionPool(connPoolConfig, logger)
 
-	// 创建工作池
-	workerPool := grpcOptimizer.NewWorkerPool(perfConfig.WorkerPoolSize, perfConfig.RequestBufferSize, logger)
-	workerPool.Start()
+	// Protocol Buffers优化器在init中注册为flashcard-gzip压缩器，这里只读取它的压缩统计
+	protobufOptimizer := grpcOptimizer.DefaultProtobufOptimizer
 
-	// 创建Protocol Buffers优化器
-	protobufOptimizer := grpcOptimizer.NewProtobufOptimizer(grpcOptimizer.DefaultProtobufOptimizerConfig(), logger)
+	registerPoolMetrics(m, workerPool, protobufOptimizer)
 
 	return &Server{
 		config:            cfg,
@@ -78,11 +141,27 @@ func NewServer(
 		workerPool:        workerPool,
 		protobufOptimizer: protobufOptimizer,
 		authMiddleware:    authMiddleware,
+		metrics:           m,
+		logLevel:          logLevel,
+		shutdowner:        shutdowner,
+		certReloader:      certReloader,
+		clientTLS:         clientTLS,
 	}
 }
 
-// Start 启动服务器
-func (s *Server) Start() error {
+// Start 启动服务器。所有端口在返回前完成监听，端口被占用等错误直接返回，由fx终止启动；
+// 启动之后服务异常退出时通过Shutdowner关闭应用
+func (s *Server) Start() (err error) {
+	// 启动失败时关闭已经打开的监听器和连接
+	var closers []io.Closer
+	defer func() {
+		if err != nil {
+			for _, c := range closers {
+				_ = c.Close()
+			}
+		}
+	}()
+
 	// 注册gRPC服务
 	s.handler.RegisterServices(s.grpcServer)
 
@@ -92,93 +171,286 @@ func (s *Server) Start() error {
 		s.logger.Error("Failed to listen gRPC port", zap.Error(err))
 		return err
 	}
+	closers = append(closers, grpcLis)
 
-	// 启动gRPC服务器
-	go func() {
-		s.logger.Info("gRPC Server starting", zap.Int("port", s.config.Server.Port))
-		if err := s.grpcServer.Serve(grpcLis); err != nil {
-			s.logger.Error("Failed to serve gRPC", zap.Error(err))
+	// 监听HTTP网关端口
+	httpLis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.Server.HTTPPort))
+	if err != nil {
+		s.logger.Error("Failed to listen HTTP port", zap.Error(err))
+		return err
+	}
+	closers = append(closers, httpLis)
+
+	// 开启TLS时gRPC和HTTP端口都使用TLS，证书在握手时按需重新加载；是否要求客户端证书按端口配置
+	if s.certReloader != nil {
+		mtls := s.config.TLS.MTLS
+		grpcLis = tls.NewListener(grpcLis, s.certReloader.ServerConfig(mtls.GRPC, "h2"))
+		httpLis = tls.NewListener(httpLis, s.certReloader.ServerConfig(mtls.HTTP, "h2", "http/1.1"))
+		s.logger.Info("TLS enabled",
+			zap.Bool("grpc_mtls", s.config.TLS.RequireClientCert(mtls.GRPC)),
+			zap.Bool("http_mtls", s.config.TLS.RequireClientCert(mtls.HTTP)),
+			zap.Bool("admin_mtls", s.config.TLS.RequireClientCert(mtls.Admin)))
+	}
+
+	// 创建HTTP网关，进程内模式下同时创建内存监听器
+	if err := s.setupHTTPGateway(); err != nil {
+		if s.gatewayConn != nil {
+			_ = s.gatewayConn.Close()
 		}
-	}()
+		return err
+	}
+	closers = append(closers, s.gatewayConn)
+
+	// 管理HTTP服务器提供/metrics和运行时日志级别调整，admin_port为0时不启动
+	if s.config.Server.AdminPort > 0 {
+		if err := s.startAdminServer(); err != nil {
+			return err
+		}
+	}
+
+	// 启动gRPC服务器，进程内模式下同时在内存监听器上提供服务
+	s.serve("gRPC Server", s.config.Server.Port, func() error { return s.grpcServer.Serve(grpcLis) })
+	if s.inProcessLis != nil {
+		s.serve("In-process gRPC", 0, func() error { return s.grpcServer.Serve(s.inProcessLis) })
+	}
 
 	// 启动gRPC-Gateway HTTP服务器
+	s.serve("HTTP Gateway", s.config.Server.HTTPPort, func() error { return s.httpServer.Serve(httpLis) })
+
+	return nil
+}
+
+// serve 在后台运行服务，服务异常退出时关闭整个应用，避免进程存活但端口不可用
+func (s *Server) serve(name string, port int, run func() error) {
 	go func() {
-		if err := s.startHTTPGateway(); err != nil {
-			s.logger.Error("Failed to start HTTP gateway", zap.Error(err))
+		s.logger.Info(name+" starting", zap.Int("port", port))
+		if err := run(); err != nil && err != http.ErrServerClosed && err != grpc.ErrServerStopped {
+			s.logger.Error(name+" stopped unexpectedly", zap.Error(err))
+			if err := s.shutdowner.Shutdown(fx.ExitCode(1)); err != nil {
+				s.logger.Error("Failed to shutdown application", zap.Error(err))
+			}
 		}
 	}()
-
-	return nil
 }
 
-// startHTTPGateway 启动gRPC-Gateway HTTP服务器
-func (s *Server) startHTTPGateway() error {
+// setupHTTPGateway 创建gRPC-Gateway HTTP服务器
+func (s *Server) setupHTTPGateway() error {
 	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
 
 	// 创建gRPC-Gateway mux
-	mux := runtime.NewServeMux()
-
-	// gRPC服务器地址
-	grpcServerEndpoint := fmt.Sprintf("localhost:%d", s.config.Server.Port)
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+		runtime.WithErrorHandler(gatewayErrorHandler),
+		// JSON字段名与proto定义一致（snake_case），零值字段也输出，忽略请求中的未知字段
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.HTTPBodyMarshaler{
+			Marshaler: &runtime.JSONPb{
+				MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+				UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+			},
+		}),
+	)
 
-	// 连接选项
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	conn, err := s.dialGateway()
+	if err != nil {
+		return fmt.Errorf("failed to create gateway connection: %w", err)
+	}
+	s.gatewayConn = conn
 
 	// 注册所有服务的HTTP处理器
-	if err := userPb.RegisterUserServiceHandlerFromEndpoint(ctx, mux, grpcServerEndpoint, opts); err != nil {
+	if err := userPb.RegisterUserServiceHandler(ctx, mux, conn); err != nil {
 		return fmt.Errorf("failed to register user service handler: %w", err)
 	}
 
-	if err := dictionaryPb.RegisterDictionaryServiceHandlerFromEndpoint(ctx, mux, grpcServerEndpoint, opts); err != nil {
+	if err := dictionaryPb.RegisterDictionaryServiceHandler(ctx, mux, conn); err != nil {
 		return fmt.Errorf("failed to register dictionary service handler: %w", err)
 	}
 
-	if err := favoritePb.RegisterFavoriteServiceHandlerFromEndpoint(ctx, mux, grpcServerEndpoint, opts); err != nil {
+	if err := favoritePb.RegisterFavoriteServiceHandler(ctx, mux, conn); err != nil {
 		return fmt.Errorf("failed to register favorite service handler: %w", err)
 	}
 
-	if err := translationPb.RegisterTranslationHandlerFromEndpoint(ctx, mux, grpcServerEndpoint, opts); err != nil {
+	if err := translationPb.RegisterTranslationHandler(ctx, mux, conn); err != nil {
 		return fmt.Errorf("failed to register translation service handler: %w", err)
 	}
 
-	if err := healthPb.RegisterHealthServiceHandlerFromEndpoint(ctx, mux, grpcServerEndpoint, opts); err != nil {
+	if err := healthPb.RegisterHealthServiceHandler(ctx, mux, conn); err != nil {
 		return fmt.Errorf("failed to register health service handler: %w", err)
 	}
 
+	// 从HTTP请求头中提取追踪上下文并创建网关span
+	var httpHandler http.Handler = otelhttp.NewHandler(mux, "gateway",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}))
+
+	// API文档与网关共用端口；/metrics只在管理端口上提供，不对外暴露
+	root := http.NewServeMux()
+	if err := registerDocs(root); err != nil {
+		return err
+	}
+	root.Handle("/", httpHandler)
+	httpHandler = root
+	if s.config.Gateway.GRPCWeb {
+		httpHandler = grpcWebHandler(s.grpcServer, httpHandler)
+	}
+	httpHandler = wrapGateway(httpHandler, s.config.Gateway, s.config.TransferConfig.OCR.MaxImageSize*1024, s.logger)
+
 	// 创建HTTP服务器
+	gateway := s.config.Gateway
 	s.httpServer = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.config.Server.HTTPPort),
-		Handler: mux,
+		Handler:           httpHandler,
+		ReadHeaderTimeout: time.Duration(gateway.ReadHeaderTimeout) * time.Second,
+		ReadTimeout:       time.Duration(gateway.ReadTimeout) * time.Second,
+		IdleTimeout:       time.Duration(gateway.IdleTimeout) * time.Second,
 	}
+	return nil
+}
 
-	s.logger.Info("HTTP Gateway starting", zap.Int("port", s.config.Server.HTTPPort))
+// dialGateway 创建网关调用gRPC服务的连接。进程内模式通过内存监听器连接本进程的gRPC服务器，
+// 不经过网络，拦截器与直接调用gRPC时完全相同；endpoint模式通过TCP连接本机的gRPC端口，开启TLS时使用TLS连接。
+// 两种模式都会将HTTP请求的追踪上下文写入metadata
+func (s *Server) dialGateway() (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(tracing.StreamClientInterceptor()),
+	}
 
-	// 启动HTTP服务器
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return fmt.Errorf("failed to serve HTTP gateway: %w", err)
+	if s.config.Gateway.Mode == config.GatewayModeEndpoint {
+		creds := insecure.NewCredentials()
+		if s.clientTLS.Config != nil {
+			creds = credentials.NewTLS(s.clientTLS.Config)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+		return grpc.NewClient(net.JoinHostPort("localhost", strconv.Itoa(s.config.Server.Port)), opts...)
 	}
 
+	// 内存连接不经过网络，不需要TLS
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	s.inProcessLis = bufconn.Listen(inProcessBufferSize)
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.inProcessLis.DialContext(ctx)
+	}))
+	return grpc.NewClient("passthrough:///inprocess", opts...)
+}
+
+// incomingHeaderMatcher 除默认转发的请求头外，将x-request-id、accept-language和idempotency-key原样转发给gRPC服务。
+// Authorization由grpc-gateway固定以authorization转发，这里不再重复返回，否则认证中间件会读到两个值
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, middleware.RequestIDHeader) {
+		return middleware.RequestIDHeader, true
+	}
+	if strings.EqualFold(key, i18n.AcceptLanguageHeader) {
+		return i18n.AcceptLanguageHeader, true
+	}
+	if strings.EqualFold(key, middleware.IdempotencyKeyHeader) {
+		return middleware.IdempotencyKeyHeader, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher 将gRPC响应中的x-request-id、idempotent-replayed和限流相关的字段写入同名HTTP响应头，其余保持默认前缀
+func outgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case middleware.RequestIDHeader:
+		return "X-Request-Id", true
+	case middleware.IdempotentReplayedHeader:
+		return "Idempotent-Replayed", true
+	case middleware.RateLimitLimitHeader:
+		return "RateLimit-Limit", true
+	case middleware.RateLimitRemainingHeader:
+		return "RateLimit-Remaining", true
+	case middleware.RateLimitResetHeader:
+		return "RateLimit-Reset", true
+	case middleware.RetryAfterHeader:
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// startAdminServer 启动管理HTTP服务器，端口被占用时直接返回错误。
+// 默认只监听本机地址；开启TLS时同样使用TLS，配置了admin_token时要求请求携带该令牌
+func (s *Server) startAdminServer() error {
+	adminLis, err := net.Listen("tcp", net.JoinHostPort(s.config.Server.AdminHost, strconv.Itoa(s.config.Server.AdminPort)))
+	if err != nil {
+		s.logger.Error("Failed to listen admin port", zap.Error(err))
+		return err
+	}
+	if s.certReloader != nil {
+		adminLis = tls.NewListener(adminLis, s.certReloader.ServerConfig(s.config.TLS.MTLS.Admin, "http/1.1"))
+	}
+
+	// /log/level: GET查询当前日志级别，PUT {"level":"debug"} 修改日志级别
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.Handler())
+	mux.Handle("/log/level", s.logLevel)
+	s.adminServer = &http.Server{Handler: adminAuth(s.config.Server.AdminToken, mux)}
+
+	s.logger.Info("Admin server listening",
+		zap.String("host", s.config.Server.AdminHost),
+		zap.Bool("token", s.config.Server.AdminToken != ""))
+	s.serve("Admin Server", s.config.Server.AdminPort, func() error { return s.adminServer.Serve(adminLis) })
 	return nil
 }
 
-// Stop 停止服务器
-func (s *Server) Stop() error {
+// adminAuth token不为空时要求请求携带 Authorization: Bearer <token>
+func adminAuth(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Stop 按顺序停止服务器：
+//  1. 健康状态改为NOT_SERVING，负载均衡器据此摘除实例
+//  2. 等待 shutdown.drain_period，期间仍正常处理请求
+//  3. 结束Watch流，优雅关闭HTTP网关和gRPC服务器，超过 shutdown.timeout 后强制关闭连接
+//  4. 等待工作池处理完排队的任务，最多 shutdown.worker_drain_timeout
+//
+// 数据库和Redis由各自模块的fx生命周期钩子在服务器停止之后关闭
+func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping server...")
+	cfg := s.config.Shutdown
+	healthServer := s.handler.GetHealthServer()
 
-	// 停止HTTP服务器
-	if s.httpServer != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.httpServer.Shutdown(ctx); err != nil {
-			s.logger.Error("Failed to shutdown HTTP server", zap.Error(err))
+	// 先将健康状态标记为NOT_SERVING，让负载均衡器停止转发新请求
+	healthServer.Shutdown()
+
+	if drain := time.Duration(cfg.DrainPeriod) * time.Second; drain > 0 {
+		s.logger.Info("Draining before shutdown", zap.Duration("drain_period", drain))
+		select {
+		case <-time.After(drain):
+		case <-ctx.Done():
 		}
 	}
 
-	// 停止工作池
+	// Watch流不会自行结束，不先关闭会阻塞优雅关闭
+	healthServer.StopWatches()
+	s.stopServers(ctx, time.Duration(cfg.Timeout)*time.Second)
+
+	// 关闭网关到gRPC服务的连接
+	if s.gatewayConn != nil {
+		if err := s.gatewayConn.Close(); err != nil {
+			s.logger.Error("Failed to close gateway connection", zap.Error(err))
+		}
+	}
+
+	// 等待工作池处理完排队的任务
 	if s.workerPool != nil {
-		s.workerPool.Stop()
+		drainCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.WorkerDrainTimeout)*time.Second)
+		defer cancel()
+		if err := s.workerPool.Shutdown(drainCtx); err != nil {
+			s.logger.Warn("Worker pool did not drain in time", zap.Error(err))
+		}
 	}
 
 	// 关闭连接池
@@ -186,13 +458,50 @@ func (s *Server) Stop() error {
 		s.connectionPool.Close()
 	}
 
-	// 优雅停止gRPC服务器
-	s.grpcServer.GracefulStop()
-
 	s.logger.Info("Server stopped successfully")
 	return nil
 }
 
+// stopServers 同时优雅关闭HTTP网关、管理端口和gRPC服务器，超过timeout或ctx结束后强制关闭仍未结束的连接
+func (s *Server) stopServers(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for name, server := range map[string]*http.Server{"HTTP gateway": s.httpServer, "Admin server": s.adminServer} {
+		if server == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				s.logger.Warn(name+" did not shut down in time, closing connections", zap.Error(err))
+				_ = server.Close()
+			}
+		}(name, server)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			s.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			s.logger.Warn("gRPC server did not stop in time, closing connections")
+			s.grpcServer.Stop()
+			<-stopped
+		}
+	}()
+
+	wg.Wait()
+}
+
 // GetPerformanceStats 获取性能统计信息
 func (s *Server) GetPerformanceStats() map[string]interface{} {
 	stats := make(map[string]interface{})
@@ -207,10 +516,41 @@ func (s *Server) GetPerformanceStats() map[string]interface{} {
 		stats["worker_pool"] = s.workerPool.GetWorkerPoolStats()
 	}
 
-	// Protocol Buffers统计
+	// Protocol Buffers压缩统计
 	if s.protobufOptimizer != nil {
 		stats["protobuf"] = s.protobufOptimizer.GetCompressionStats()
 	}
 
 	return stats
 }
+
+// registerPoolMetrics 将工作池和gRPC压缩的统计信息注册为Prometheus指标
+func registerPoolMetrics(m *metrics.Metrics, workerPool *grpcOptimizer.WorkerPool, optimizer *grpcOptimizer.ProtobufOptimizer) {
+	m.RegisterGaugeFunc("worker_pool_workers", "工作池的工作协程数", func() float64 {
+		return float64(workerPool.WorkerCount())
+	})
+	m.RegisterGaugeFunc("worker_pool_queue_length", "工作池当前排队的任务数", func() float64 {
+		return float64(workerPool.QueueLength())
+	})
+	m.RegisterGaugeFunc("worker_pool_queue_capacity", "工作池队列容量", func() float64 {
+		return float64(workerPool.QueueCapacity())
+	})
+
+	compressionMetric := func(fn func(*grpcOptimizer.ProtobufMetrics) float64) func() float64 {
+		return func() float64 {
+			stats := optimizer.GetMetrics()
+			if stats == nil {
+				return 0
+			}
+			return fn(stats)
+		}
+	}
+	m.RegisterCounterFunc("protobuf_compressions_total", "gzip压缩的gRPC消息数",
+		compressionMetric(func(p *grpcOptimizer.ProtobufMetrics) float64 { return float64(p.CompressionCount) }))
+	m.RegisterCounterFunc("protobuf_uncompressed_bytes_total", "gzip压缩前的gRPC消息字节数",
+		compressionMetric(func(p *grpcOptimizer.ProtobufMetrics) float64 { return float64(p.TotalSerializedSize) }))
+	m.RegisterCounterFunc("protobuf_compressed_bytes_total", "gzip压缩后的gRPC消息字节数",
+		compressionMetric(func(p *grpcOptimizer.ProtobufMetrics) float64 { return float64(p.TotalCompressedSize) }))
+	m.RegisterGaugeFunc("protobuf_compression_ratio", "gRPC消息的平均压缩比（压缩后/压缩前）",
+		compressionMetric(func(p *grpcOptimizer.ProtobufMetrics) float64 { return p.AvgCompressionRatio }))
+}
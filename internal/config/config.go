@@ -1,29 +1,35 @@
 package config
 
-import (
-	"os"
-	"strconv"
-
-	"github.com/joho/godotenv"
-)
-
 // Config 应用配置结构体
 type Config struct {
-	Server         ServerConfig   `json:"server"`
-	Database       DatabaseConfig `json:"database"`
-	Logger         LoggerConfig   `json:"logger"`
-	JWT            JWTConfig      `json:"jwt"`
-	Redis          RedisConfig    `json:"redis"`
-	Email          EmailConfig    `json:"email"`
-	TransferConfig TransferConfig `json:"transfer_config"`
+	Server         ServerConfig      `json:"server"`
+	Database       DatabaseConfig    `json:"database"`
+	Logger         LoggerConfig      `json:"logger"`
+	JWT            JWTConfig         `json:"jwt"`
+	Redis          RedisConfig       `json:"redis"`
+	Email          EmailConfig       `json:"email"`
+	TransferConfig TransferConfig    `json:"transfer_config"`
+	Trash          TrashConfig       `json:"trash"`
+	Health         HealthConfig      `json:"health"`
+	Tracing        TracingConfig     `json:"tracing"`
+	HotReload      HotReloadConfig   `json:"hot_reload"`
+	Idempotency    IdempotencyConfig `json:"idempotency"`
+	RateLimit      RateLimitConfig   `json:"rate_limit"`
+	Gateway        GatewayConfig     `json:"gateway"`
+	TLS            TLSConfig         `json:"tls"`
+	Shutdown       ShutdownConfig    `json:"shutdown"`
+	HTTPClient     HTTPClientConfig  `json:"http_client"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port     int    `json:"port"`      // gRPC 端口
-	HTTPPort int    `json:"http_port"` // HTTP 端口 (gRPC-Gateway)
-	Host     string `json:"host"`
-	Env      string `json:"env"`
+	Port       int    `json:"port"`        // gRPC 端口
+	HTTPPort   int    `json:"http_port"`   // HTTP 端口 (gRPC-Gateway)
+	AdminPort  int    `json:"admin_port"`  // 管理端口 (/metrics、/log/level)，为0时不提供
+	AdminHost  string `json:"admin_host"`  // 管理端口监听的地址，不是本机地址时必须设置AdminToken或开启mTLS
+	AdminToken string `json:"admin_token"` // 不为空时管理端口的请求必须携带 Authorization: Bearer <AdminToken>
+	Host       string `json:"host"`
+	Env        string `json:"env"`
 }
 
 // DatabaseConfig 数据库配置
@@ -35,6 +41,8 @@ type DatabaseConfig struct {
 	DBName   string `json:"db_name"`
 	SSLMode  string `json:"ssl_mode"`
 	TimeZone string `json:"time_zone"`
+	// AutoMigrate 启动时根据模型自动迁移表结构，仅用于开发环境，生产环境使用 migrate 子命令
+	AutoMigrate bool `json:"auto_migrate"`
 }
 
 // LoggerConfig 日志配置
@@ -71,6 +79,8 @@ type Engine string
 
 const (
 	YOUDAO Engine = "Youdao"
+	// FAKE 本地假引擎，不调用外部服务，用于开发和测试
+	FAKE Engine = "Fake"
 )
 
 // 翻译引擎设置
@@ -79,77 +89,339 @@ type TransferConfig struct {
 	Engine    Engine `json:"engine"`
 	AppKey    string `json:"app_key"`
 	AppSecret string `json:"app_secret"`
+
+	MaxConcurrency   int                  `json:"max_concurrency"`   // 同时调用翻译引擎的最大请求数，超出的请求降级为查询词典缓存
+	MaxWait          int                  `json:"max_wait"`          // 并发已满时的最长等待时间（毫秒），0表示直接降级
+	BatchConcurrency int                  `json:"batch_concurrency"` // 单个批量翻译请求同时调用翻译引擎的请求数
+	CircuitBreaker   CircuitBreakerConfig `json:"circuit_breaker"`
+	OCR              OCRConfig            `json:"ocr"`
 }
 
-// LoadConfig 加载配置
-func LoadConfig() (*Config, error) {
-	// 加载.env文件（如果存在）
-	_ = godotenv.Load()
+// OCRConfig 图片翻译设置，与文本翻译共用app_key/app_secret、并发隔离和熔断器
+type OCRConfig struct {
+	Engine       Engine `json:"engine"`         // Youdao或Fake
+	URL          string `json:"url"`            // 有道图片翻译接口地址
+	MaxImageSize int    `json:"max_image_size"` // 上传图片的大小上限（KB）
+}
+
+// CircuitBreakerConfig 熔断器配置，断开期间请求降级为查询词典缓存
+type CircuitBreakerConfig struct {
+	Enabled          bool    `json:"enabled"`
+	Window           int     `json:"window"`             // 统计失败率的滑动窗口（秒）
+	MinRequests      int     `json:"min_requests"`       // 窗口内的请求数达到该值后才按失败率判断
+	FailureRate      float64 `json:"failure_rate"`       // 失败率达到该值时断开，0~1
+	OpenTimeout      int     `json:"open_timeout"`       // 断开后进入半开的时间（秒）
+	HalfOpenRequests int     `json:"half_open_requests"` // 半开状态允许的探测请求数，全部成功后闭合
+}
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	RetentionDays int `json:"retention_days"` // 软删除记录的保留天数，超过后被彻底删除
+	PurgeInterval int `json:"purge_interval"` // 清理任务的执行间隔（分钟），0表示不执行
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	CheckInterval int `json:"check_interval"` // 依赖检查间隔（秒），0表示只在启动时检查一次
+	CheckTimeout  int `json:"check_timeout"`  // 单个依赖检查的超时时间（秒）
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Exporter     string  `json:"exporter"`      // 导出方式：otlp、stdout、none
+	OTLPEndpoint string  `json:"otlp_endpoint"` // OTLP gRPC接收端地址
+	OTLPInsecure bool    `json:"otlp_insecure"` // OTLP是否使用明文连接
+	ServiceName  string  `json:"service_name"`
+	SampleRatio  float64 `json:"sample_ratio"` // 根span的采样比例，0~1
+}
+
+// HotReloadConfig 配置热加载
+type HotReloadConfig struct {
+	Interval int `json:"interval"` // 检查配置文件变化的间隔（秒），0表示只响应SIGHUP
+}
+
+// ShutdownConfig 优雅关闭配置，各阶段的时间之和不能超过 MaxShutdownSeconds
+type ShutdownConfig struct {
+	DrainPeriod        int `json:"drain_period"`         // 健康状态改为NOT_SERVING后继续接收请求的时间（秒），等待负载均衡器摘除实例
+	Timeout            int `json:"timeout"`              // 等待进行中的请求完成的时间（秒），超时后强制关闭连接
+	WorkerDrainTimeout int `json:"worker_drain_timeout"` // 等待工作池处理完排队任务的时间（秒）
+}
+
+// MaxShutdownSeconds 优雅关闭的总时长上限（秒），fx的停止超时据此设置
+const MaxShutdownSeconds = 120
+
+// HTTPClientConfig 调用外部HTTP服务（例如翻译引擎）的客户端配置
+type HTTPClientConfig struct {
+	Timeout             int              `json:"timeout"`                 // 单次请求的超时时间（秒），重试时每次单独计时
+	MaxRetries          int              `json:"max_retries"`             // 幂等请求失败后的最大重试次数
+	RetryBackoff        int              `json:"retry_backoff"`           // 首次重试的退避时间（毫秒），之后按指数增长并加入随机抖动
+	MaxRetryBackoff     int              `json:"max_retry_backoff"`       // 退避时间上限（毫秒）
+	MaxResponseSize     int              `json:"max_response_size"`       // 响应体大小上限（KB）
+	MaxIdleConnsPerHost int              `json:"max_idle_conns_per_host"` // 每个主机保留的空闲连接数
+	MaxConnsPerHost     int              `json:"max_conns_per_host"`      // 每个主机的最大连接数，0表示不限制
+	Hosts               []HTTPHostConfig `json:"hosts"`                   // 按主机覆盖超时时间
+}
+
+// HTTPHostConfig 单个主机的客户端配置
+type HTTPHostConfig struct {
+	Host    string `json:"host"`    // 主机名，不含端口
+	Timeout int    `json:"timeout"` // 单次请求的超时时间（秒）
+}
+
+// IdempotencyConfig 幂等键配置
+type IdempotencyConfig struct {
+	TTL         int `json:"ttl"`          // 响应的保留时间（小时），在此期间使用相同幂等键的重试直接返回保存的响应
+	LockTimeout int `json:"lock_timeout"` // 处理中状态的过期时间（秒），防止进程异常退出后幂等键一直被占用
+}
+
+// RateLimitConfig 请求限流配置
+type RateLimitConfig struct {
+	Enabled bool            `json:"enabled"`
+	Default RateLimitRule   `json:"default"` // 没有单独配置的方法使用的限额，每个方法分别计数
+	Rules   []RateLimitRule `json:"rules"`   // 按方法和会员等级配置的限额
+}
+
+// RateLimitRule 限流规则，周期内最多允许Limit个请求，Limit为0表示不限流
+type RateLimitRule struct {
+	Method          string `json:"method,omitempty"`           // 完整方法名，例如 /translation.Translation/Translation
+	MembershipLevel int    `json:"membership_level,omitempty"` // 规则适用的最低会员等级，匿名用户和非会员为0
+	Limit           int    `json:"limit"`
+	Period          int    `json:"period"`          // 周期（秒）
+	Burst           int    `json:"burst,omitempty"` // 允许的突发请求数，0表示等于Limit
+}
+
+// GatewayConfig HTTP网关配置
+type GatewayConfig struct {
+	Mode           string     `json:"mode"` // inprocess：通过内存连接调用本进程的gRPC服务；endpoint：通过TCP连接gRPC端口
+	CORS           CORSConfig `json:"cors"`
+	Compression    bool       `json:"compression"`      // 按Accept-Encoding使用br或gzip压缩响应
+	MaxRequestBody int        `json:"max_request_body"` // 请求体大小上限（KB），0表示不限制
+	AccessLog      bool       `json:"access_log"`       // 是否记录HTTP访问日志
+	GRPCWeb        bool       `json:"grpc_web"`         // 是否在HTTP端口上提供gRPC-Web，浏览器可以调用流式接口
+	// 以下超时单位为秒，0表示不限制。不设置写超时，避免中断gRPC-Web的服务端流
+	ReadHeaderTimeout int `json:"read_header_timeout"` // 读取请求头的超时时间
+	ReadTimeout       int `json:"read_timeout"`        // 读取整个请求（包括请求体）的超时时间
+	IdleTimeout       int `json:"idle_timeout"`        // keep-alive连接的空闲超时时间
+}
 
-	config := &Config{
+// 网关调用gRPC服务的方式
+const (
+	GatewayModeInProcess = "inprocess"
+	GatewayModeEndpoint  = "endpoint"
+)
+
+// CORSConfig 跨域配置，AllowedOrigins为空时不允许跨域请求
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"` // 允许的来源，例如 https://app.example.com，"*"表示任意来源
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"` // 浏览器脚本可以读取的响应头
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           int      `json:"max_age"` // 预检请求结果的缓存时间（秒）
+}
+
+// TLSConfig gRPC和HTTP端口的TLS配置，证书文件变化后自动重新加载
+type TLSConfig struct {
+	Enabled        bool       `json:"enabled"`
+	CertFile       string     `json:"cert_file"`
+	KeyFile        string     `json:"key_file"`
+	ClientCAFile   string     `json:"client_ca_file"` // 配置后按MTLS要求客户端提供由该CA签发的证书（mTLS）
+	MTLS           MTLSConfig `json:"mtls"`
+	CAFile         string     `json:"ca_file"`          // 作为客户端时校验服务端证书的CA，为空时使用系统根证书
+	ClientCertFile string     `json:"client_cert_file"` // 作为客户端连接开启了mTLS的服务时使用的证书
+	ClientKeyFile  string     `json:"client_key_file"`
+	ServerName     string     `json:"server_name"`     // 作为客户端时校验的服务端名称
+	ReloadInterval int        `json:"reload_interval"` // 检查证书文件变化的间隔（秒）
+}
+
+// MTLSConfig 各端口是否要求客户端证书，只在配置了client_ca_file时生效
+type MTLSConfig struct {
+	GRPC  bool `json:"grpc"`
+	HTTP  bool `json:"http"` // HTTP网关和gRPC-Web，浏览器通常无法提供客户端证书，默认不要求
+	Admin bool `json:"admin"`
+}
+
+// RequireClientCert 开启了TLS且配置了client_ca_file时，按端口的mTLS开关（例如MTLS.GRPC）判断是否要求客户端证书
+func (c TLSConfig) RequireClientCert(enabled bool) bool {
+	return c.Enabled && c.ClientCAFile != "" && enabled
+}
+
+// DefaultConfig 返回默认配置，是分层加载的最底层
+func DefaultConfig() *Config {
+	return &Config{
 		Server: ServerConfig{
-			Port:     getEnvAsInt("SERVER_PORT", 8080),
-			HTTPPort: getEnvAsInt("HTTP_PORT", 8081),
-			Host:     getEnv("SERVER_HOST", "localhost"),
-			Env:      getEnv("APP_ENV", "development"),
+			Port:      8080,
+			HTTPPort:  8081,
+			AdminPort: 9090,
+			AdminHost: "127.0.0.1",
+			Host:      "localhost",
+			Env:       EnvDevelopment,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			DBName:   getEnv("DB_NAME", "flashcard_db"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
-			TimeZone: getEnv("DB_TIMEZONE", "Asia/Shanghai"),
+			Host:        "localhost",
+			Port:        5432,
+			User:        "postgres",
+			Password:    "password",
+			DBName:      "flashcard_db",
+			SSLMode:     "disable",
+			TimeZone:    "Asia/Shanghai",
+			AutoMigrate: false,
 		},
 		Logger: LoggerConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Format: getEnv("LOG_FORMAT", "json"),
+			Level:  "info",
+			Format: "json",
 		},
 		JWT: JWTConfig{
-			SecretKey:            getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production"),
-			AccessTokenDuration:  getEnvAsInt("JWT_ACCESS_TOKEN_DURATION", 15),   // 15分钟
-			RefreshTokenDuration: getEnvAsInt("JWT_REFRESH_TOKEN_DURATION", 168), // 7天
+			SecretKey:            defaultJWTSecret,
+			AccessTokenDuration:  15,  // 15分钟
+			RefreshTokenDuration: 168, // 7天
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnvAsInt("REDIS_PORT", 6379),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
+			Host: "localhost",
+			Port: 6379,
 		},
 		Email: EmailConfig{
-			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
-			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
-			SMTPUsername: getEnv("SMTP_USERNAME", ""),
-			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
-			FromEmail:    getEnv("FROM_EMAIL", ""),
-			FromName:     getEnv("FROM_NAME", "Flashcard App"),
+			SMTPHost: "smtp.gmail.com",
+			SMTPPort: 587,
+			FromName: "Flashcard App",
 		},
 		TransferConfig: TransferConfig{
-			URL:       getEnv("TRANSFER_URL", "https://openapi.youdao.com/api"),
-			Engine:    YOUDAO,
-			AppKey:    getEnv("APP_KEY", ""),
-			AppSecret: getEnv("APP_SECRET", ""),
+			URL:              "https://openapi.youdao.com/api",
+			Engine:           YOUDAO,
+			MaxConcurrency:   32,
+			BatchConcurrency: 8,
+			CircuitBreaker: CircuitBreakerConfig{
+				Enabled:          true,
+				Window:           30,
+				MinRequests:      10,
+				FailureRate:      0.5,
+				OpenTimeout:      15,
+				HalfOpenRequests: 3,
+			},
+			OCR: OCRConfig{
+				Engine:       YOUDAO,
+				URL:          "https://openapi.youdao.com/ocrtransapi",
+				MaxImageSize: 2048, // 2MB
+			},
+		},
+		Trash: TrashConfig{
+			RetentionDays: 30,
+			PurgeInterval: 60,
+		},
+		Health: HealthConfig{
+			CheckInterval: 15, // 15秒
+			CheckTimeout:  3,  // 3秒
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "localhost:4317",
+			OTLPInsecure: true,
+			ServiceName:  "flashcard-backend",
+			SampleRatio:  1,
+		},
+		HotReload: HotReloadConfig{
+			Interval: 10,
+		},
+		Shutdown: ShutdownConfig{
+			DrainPeriod:        5,
+			Timeout:            20,
+			WorkerDrainTimeout: 10,
+		},
+		HTTPClient: HTTPClientConfig{
+			Timeout:             3,
+			MaxRetries:          2,
+			RetryBackoff:        100,
+			MaxRetryBackoff:     2000,
+			MaxResponseSize:     1024, // 1MB
+			MaxIdleConnsPerHost: 16,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         24, // 24小时
+			LockTimeout: 30, // 30秒
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Default: RateLimitRule{Limit: 300, Period: 60},
+			Rules: []RateLimitRule{
+				{Method: "/translation.Translation/Translation", Limit: 30, Period: 60},
+				{Method: "/translation.Translation/Translation", MembershipLevel: 1, Limit: 120, Period: 60},
+				{Method: "/translation.Translation/BatchTranslate", Limit: 5, Period: 60},
+				{Method: "/translation.Translation/BatchTranslate", MembershipLevel: 1, Limit: 30, Period: 60},
+				{Method: "/translation.Translation/TranslateImage", Limit: 5, Period: 60},
+				{Method: "/translation.Translation/TranslateImage", MembershipLevel: 1, Limit: 30, Period: 60},
+				{Method: "/user.UserService/SendEmailCaptcha", Limit: 5, Period: 600},
+				{Method: "/user.UserService/Login", Limit: 20, Period: 60},
+				{Method: "/user.UserService/Register", Limit: 10, Period: 3600},
+			},
+		},
+		Gateway: GatewayConfig{
+			Mode: GatewayModeInProcess,
+			CORS: CORSConfig{
+				AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+				AllowedHeaders: []string{"Authorization", "Content-Type", "Accept-Language", "X-Request-Id", "Idempotency-Key", "X-Grpc-Web", "X-User-Agent", "Grpc-Timeout"},
+				ExposedHeaders: []string{"X-Request-Id", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"},
+				MaxAge:         600,
+			},
+			Compression:       true,
+			MaxRequestBody:    1024, // 1MB
+			AccessLog:         true,
+			GRPCWeb:           true,
+			ReadHeaderTimeout: 10,
+			ReadTimeout:       60,
+			IdleTimeout:       120,
+		},
+		TLS: TLSConfig{
+			ServerName:     "localhost",
+			ReloadInterval: 60,
+			MTLS: MTLSConfig{
+				GRPC:  true,
+				HTTP:  false,
+				Admin: true,
+			},
 		},
 	}
+}
 
-	return config, nil
+// Options 配置加载选项，通常来自命令行参数
+type Options struct {
+	File      string   // 配置文件路径，为空时使用CONFIG_FILE环境变量
+	Overrides []string // 命令行覆盖项，格式为 server.http_port=9000
+	Sections  []string // 只校验这些配置节（见SectionServer等），为空时校验全部
 }
 
-// getEnv 获取环境变量，如果不存在则返回默认值
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+// LoadConfig 使用默认选项加载配置
+func LoadConfig() (*Config, error) {
+	return Load(Options{})
 }
 
-// getEnvAsInt 获取环境变量并转换为整数，如果不存在或转换失败则返回默认值
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// Load 按 默认值 < 配置文件 < 环境变量(.env) < 命令行 的优先级加载配置，并校验opts.Sections中的配置节
+func Load(opts Options) (*Config, error) {
+	env, err := newEnvSource(dotEnvFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	file := opts.File
+	if file == "" {
+		file, _, err = env.lookup("CONFIG_FILE")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if file != "" {
+		if err := loadFile(file, cfg); err != nil {
+			return nil, err
 		}
 	}
-	return defaultValue
+	if err := applyEnv(cfg, env); err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(cfg, opts.Overrides); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateSections(opts.Sections...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
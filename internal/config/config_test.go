@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdirTemp 切换到临时目录，避免读取仓库中的.env
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	return dir
+}
+
+// writeFile 在dir中写入文件并返回路径
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := chdirTemp(t)
+	file := writeFile(t, dir, "config.yaml", `
+server:
+  http_port: 7000
+database:
+  host: file-host
+  db_name: file-db
+logger:
+  level: debug
+redis:
+  port: 6380
+`)
+	writeFile(t, dir, dotEnvFile, "DB_HOST=dotenv-host\nDB_NAME=dotenv-db\n")
+	t.Setenv("HTTP_PORT", "7001")
+	t.Setenv("DB_HOST", "env-host")
+
+	cfg, err := Load(Options{File: file, Overrides: []string{"server.http_port=7002"}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "default", got: cfg.Server.Port, want: 8080},
+		{name: "file over default", got: cfg.Logger.Level, want: "debug"},
+		{name: "file over default", got: cfg.Redis.Port, want: 6380},
+		{name: "dotenv over file", got: cfg.Database.DBName, want: "dotenv-db"},
+		{name: "env over dotenv", got: cfg.Database.Host, want: "env-host"},
+		{name: "flag over env", got: cfg.Server.HTTPPort, want: 7002},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	dir := chdirTemp(t)
+	file := writeFile(t, dir, "config.toml", "[logger]\nlevel = \"warn\"\n")
+	t.Setenv("CONFIG_FILE", file)
+
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Logger.Level != "warn" {
+		t.Fatalf("Logger.Level = %q, want warn", cfg.Logger.Level)
+	}
+}
+
+func TestLoadRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		env       map[string]string
+		overrides []string
+		wantErr   string
+	}{
+		{name: "unknown file field", file: "logger:\n  colour: red\n", wantErr: "colour"},
+		{name: "invalid env integer", env: map[string]string{"SERVER_PORT": "abc"}, wantErr: "SERVER_PORT不是合法的整数"},
+		{name: "unknown override", overrides: []string{"server.unknown=1"}, wantErr: "未知的配置项"},
+		{name: "malformed override", overrides: []string{"server.port"}, wantErr: "key=value"},
+		{name: "validation", overrides: []string{"logger.level=trace"}, wantErr: "logger.level"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			opts := Options{Overrides: tt.overrides}
+			if tt.file != "" {
+				opts.File = writeFile(t, dir, "config.yaml", tt.file)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load(opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFileIndirection(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		env     map[string]string
+		want    string
+		wantErr string
+	}{
+		{name: "trailing newline trimmed", secret: "file-secret\n", want: "file-secret"},
+		{name: "both set", secret: "file-secret", env: map[string]string{"JWT_SECRET_KEY": "env-secret"}, wantErr: "不能同时设置"},
+		{name: "missing file", env: map[string]string{"JWT_SECRET_KEY_FILE": "missing"}, wantErr: "JWT_SECRET_KEY_FILE指定的文件"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			if tt.secret != "" {
+				t.Setenv("JWT_SECRET_KEY_FILE", writeFile(t, dir, "jwt", tt.secret))
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load(Options{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.JWT.SecretKey != tt.want {
+				t.Fatalf("JWT.SecretKey = %q, want %q", cfg.JWT.SecretKey, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// dotEnvFile 默认读取的.env文件，不存在时忽略
+const dotEnvFile = ".env"
+
+// fileSuffix 以该后缀结尾的环境变量表示从文件读取值，例如 JWT_SECRET_KEY_FILE=/run/secrets/jwt
+const fileSuffix = "_FILE"
+
+// envSource 环境变量来源，进程环境变量优先于.env文件
+type envSource struct {
+	dotEnv map[string]string
+}
+
+// newEnvSource 读取.env文件，每次加载都重新读取，保证热加载能感知.env的变化
+func newEnvSource(path string) (*envSource, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("读取%s失败: %w", path, err)
+		}
+		values = map[string]string{}
+	}
+	return &envSource{dotEnv: values}, nil
+}
+
+// get 读取原始值，不处理_FILE
+func (e *envSource) get(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value, true
+	}
+	if value, ok := e.dotEnv[key]; ok && value != "" {
+		return value, true
+	}
+	return "", false
+}
+
+// lookup 读取key的值，设置了key_FILE时从对应文件读取，两者不能同时设置
+func (e *envSource) lookup(key string) (string, bool, error) {
+	value, ok := e.get(key)
+	path, fromFile := e.get(key + fileSuffix)
+	if !fromFile {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("%s和%s不能同时设置", key, key+fileSuffix)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("读取%s指定的文件失败: %w", key+fileSuffix, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// envBinder 将环境变量绑定到配置字段，未设置的变量保持字段原值，解析失败的错误汇总后返回
+type envBinder struct {
+	source *envSource
+	errs   []error
+}
+
+// string 绑定字符串字段
+func (b *envBinder) string(target *string, key string) {
+	value, ok, err := b.source.lookup(key)
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return
+	}
+	if ok {
+		*target = value
+	}
+}
+
+// int 绑定整数字段
+func (b *envBinder) int(target *int, key string) {
+	value, ok, err := b.source.lookup(key)
+	if err != nil || !ok {
+		b.errs = appendIf(b.errs, err)
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("%s不是合法的整数: %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// bool 绑定布尔字段
+func (b *envBinder) bool(target *bool, key string) {
+	value, ok, err := b.source.lookup(key)
+	if err != nil || !ok {
+		b.errs = appendIf(b.errs, err)
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("%s不是合法的布尔值: %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// float 绑定浮点数字段
+func (b *envBinder) float(target *float64, key string) {
+	value, ok, err := b.source.lookup(key)
+	if err != nil || !ok {
+		b.errs = appendIf(b.errs, err)
+		return
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("%s不是合法的数字: %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// strings 绑定字符串列表字段，多个值以逗号分隔
+func (b *envBinder) strings(target *[]string, key string) {
+	value, ok, err := b.source.lookup(key)
+	if err != nil || !ok {
+		b.errs = appendIf(b.errs, err)
+		return
+	}
+	*target = splitList(value)
+}
+
+// splitList 按逗号拆分并去掉空白和空值
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// appendIf err不为空时追加
+func appendIf(errs []error, err error) []error {
+	if err != nil {
+		return append(errs, err)
+	}
+	return errs
+}
+
+// applyEnv 使用环境变量覆盖配置
+func applyEnv(cfg *Config, source *envSource) error {
+	b := &envBinder{source: source}
+
+	b.int(&cfg.Server.Port, "SERVER_PORT")
+	b.int(&cfg.Server.HTTPPort, "HTTP_PORT")
+	b.int(&cfg.Server.AdminPort, "ADMIN_PORT")
+	b.string(&cfg.Server.AdminHost, "ADMIN_HOST")
+	b.string(&cfg.Server.AdminToken, "ADMIN_TOKEN")
+	b.string(&cfg.Server.Host, "SERVER_HOST")
+	b.string(&cfg.Server.Env, "APP_ENV")
+
+	b.string(&cfg.Database.Host, "DB_HOST")
+	b.int(&cfg.Database.Port, "DB_PORT")
+	b.string(&cfg.Database.User, "DB_USER")
+	b.string(&cfg.Database.Password, "DB_PASSWORD")
+	b.string(&cfg.Database.DBName, "DB_NAME")
+	b.string(&cfg.Database.SSLMode, "DB_SSL_MODE")
+	b.string(&cfg.Database.TimeZone, "DB_TIMEZONE")
+	b.bool(&cfg.Database.AutoMigrate, "DB_AUTO_MIGRATE")
+
+	b.string(&cfg.Logger.Level, "LOG_LEVEL")
+	b.string(&cfg.Logger.Format, "LOG_FORMAT")
+
+	b.string(&cfg.JWT.SecretKey, "JWT_SECRET_KEY")
+	b.int(&cfg.JWT.AccessTokenDuration, "JWT_ACCESS_TOKEN_DURATION")
+	b.int(&cfg.JWT.RefreshTokenDuration, "JWT_REFRESH_TOKEN_DURATION")
+
+	b.string(&cfg.Redis.Host, "REDIS_HOST")
+	b.int(&cfg.Redis.Port, "REDIS_PORT")
+	b.string(&cfg.Redis.Password, "REDIS_PASSWORD")
+	b.int(&cfg.Redis.DB, "REDIS_DB")
+
+	b.string(&cfg.Email.SMTPHost, "SMTP_HOST")
+	b.int(&cfg.Email.SMTPPort, "SMTP_PORT")
+	b.string(&cfg.Email.SMTPUsername, "SMTP_USERNAME")
+	b.string(&cfg.Email.SMTPPassword, "SMTP_PASSWORD")
+	b.string(&cfg.Email.FromEmail, "FROM_EMAIL")
+	b.string(&cfg.Email.FromName, "FROM_NAME")
+
+	b.string(&cfg.TransferConfig.URL, "TRANSFER_URL")
+	b.string(&cfg.TransferConfig.AppKey, "APP_KEY")
+	b.string(&cfg.TransferConfig.AppSecret, "APP_SECRET")
+	b.int(&cfg.TransferConfig.MaxConcurrency, "TRANSLATION_MAX_CONCURRENCY")
+	b.int(&cfg.TransferConfig.MaxWait, "TRANSLATION_MAX_WAIT")
+	b.int(&cfg.TransferConfig.BatchConcurrency, "TRANSLATION_BATCH_CONCURRENCY")
+	b.bool(&cfg.TransferConfig.CircuitBreaker.Enabled, "TRANSLATION_BREAKER_ENABLED")
+	b.float(&cfg.TransferConfig.CircuitBreaker.FailureRate, "TRANSLATION_BREAKER_FAILURE_RATE")
+	b.int(&cfg.TransferConfig.CircuitBreaker.OpenTimeout, "TRANSLATION_BREAKER_OPEN_TIMEOUT")
+	b.string((*string)(&cfg.TransferConfig.OCR.Engine), "OCR_ENGINE")
+	b.string(&cfg.TransferConfig.OCR.URL, "OCR_URL")
+	b.int(&cfg.TransferConfig.OCR.MaxImageSize, "OCR_MAX_IMAGE_SIZE")
+
+	b.int(&cfg.Trash.RetentionDays, "TRASH_RETENTION_DAYS")
+	b.int(&cfg.Trash.PurgeInterval, "TRASH_PURGE_INTERVAL")
+
+	b.int(&cfg.Health.CheckInterval, "HEALTH_CHECK_INTERVAL")
+	b.int(&cfg.Health.CheckTimeout, "HEALTH_CHECK_TIMEOUT")
+
+	b.string(&cfg.Tracing.Exporter, "TRACING_EXPORTER")
+	b.string(&cfg.Tracing.OTLPEndpoint, "TRACING_OTLP_ENDPOINT")
+	b.bool(&cfg.Tracing.OTLPInsecure, "TRACING_OTLP_INSECURE")
+	b.string(&cfg.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+	b.float(&cfg.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+
+	b.int(&cfg.HotReload.Interval, "CONFIG_RELOAD_INTERVAL")
+
+	b.int(&cfg.Shutdown.DrainPeriod, "SHUTDOWN_DRAIN_PERIOD")
+	b.int(&cfg.Shutdown.Timeout, "SHUTDOWN_TIMEOUT")
+	b.int(&cfg.Shutdown.WorkerDrainTimeout, "SHUTDOWN_WORKER_DRAIN_TIMEOUT")
+
+	b.int(&cfg.HTTPClient.Timeout, "HTTP_CLIENT_TIMEOUT")
+	b.int(&cfg.HTTPClient.MaxRetries, "HTTP_CLIENT_MAX_RETRIES")
+	b.int(&cfg.HTTPClient.MaxResponseSize, "HTTP_CLIENT_MAX_RESPONSE_SIZE")
+
+	b.int(&cfg.Idempotency.TTL, "IDEMPOTENCY_TTL")
+	b.int(&cfg.Idempotency.LockTimeout, "IDEMPOTENCY_LOCK_TIMEOUT")
+
+	b.bool(&cfg.RateLimit.Enabled, "RATE_LIMIT_ENABLED")
+	b.int(&cfg.RateLimit.Default.Limit, "RATE_LIMIT_DEFAULT_LIMIT")
+	b.int(&cfg.RateLimit.Default.Period, "RATE_LIMIT_DEFAULT_PERIOD")
+	b.int(&cfg.RateLimit.Default.Burst, "RATE_LIMIT_DEFAULT_BURST")
+
+	b.string(&cfg.Gateway.Mode, "GATEWAY_MODE")
+	b.strings(&cfg.Gateway.CORS.AllowedOrigins, "GATEWAY_CORS_ALLOWED_ORIGINS")
+	b.bool(&cfg.Gateway.CORS.AllowCredentials, "GATEWAY_CORS_ALLOW_CREDENTIALS")
+	b.bool(&cfg.Gateway.Compression, "GATEWAY_COMPRESSION")
+	b.int(&cfg.Gateway.MaxRequestBody, "GATEWAY_MAX_REQUEST_BODY")
+	b.bool(&cfg.Gateway.AccessLog, "GATEWAY_ACCESS_LOG")
+	b.bool(&cfg.Gateway.GRPCWeb, "GATEWAY_GRPC_WEB")
+	b.int(&cfg.Gateway.ReadHeaderTimeout, "GATEWAY_READ_HEADER_TIMEOUT")
+	b.int(&cfg.Gateway.ReadTimeout, "GATEWAY_READ_TIMEOUT")
+	b.int(&cfg.Gateway.IdleTimeout, "GATEWAY_IDLE_TIMEOUT")
+
+	// 证书路径使用_PATH后缀，避免与从文件读取值的_FILE约定混淆
+	b.bool(&cfg.TLS.Enabled, "TLS_ENABLED")
+	b.string(&cfg.TLS.CertFile, "TLS_CERT_PATH")
+	b.string(&cfg.TLS.KeyFile, "TLS_KEY_PATH")
+	b.string(&cfg.TLS.ClientCAFile, "TLS_CLIENT_CA_PATH")
+	b.bool(&cfg.TLS.MTLS.GRPC, "TLS_MTLS_GRPC")
+	b.bool(&cfg.TLS.MTLS.HTTP, "TLS_MTLS_HTTP")
+	b.bool(&cfg.TLS.MTLS.Admin, "TLS_MTLS_ADMIN")
+	b.string(&cfg.TLS.CAFile, "TLS_CA_PATH")
+	b.string(&cfg.TLS.ClientCertFile, "TLS_CLIENT_CERT_PATH")
+	b.string(&cfg.TLS.ClientKeyFile, "TLS_CLIENT_KEY_PATH")
+	b.string(&cfg.TLS.ServerName, "TLS_SERVER_NAME")
+	b.int(&cfg.TLS.ReloadInterval, "TLS_RELOAD_INTERVAL")
+
+	return errors.Join(b.errs...)
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile 读取YAML、TOML或JSON配置文件并覆盖cfg中对应的字段。
+// 文件先转换为JSON再解码，字段名统一使用结构体的json标签，未知字段视为错误。
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var values map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	case ".json":
+		err = json.Unmarshal(content, &values)
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+	}
+
+	if err := decodeStrict(values, cfg); err != nil {
+		return fmt.Errorf("配置文件%s错误: %w", path, err)
+	}
+	return nil
+}
+
+// decodeStrict 将通用的键值结构解码到cfg中，不允许未知字段
+func decodeStrict(values map[string]interface{}, cfg *Config) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+// applyOverrides 应用命令行覆盖项，键为json标签组成的路径，例如 logger.level=debug
+func applyOverrides(cfg *Config, overrides []string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	for _, override := range overrides {
+		key, raw, ok := strings.Cut(override, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("覆盖项格式错误，应为key=value: %q", override)
+		}
+		if err := setPath(values, strings.Split(key, "."), raw); err != nil {
+			return fmt.Errorf("覆盖项%s错误: %w", key, err)
+		}
+	}
+
+	*cfg = Config{}
+	return decodeStrict(values, cfg)
+}
+
+// setPath 按路径设置值，值的类型由原有字段的类型决定
+func setPath(values map[string]interface{}, path []string, raw string) error {
+	current, ok := values[path[0]]
+	if !ok {
+		return fmt.Errorf("未知的配置项: %s", path[0])
+	}
+	if len(path) > 1 {
+		nested, ok := current.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s不是配置分组", path[0])
+		}
+		return setPath(nested, path[1:], raw)
+	}
+
+	switch current.(type) {
+	case string:
+		values[path[0]] = raw
+	case float64:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("不是合法的数字: %q", raw)
+		}
+		values[path[0]] = number
+	case bool:
+		flag, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("不是合法的布尔值: %q", raw)
+		}
+		values[path[0]] = flag
+	case []interface{}, nil:
+		// 列表以逗号分隔，例如 gateway.cors.allowed_origins=https://a.com,https://b.com
+		var items []interface{}
+		for _, item := range splitList(raw) {
+			items = append(items, item)
+		}
+		values[path[0]] = items
+	default:
+		return fmt.Errorf("%s是配置分组，不能直接赋值", path[0])
+	}
+	return nil
+}
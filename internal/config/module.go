@@ -1,10 +1,40 @@
 package config
 
 import (
+	"context"
+
 	"go.uber.org/fx"
 )
 
+// Params 配置加载的依赖，命令行选项由入口通过fx.Supply提供
+type Params struct {
+	fx.In
+
+	Options *Options `optional:"true"`
+}
+
+// NewConfig 按命令行选项加载配置
+func NewConfig(p Params) (*Config, error) {
+	if p.Options == nil {
+		return LoadConfig()
+	}
+	return Load(*p.Options)
+}
+
 // Module 配置模块
 var Module = fx.Options(
-	fx.Provide(LoadConfig),
+	fx.Provide(NewConfig),
+	fx.Provide(NewWatcher),
+	fx.Invoke(func(lc fx.Lifecycle, watcher *Watcher) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				watcher.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				watcher.Stop()
+				return nil
+			},
+		})
+	}),
 )
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// defaultJWTSecret 开发环境默认的JWT密钥，生产环境禁止使用
+const defaultJWTSecret = "your-secret-key-change-in-production"
+
+// minProductionSecretLength 生产环境JWT密钥的最小长度
+const minProductionSecretLength = 32
+
+// insecureSecrets 示例配置中出现过的密钥，生产环境禁止使用
+var insecureSecrets = []string{
+	defaultJWTSecret,
+	"your-super-secret-jwt-key-change-in-production",
+}
+
+// IsProduction 是否为生产环境
+func (c *Config) IsProduction() bool {
+	return c.Server.Env == EnvProduction
+}
+
+// 配置节，与配置文件的顶层字段对应。子命令通过Options.Sections只校验自己用到的配置节，
+// 例如migrate不需要邮件和翻译引擎，生产环境下也不要求配置它们
+const (
+	SectionServer      = "server" // 端口以及只有serve使用的配置：回收站、健康检查、热加载、优雅关闭、幂等、限流、网关、TLS、链路追踪
+	SectionDatabase    = "database"
+	SectionLogger      = "logger"
+	SectionJWT         = "jwt"
+	SectionRedis       = "redis"
+	SectionEmail       = "email"
+	SectionTranslation = "transfer_config"
+	SectionHTTPClient  = "http_client"
+)
+
+// checkFunc 记录一条校验失败
+type checkFunc func(ok bool, format string, args ...interface{})
+
+// sectionValidators 各配置节的校验，按顺序执行以保证错误信息的顺序稳定
+var sectionValidators = []struct {
+	section  string
+	validate func(c *Config, check checkFunc)
+}{
+	{SectionServer, validateServer},
+	{SectionDatabase, validateDatabase},
+	{SectionLogger, validateLogger},
+	{SectionJWT, validateJWT},
+	{SectionRedis, validateRedis},
+	{SectionEmail, validateEmail},
+	{SectionTranslation, validateTranslation},
+	{SectionHTTPClient, validateHTTPClient},
+}
+
+// Validate 校验全部配置，返回所有错误。生产环境额外要求密钥不是默认值，并配置了邮件和翻译引擎
+func (c *Config) Validate() error {
+	return c.ValidateSections()
+}
+
+// ValidateSections 只校验给定的配置节，为空时校验全部。server.env总是校验，各配置节的生产环境要求依赖它
+func (c *Config) ValidateSections(sections ...string) error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(oneOf(c.Server.Env, EnvDevelopment, EnvTest, EnvProduction), "server.env必须是development、test或production: %s", c.Server.Env)
+	for _, v := range sectionValidators {
+		if len(sections) == 0 || oneOf(v.section, sections...) {
+			v.validate(c, check)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validateServer 校验端口以及只有serve使用的配置
+func validateServer(c *Config, check checkFunc) {
+	check(validPort(c.Server.Port), "server.port不是合法的端口: %d", c.Server.Port)
+	check(validPort(c.Server.HTTPPort), "server.http_port不是合法的端口: %d", c.Server.HTTPPort)
+	check(c.Server.AdminPort == 0 || validPort(c.Server.AdminPort), "server.admin_port不是合法的端口: %d", c.Server.AdminPort)
+	check(c.Server.Port != c.Server.HTTPPort, "server.port和server.http_port不能相同")
+	check(c.Server.AdminPort == 0 || (c.Server.AdminPort != c.Server.Port && c.Server.AdminPort != c.Server.HTTPPort),
+		"server.admin_port不能与其他端口相同")
+	check(c.Server.AdminPort == 0 || isLoopback(c.Server.AdminHost) || c.Server.AdminToken != "" || c.TLS.RequireClientCert(c.TLS.MTLS.Admin),
+		"server.admin_host不是本机地址时必须设置server.admin_token或开启mTLS: %q", c.Server.AdminHost)
+
+	check(c.Trash.RetentionDays >= 0, "trash.retention_days不能小于0")
+	check(c.Trash.PurgeInterval >= 0, "trash.purge_interval不能小于0")
+	check(c.Health.CheckInterval >= 0, "health.check_interval不能小于0")
+	check(c.Health.CheckTimeout > 0, "health.check_timeout必须大于0")
+	check(c.HotReload.Interval >= 0, "hot_reload.interval不能小于0")
+	check(c.Shutdown.DrainPeriod >= 0 && c.Shutdown.Timeout >= 0 && c.Shutdown.WorkerDrainTimeout >= 0, "shutdown的各项时间不能小于0")
+	check(c.Shutdown.DrainPeriod+c.Shutdown.Timeout+c.Shutdown.WorkerDrainTimeout <= MaxShutdownSeconds,
+		"shutdown.drain_period、timeout和worker_drain_timeout之和不能超过%d秒", MaxShutdownSeconds)
+	check(c.Idempotency.TTL > 0, "idempotency.ttl必须大于0")
+	check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout必须大于0")
+
+	validateRateLimitRule("rate_limit.default", c.RateLimit.Default, check)
+	seen := make(map[string]bool, len(c.RateLimit.Rules))
+	for i, rule := range c.RateLimit.Rules {
+		name := fmt.Sprintf("rate_limit.rules[%d]", i)
+		check(strings.HasPrefix(rule.Method, "/"), "%s.method必须是完整方法名，例如/translation.Translation/Translation: %q", name, rule.Method)
+		check(rule.MembershipLevel >= 0, "%s.membership_level不能小于0", name)
+		key := fmt.Sprintf("%s#%d", rule.Method, rule.MembershipLevel)
+		check(!seen[key], "%s与其他规则的method和membership_level重复", name)
+		seen[key] = true
+		validateRateLimitRule(name, rule, check)
+	}
+
+	check(oneOf(c.Gateway.Mode, GatewayModeInProcess, GatewayModeEndpoint), "gateway.mode必须是inprocess或endpoint: %s", c.Gateway.Mode)
+	check(c.Gateway.MaxRequestBody >= 0, "gateway.max_request_body不能小于0")
+	check(c.Gateway.CORS.MaxAge >= 0, "gateway.cors.max_age不能小于0")
+	check(c.Gateway.ReadHeaderTimeout >= 0 && c.Gateway.ReadTimeout >= 0 && c.Gateway.IdleTimeout >= 0, "gateway的各项超时时间不能小于0")
+	check(!c.Gateway.CORS.AllowCredentials || !oneOf("*", c.Gateway.CORS.AllowedOrigins...),
+		"gateway.cors.allow_credentials为true时allowed_origins不能包含\"*\"")
+
+	if c.TLS.Enabled {
+		check(c.TLS.CertFile != "" && c.TLS.KeyFile != "", "开启TLS时tls.cert_file和tls.key_file不能为空")
+		check(c.TLS.ReloadInterval >= 0, "tls.reload_interval不能小于0")
+		check((c.TLS.ClientCertFile == "") == (c.TLS.ClientKeyFile == ""), "tls.client_cert_file和tls.client_key_file必须同时设置")
+		check(c.Gateway.Mode != GatewayModeEndpoint || !c.TLS.RequireClientCert(c.TLS.MTLS.GRPC) || c.TLS.ClientCertFile != "",
+			"开启mTLS且gateway.mode为endpoint时需要配置tls.client_cert_file")
+	}
+
+	check(oneOf(strings.ToLower(c.Tracing.Exporter), "", "none", "otlp", "stdout"), "tracing.exporter必须是otlp、stdout或none: %s", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio必须在0到1之间")
+}
+
+// validateDatabase 校验数据库连接
+func validateDatabase(c *Config, check checkFunc) {
+	check(validPort(c.Database.Port), "database.port不是合法的端口: %d", c.Database.Port)
+	check(c.Database.Host != "" && c.Database.DBName != "", "database.host和database.db_name不能为空")
+	if c.IsProduction() {
+		check(c.Database.Password != "" && c.Database.Password != "password", "生产环境不能使用默认的database.password")
+	}
+}
+
+// validateLogger 校验日志
+func validateLogger(c *Config, check checkFunc) {
+	check(oneOf(c.Logger.Level, "debug", "info", "warn", "error"), "logger.level必须是debug、info、warn或error: %s", c.Logger.Level)
+	check(oneOf(c.Logger.Format, "json", "console"), "logger.format必须是json或console: %s", c.Logger.Format)
+}
+
+// validateJWT 校验令牌签发
+func validateJWT(c *Config, check checkFunc) {
+	check(c.JWT.SecretKey != "", "jwt.secret_key不能为空")
+	check(c.JWT.AccessTokenDuration > 0, "jwt.access_token_duration必须大于0")
+	check(c.JWT.RefreshTokenDuration > 0, "jwt.refresh_token_duration必须大于0")
+	if c.IsProduction() {
+		check(!oneOf(c.JWT.SecretKey, insecureSecrets...), "生产环境不能使用默认的jwt.secret_key")
+		check(len(c.JWT.SecretKey) >= minProductionSecretLength, "生产环境jwt.secret_key至少需要%d个字符", minProductionSecretLength)
+	}
+}
+
+// validateRedis 校验Redis连接
+func validateRedis(c *Config, check checkFunc) {
+	check(validPort(c.Redis.Port), "redis.port不是合法的端口: %d", c.Redis.Port)
+}
+
+// validateEmail 校验验证码邮件
+func validateEmail(c *Config, check checkFunc) {
+	check(validPort(c.Email.SMTPPort), "email.smtp_port不是合法的端口: %d", c.Email.SMTPPort)
+	if c.IsProduction() {
+		check(c.Email.SMTPUsername != "" && c.Email.SMTPPassword != "" && c.Email.FromEmail != "",
+			"生产环境必须配置email.smtp_username、email.smtp_password和email.from_email")
+	}
+}
+
+// validateTranslation 校验翻译引擎
+func validateTranslation(c *Config, check checkFunc) {
+	check(c.TransferConfig.MaxConcurrency >= 0, "transfer_config.max_concurrency不能小于0")
+	check(c.TransferConfig.MaxWait >= 0, "transfer_config.max_wait不能小于0")
+	check(c.TransferConfig.BatchConcurrency > 0, "transfer_config.batch_concurrency必须大于0")
+	if breaker := c.TransferConfig.CircuitBreaker; breaker.Enabled {
+		check(breaker.Window > 0, "transfer_config.circuit_breaker.window必须大于0")
+		check(breaker.MinRequests > 0, "transfer_config.circuit_breaker.min_requests必须大于0")
+		check(breaker.FailureRate > 0 && breaker.FailureRate <= 1, "transfer_config.circuit_breaker.failure_rate必须在(0, 1]之间")
+		check(breaker.OpenTimeout > 0, "transfer_config.circuit_breaker.open_timeout必须大于0")
+		check(breaker.HalfOpenRequests > 0, "transfer_config.circuit_breaker.half_open_requests必须大于0")
+	}
+	check(c.TransferConfig.OCR.Engine == YOUDAO || c.TransferConfig.OCR.Engine == FAKE,
+		"transfer_config.ocr.engine必须是%s或%s: %s", YOUDAO, FAKE, c.TransferConfig.OCR.Engine)
+	check(c.TransferConfig.OCR.MaxImageSize > 0, "transfer_config.ocr.max_image_size必须大于0")
+	if c.IsProduction() {
+		check(c.TransferConfig.AppKey != "" && c.TransferConfig.AppSecret != "",
+			"生产环境必须配置transfer_config.app_key和transfer_config.app_secret")
+		check(c.TransferConfig.OCR.Engine != FAKE, "生产环境不能使用%s图片翻译引擎", FAKE)
+	}
+}
+
+// validateHTTPClient 校验调用外部服务的HTTP客户端
+func validateHTTPClient(c *Config, check checkFunc) {
+	check(c.HTTPClient.Timeout > 0, "http_client.timeout必须大于0")
+	check(c.HTTPClient.MaxRetries >= 0, "http_client.max_retries不能小于0")
+	check(c.HTTPClient.RetryBackoff >= 0 && c.HTTPClient.MaxRetryBackoff >= c.HTTPClient.RetryBackoff,
+		"http_client.retry_backoff不能小于0且不能大于max_retry_backoff")
+	check(c.HTTPClient.MaxResponseSize > 0, "http_client.max_response_size必须大于0")
+	check(c.HTTPClient.MaxIdleConnsPerHost >= 0 && c.HTTPClient.MaxConnsPerHost >= 0, "http_client的连接数不能小于0")
+	for i, host := range c.HTTPClient.Hosts {
+		check(host.Host != "", "http_client.hosts[%d].host不能为空", i)
+		check(host.Timeout > 0, "http_client.hosts[%d].timeout必须大于0", i)
+	}
+}
+
+// validateRateLimitRule 校验限流规则的限额
+func validateRateLimitRule(name string, rule RateLimitRule, check checkFunc) {
+	check(rule.Limit >= 0, "%s.limit不能小于0", name)
+	check(rule.Limit <= 0 || rule.Period > 0, "%s.period必须大于0", name)
+	check(rule.Burst >= 0, "%s.burst不能小于0", name)
+}
+
+// validPort 端口是否在合法范围内
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// isLoopback host是否为本机地址，为空表示监听所有地址
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// oneOf value是否为候选值之一
+func oneOf(value string, candidates ...string) bool {
+	for _, candidate := range candidates {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
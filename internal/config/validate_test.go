@@ -0,0 +1,89 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// productionConfig 返回可以通过生产环境校验的配置
+func productionConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.Server.Env = EnvProduction
+	cfg.Database.Password = "database-password"
+	cfg.JWT.SecretKey = strings.Repeat("s", minProductionSecretLength)
+	cfg.Email.SMTPUsername = "smtp-user"
+	cfg.Email.SMTPPassword = "smtp-password"
+	cfg.Email.FromEmail = "noreply@example.com"
+	cfg.TransferConfig.AppKey = "app-key"
+	cfg.TransferConfig.AppSecret = "app-secret"
+	return cfg
+}
+
+func TestValidateProduction(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(c *Config)
+		sections []string
+		wantErr  string
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{name: "default database password", modify: func(c *Config) { c.Database.Password = "password" }, wantErr: "database.password"},
+		{name: "default jwt secret", modify: func(c *Config) { c.JWT.SecretKey = defaultJWTSecret }, wantErr: "默认的jwt.secret_key"},
+		{name: "short jwt secret", modify: func(c *Config) { c.JWT.SecretKey = "short" }, wantErr: "jwt.secret_key至少需要32个字符"},
+		{name: "missing email", modify: func(c *Config) { c.Email.SMTPPassword = "" }, wantErr: "email.smtp_username"},
+		{name: "missing translation credentials", modify: func(c *Config) { c.TransferConfig.AppSecret = "" }, wantErr: "transfer_config.app_key"},
+		{name: "fake ocr engine", modify: func(c *Config) { c.TransferConfig.OCR.Engine = FAKE }, wantErr: "图片翻译引擎"},
+		{name: "exposed admin port", modify: func(c *Config) { c.Server.AdminHost = "0.0.0.0" }, wantErr: "server.admin_host"},
+		{
+			name:     "unvalidated sections skipped",
+			modify:   func(c *Config) { c.Email.SMTPPassword = ""; c.TransferConfig.AppSecret = "" },
+			sections: []string{SectionDatabase, SectionLogger},
+		},
+		{
+			name:     "env always validated",
+			modify:   func(c *Config) { c.Server.Env = "staging" },
+			sections: []string{SectionDatabase},
+			wantErr:  "server.env",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := productionConfig()
+			tt.modify(cfg)
+
+			err := cfg.ValidateSections(tt.sections...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateSections() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateSections() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := productionConfig()
+	cfg.Database.Password = "password"
+	cfg.JWT.SecretKey = defaultJWTSecret
+	cfg.Email.FromEmail = ""
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{"database.password", "jwt.secret_key", "email.from_email"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want containing %q", err, want)
+		}
+	}
+}
+
+func TestValidateDefaultConfig(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("DefaultConfig().Validate() error = %v", err)
+	}
+}
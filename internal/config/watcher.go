@@ -0,0 +1,207 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Subscriber 配置热加载的订阅者，只有可热加载的字段变化时才会收到通知
+type Subscriber interface {
+	ConfigReloaded(old, updated *Config)
+}
+
+// SubscriberFunc 函数形式的订阅者
+type SubscriberFunc func(old, updated *Config)
+
+// ConfigReloaded 实现Subscriber
+func (f SubscriberFunc) ConfigReloaded(old, updated *Config) {
+	f(old, updated)
+}
+
+// AsSubscriber 将构造函数的结果注册为配置订阅者
+func AsSubscriber(constructor interface{}) interface{} {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(Subscriber)),
+		fx.ResultTags(`group:"config_subscribers"`),
+	)
+}
+
+// applyReloadable 将可以安全热加载的字段从src复制到dst，其余字段需要重启才能生效
+func applyReloadable(dst, src *Config) {
+	dst.Logger.Level = src.Logger.Level
+	dst.Trash = src.Trash
+	dst.RateLimit = src.RateLimit
+}
+
+// WatcherParams 配置监听器的依赖
+type WatcherParams struct {
+	fx.In
+
+	Config      *Config
+	Options     *Options     `optional:"true"`
+	Subscribers []Subscriber `group:"config_subscribers"`
+	Logger      *zap.Logger
+}
+
+// Watcher 监听配置文件和.env的变化以及SIGHUP信号，重新加载配置并通知订阅者
+type Watcher struct {
+	options     Options
+	subscribers []Subscriber
+	logger      *zap.Logger
+	interval    time.Duration
+
+	mu       sync.Mutex
+	current  *Config
+	modTimes map[string]time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewWatcher 创建配置监听器
+func NewWatcher(p WatcherParams) *Watcher {
+	var options Options
+	if p.Options != nil {
+		options = *p.Options
+	}
+	w := &Watcher{
+		options:     options,
+		subscribers: p.Subscribers,
+		logger:      p.Logger,
+		interval:    time.Duration(p.Config.HotReload.Interval) * time.Second,
+		current:     p.Config,
+	}
+	w.modTimes = w.snapshot()
+	return w
+}
+
+// Current 返回最近一次加载成功的配置
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Start 开始监听
+func (w *Watcher) Start() {
+	w.stop = make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer signal.Stop(signals)
+
+		// 间隔为0时只响应SIGHUP
+		var tick <-chan time.Time
+		if w.interval > 0 {
+			ticker := time.NewTicker(w.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-signals:
+				w.logger.Info("Received SIGHUP, reloading config")
+				w.Reload()
+			case <-tick:
+				if w.changed() {
+					w.logger.Info("Config file changed, reloading config")
+					w.Reload()
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止监听
+func (w *Watcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	w.wg.Wait()
+}
+
+// Reload 重新加载配置。加载或校验失败时保留当前配置；
+// 只有可热加载的字段会生效，其他字段的变化记录警告并在重启后生效
+func (w *Watcher) Reload() bool {
+	loaded, err := Load(w.options)
+	if err != nil {
+		w.logger.Error("Failed to reload config, keeping current config", zap.Error(err))
+		return false
+	}
+
+	w.mu.Lock()
+	old := w.current
+	next := *old
+	applyReloadable(&next, loaded)
+	if !reflect.DeepEqual(&next, loaded) {
+		w.logger.Warn("Some config changes require a restart to take effect")
+	}
+	if reflect.DeepEqual(&next, old) {
+		w.mu.Unlock()
+		w.logger.Info("No reloadable config changes")
+		return false
+	}
+	w.current = &next
+	w.mu.Unlock()
+
+	w.logger.Info("Config reloaded", zap.Int("subscribers", len(w.subscribers)))
+	for _, subscriber := range w.subscribers {
+		subscriber.ConfigReloaded(old, &next)
+	}
+	return true
+}
+
+// changed 检查监听的文件是否有变化
+func (w *Watcher) changed() bool {
+	modTimes := w.snapshot()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if reflect.DeepEqual(modTimes, w.modTimes) {
+		return false
+	}
+	w.modTimes = modTimes
+	return true
+}
+
+// snapshot 记录配置文件和.env的修改时间
+func (w *Watcher) snapshot() map[string]time.Time {
+	files := []string{dotEnvFile}
+	if file := w.configFile(); file != "" {
+		files = append(files, file)
+	}
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// configFile 当前使用的配置文件路径
+func (w *Watcher) configFile() string {
+	if w.options.File != "" {
+		return w.options.File
+	}
+	source, err := newEnvSource(dotEnvFile)
+	if err != nil {
+		return ""
+	}
+	file, _, _ := source.lookup("CONFIG_FILE")
+	return file
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWatcherReload(t *testing.T) {
+	dir := chdirTemp(t)
+	file := writeFile(t, dir, "config.yaml", "logger:\n  level: info\n")
+	options := &Options{File: file}
+	initial, err := Load(*options)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	type notification struct{ old, updated *Config }
+	var notifications []notification
+	subscriber := SubscriberFunc(func(old, updated *Config) {
+		notifications = append(notifications, notification{old: old, updated: updated})
+	})
+	watcher := NewWatcher(WatcherParams{Config: initial, Options: options, Subscribers: []Subscriber{subscriber}, Logger: zap.NewNop()})
+
+	// 每一步都基于上一步的结果，文件内容是完整的配置
+	steps := []struct {
+		name          string
+		content       string
+		want          bool
+		wantLevel     string
+		wantRetention int
+	}{
+		{name: "unchanged", content: "logger:\n  level: info\n", want: false, wantLevel: "info", wantRetention: 30},
+		{name: "restart required only", content: "logger:\n  level: info\nserver:\n  http_port: 7000\n", want: false, wantLevel: "info", wantRetention: 30},
+		{name: "reloadable", content: "logger:\n  level: debug\nserver:\n  http_port: 7000\ntrash:\n  retention_days: 7\n", want: true, wantLevel: "debug", wantRetention: 7},
+		{name: "invalid keeps current", content: "logger:\n  level: trace\n", want: false, wantLevel: "debug", wantRetention: 7},
+		{name: "malformed keeps current", content: "logger: [", want: false, wantLevel: "debug", wantRetention: 7},
+	}
+	for _, step := range steps {
+		if err := os.WriteFile(file, []byte(step.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		before := watcher.Current()
+		notified := len(notifications)
+
+		if got := watcher.Reload(); got != step.want {
+			t.Fatalf("%s: Reload() = %v, want %v", step.name, got, step.want)
+		}
+		current := watcher.Current()
+		if current.Logger.Level != step.wantLevel || current.Trash.RetentionDays != step.wantRetention {
+			t.Fatalf("%s: level = %q, retention = %d, want %q, %d",
+				step.name, current.Logger.Level, current.Trash.RetentionDays, step.wantLevel, step.wantRetention)
+		}
+		// 需要重启的字段不会热加载
+		if current.Server.HTTPPort != initial.Server.HTTPPort {
+			t.Fatalf("%s: HTTPPort = %d, want %d", step.name, current.Server.HTTPPort, initial.Server.HTTPPort)
+		}
+
+		if !step.want {
+			if len(notifications) != notified || current != before {
+				t.Fatalf("%s: subscribers notified or config replaced without reloadable changes", step.name)
+			}
+			continue
+		}
+		if len(notifications) != notified+1 {
+			t.Fatalf("%s: notifications = %d, want %d", step.name, len(notifications), notified+1)
+		}
+		last := notifications[len(notifications)-1]
+		if last.old != before || last.updated != current {
+			t.Fatalf("%s: subscriber got wrong configs", step.name)
+		}
+		if last.old.Logger.Level != "info" {
+			t.Fatalf("%s: old config modified, level = %q", step.name, last.old.Logger.Level)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"time"
+
 	"github.com/cheel98/flashcard-backend/internal/config"
 	"github.com/cheel98/flashcard-backend/internal/model"
 
@@ -16,7 +19,30 @@ type Database struct {
 }
 
 // NewDatabase 创建新的数据库连接
+// 表结构由 migrate 子命令管理，AutoMigrate 仅在开发环境下通过 DB_AUTO_MIGRATE 开启
 func NewDatabase(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
+	db, err := Open(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Database.AutoMigrate {
+		logger.Warn("AutoMigrate is enabled, use it in development only")
+		if err := autoMigrate(db); err != nil {
+			logger.Error("Failed to migrate database", zap.Error(err))
+			return nil, err
+		}
+	} else {
+		checkPendingMigrations(db, logger)
+	}
+
+	logger.Info("Database connected successfully")
+
+	return db, nil
+}
+
+// Open 仅建立数据库连接，不做任何迁移
+func Open(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
 	// 构建数据库连接字符串
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
@@ -30,32 +56,52 @@ func NewDatabase(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
 	)
 
 	// 连接数据库
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// 将唯一约束冲突等数据库错误转换为gorm.ErrDuplicatedKey等通用错误
+		TranslateError: true,
+	})
 	if err != nil {
 		logger.Error("Failed to connect to database", zap.Error(err))
 		return nil, err
 	}
+	return db, nil
+}
 
-	// 自动迁移数据库表
-	if err := db.AutoMigrate(
+// autoMigrate 根据模型自动迁移数据库表
+func autoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
 		&model.User{},
 		&model.UserSettings{},
 		&model.UserPreferences{},
 		&model.UserLogs{},
 		&model.PaymentRecord{},
 		&model.Favorite{},
+		&model.FavoriteTag{},
 		&model.StudyRecord{},
 		&model.Dictionary{},
 		&model.DictionaryAudio{},
 		&model.DictionaryMetadata{},
-	); err != nil {
-		logger.Error("Failed to migrate database", zap.Error(err))
-		return nil, err
-	}
+	)
+}
 
-	logger.Info("Database connected successfully")
+// checkPendingMigrations 检查是否有未执行的迁移，只记录日志不阻止启动
+func checkPendingMigrations(db *gorm.DB, logger *zap.Logger) {
+	migrator, err := NewMigrator(db, logger)
+	if err != nil {
+		logger.Error("Failed to load migrations", zap.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	return db, nil
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		logger.Error("Failed to check migration status", zap.Error(err))
+		return
+	}
+	if pending > 0 {
+		logger.Warn("Database has pending migrations, run `migrate up` to apply them", zap.Int("pending", pending))
+	}
 }
 
 // Close 关闭数据库连接
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		want     string
+	}{
+		{name: "empty dir", want: "000001_add_index"},
+		{name: "after embedded migrations", existing: []string{"000005_a.up.sql", "000006_b.up.sql", "000006_b.down.sql", "README.md"}, want: "000007_add_index"},
+		{name: "gap", existing: []string{"000002_a.up.sql", "000010_b.up.sql"}, want: "000011_add_index"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			upPath, downPath, err := CreateMigration(dir, "Add Index")
+			if err != nil {
+				t.Fatalf("CreateMigration() error = %v", err)
+			}
+			if want := filepath.Join(dir, tt.want+".up.sql"); upPath != want {
+				t.Errorf("up path = %s, want %s", upPath, want)
+			}
+			if want := filepath.Join(dir, tt.want+".down.sql"); downPath != want {
+				t.Errorf("down path = %s, want %s", downPath, want)
+			}
+		})
+	}
+}
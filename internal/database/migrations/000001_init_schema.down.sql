@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS study_record;
+DROP TABLE IF EXISTS favorite_tag;
+DROP TABLE IF EXISTS favorite;
+DROP TABLE IF EXISTS dictionary_metadata;
+DROP TABLE IF EXISTS dictionary_audio;
+DROP TABLE IF EXISTS dictionary;
+DROP TABLE IF EXISTS payment_records;
+DROP TABLE IF EXISTS user_log;
+DROP TABLE IF EXISTS user_preference;
+DROP TABLE IF EXISTS user_setting;
+DROP TABLE IF EXISTS "user";
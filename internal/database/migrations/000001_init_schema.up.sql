@@ -0,0 +1,145 @@
+-- 初始表结构，与之前由AutoMigrate创建的结构保持一致，已有数据库上重复执行不会报错
+
+CREATE TABLE IF NOT EXISTS "user" (
+    id                VARCHAR(255) PRIMARY KEY,
+    name              VARCHAR(20),
+    email             VARCHAR(255),
+    phone             VARCHAR(11),
+    password_hash     VARCHAR(255),
+    refresh_token     TEXT,
+    avatar            VARCHAR(255),
+    nickname          TEXT,
+    member_ship_level BIGINT,
+    membership_expire TIMESTAMPTZ,
+    balance           BIGINT,
+    create_at         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at        TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_name ON "user" (name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_email ON "user" (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_phone ON "user" (phone);
+CREATE INDEX IF NOT EXISTS idx_user_deleted_at ON "user" (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_setting (
+    user_id             TEXT,
+    language_preference TEXT,
+    create_at           TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at           TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at          TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_setting_user_id ON user_setting (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_setting_deleted_at ON user_setting (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_preference (
+    user_id    TEXT,
+    tech_area  TEXT,
+    create_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_preference_user_id ON user_preference (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_preference_deleted_at ON user_preference (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_log (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    VARCHAR(255),
+    action     VARCHAR(255),
+    ip_address VARCHAR(45),
+    create_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at  TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_user_log_deleted_at ON user_log (deleted_at);
+
+CREATE TABLE IF NOT EXISTS payment_records (
+    id             VARCHAR(255) PRIMARY KEY,
+    user_id        VARCHAR(255) NOT NULL,
+    amount         NUMERIC(10, 2) NOT NULL,
+    currency       VARCHAR(10) DEFAULT 'CNY',
+    payment_method VARCHAR(50),
+    status         VARCHAR(20) DEFAULT 'pending',
+    transaction_id VARCHAR(100),
+    completed_at   TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_payment_records_user_id ON payment_records (user_id);
+
+CREATE TABLE IF NOT EXISTS dictionary (
+    id               BIGSERIAL PRIMARY KEY,
+    source_lang      VARCHAR(10) NOT NULL,
+    target_lang      VARCHAR(10) NOT NULL,
+    source_text      TEXT NOT NULL,
+    translated_text  TEXT NOT NULL,
+    part_of_speech   VARCHAR(20),
+    ipa              VARCHAR(50),
+    example_sentence TEXT,
+    create_at        TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at        TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at       TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_translation ON dictionary (source_lang, target_lang, source_text);
+CREATE INDEX IF NOT EXISTS idx_dictionary_deleted_at ON dictionary (deleted_at);
+
+CREATE TABLE IF NOT EXISTS dictionary_audio (
+    id            BIGSERIAL PRIMARY KEY,
+    dictionary_id BIGINT NOT NULL REFERENCES dictionary (id) ON DELETE CASCADE,
+    audio_path    VARCHAR(255) NOT NULL,
+    accent        VARCHAR(50),
+    create_at     TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at     TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_dictionary_audio_dictionary_id ON dictionary_audio (dictionary_id);
+CREATE INDEX IF NOT EXISTS idx_dictionary_audio_deleted_at ON dictionary_audio (deleted_at);
+
+CREATE TABLE IF NOT EXISTS dictionary_metadata (
+    id            BIGSERIAL PRIMARY KEY,
+    dictionary_id BIGINT NOT NULL REFERENCES dictionary (id) ON DELETE CASCADE,
+    key           VARCHAR(50) NOT NULL,
+    value         TEXT NOT NULL,
+    create_at     TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at     TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_dictionary_metadata_dictionary_id ON dictionary_metadata (dictionary_id);
+CREATE INDEX IF NOT EXISTS idx_dictionary_metadata_deleted_at ON dictionary_metadata (deleted_at);
+
+CREATE TABLE IF NOT EXISTS favorite (
+    id             VARCHAR(255) PRIMARY KEY,
+    user_id        VARCHAR(255),
+    dictionary_id  BIGINT,
+    memory_depth   BIGINT,
+    note           TEXT,
+    custom_example TEXT,
+    last_result    VARCHAR(20) DEFAULT '',
+    due_at         TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    create_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at     TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_favorite_deleted_at ON favorite (deleted_at);
+
+CREATE TABLE IF NOT EXISTS favorite_tag (
+    id          BIGSERIAL PRIMARY KEY,
+    favorite_id VARCHAR(255) NOT NULL REFERENCES favorite (id) ON DELETE CASCADE,
+    user_id     VARCHAR(255) NOT NULL,
+    name        VARCHAR(50) NOT NULL,
+    create_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at  TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorite_tag ON favorite_tag (favorite_id, name);
+CREATE INDEX IF NOT EXISTS idx_user_tag ON favorite_tag (user_id, name);
+CREATE INDEX IF NOT EXISTS idx_favorite_tag_deleted_at ON favorite_tag (deleted_at);
+
+CREATE TABLE IF NOT EXISTS study_record (
+    id          VARCHAR(255) PRIMARY KEY,
+    favorite_id VARCHAR(255),
+    result      VARCHAR(20) CHECK (result IN ('remembered', 'fuzzy', 'strange')),
+    remark      TEXT,
+    create_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    update_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at  TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_study_record_favorite_id ON study_record (favorite_id);
+CREATE INDEX IF NOT EXISTS idx_study_record_deleted_at ON study_record (deleted_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_favorite_user_dictionary;
+DROP INDEX IF EXISTS idx_favorite_user_due_at;
+DROP INDEX IF EXISTS idx_dictionary_translated_text_trgm;
+DROP INDEX IF EXISTS idx_dictionary_source_text_trgm;
@@ -0,0 +1,9 @@
+-- 收藏搜索使用ILIKE '%关键字%'，需要三元组索引才能走索引
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_dictionary_source_text_trgm ON dictionary USING gin (source_text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_dictionary_translated_text_trgm ON dictionary USING gin (translated_text gin_trgm_ops);
+
+-- 复习列表只查询未删除的收藏
+CREATE INDEX IF NOT EXISTS idx_favorite_user_due_at ON favorite (user_id, due_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_favorite_user_dictionary ON favorite (user_id, dictionary_id) WHERE deleted_at IS NULL;
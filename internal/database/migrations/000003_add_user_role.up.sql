@@ -0,0 +1,2 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS role VARCHAR(20) DEFAULT 'user';
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS disabled BOOLEAN DEFAULT false;
@@ -0,0 +1,2 @@
+-- 零值无法与NULL区分，回滚不恢复原来的数据
+SELECT 1;
@@ -0,0 +1,13 @@
+-- 软删除之前deleted_at是time.Time，未删除的记录保存的是零值0001-01-01而不是NULL。
+-- gorm.DeletedAt把非NULL视为已删除，需要先把零值改回NULL，否则这些记录不可见，并会被回收站清理任务彻底删除
+
+UPDATE "user" SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE user_setting SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE user_preference SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE user_log SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE dictionary SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE dictionary_audio SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE dictionary_metadata SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE favorite SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE favorite_tag SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
+UPDATE study_record SET deleted_at = NULL WHERE deleted_at < '0001-01-02';
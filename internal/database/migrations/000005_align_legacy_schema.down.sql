@@ -0,0 +1,8 @@
+-- 补齐的列和外键在新建的数据库中由000001创建，回滚时保留，只恢复用户表的唯一索引。
+-- 已有注销后重新注册的用户时恢复会失败，需要先处理重复的记录
+DROP INDEX IF EXISTS idx_user_phone;
+DROP INDEX IF EXISTS idx_user_email;
+DROP INDEX IF EXISTS idx_user_name;
+CREATE UNIQUE INDEX idx_user_name ON "user" (name);
+CREATE UNIQUE INDEX idx_user_email ON "user" (email);
+CREATE UNIQUE INDEX idx_user_phone ON "user" (phone);
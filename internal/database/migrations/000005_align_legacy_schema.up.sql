@@ -0,0 +1,36 @@
+-- 000001只在表不存在时建表，AutoMigrate时期创建的表可能缺少之后新增的列和外键，这里补齐
+
+ALTER TABLE favorite ADD COLUMN IF NOT EXISTS note TEXT;
+ALTER TABLE favorite ADD COLUMN IF NOT EXISTS custom_example TEXT;
+ALTER TABLE favorite ADD COLUMN IF NOT EXISTS last_result VARCHAR(20) DEFAULT '';
+ALTER TABLE favorite ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP;
+UPDATE favorite SET last_result = '' WHERE last_result IS NULL;
+UPDATE favorite SET due_at = create_at WHERE due_at IS NULL;
+
+ALTER TABLE study_record ADD COLUMN IF NOT EXISTS favorite_id VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_study_record_favorite_id ON study_record (favorite_id);
+
+-- 外键没有IF NOT EXISTS，已有指向favorite的外键时跳过（AutoMigrate创建的外键名为fk_favorite_tags）
+DELETE FROM favorite_tag WHERE NOT EXISTS (SELECT 1 FROM favorite WHERE favorite.id = favorite_tag.favorite_id);
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE contype = 'f'
+          AND conrelid = 'favorite_tag'::regclass
+          AND confrelid = 'favorite'::regclass
+    ) THEN
+        ALTER TABLE favorite_tag
+            ADD CONSTRAINT favorite_tag_favorite_id_fkey
+            FOREIGN KEY (favorite_id) REFERENCES favorite (id) ON DELETE CASCADE;
+    END IF;
+END
+$$;
+
+-- 注销后的用户名、邮箱和手机号可以重新注册，唯一索引只约束未删除的用户
+DROP INDEX IF EXISTS idx_user_name;
+DROP INDEX IF EXISTS idx_user_email;
+DROP INDEX IF EXISTS idx_user_phone;
+CREATE UNIQUE INDEX idx_user_name ON "user" (name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_user_email ON "user" (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_user_phone ON "user" (phone) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- 移入回收站的重复收藏不会恢复
+DROP INDEX IF EXISTS idx_favorite_user_dictionary;
+CREATE INDEX IF NOT EXISTS idx_favorite_user_dictionary ON favorite (user_id, dictionary_id) WHERE deleted_at IS NULL;
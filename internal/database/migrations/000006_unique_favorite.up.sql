@@ -0,0 +1,14 @@
+-- 同一用户对同一词典只能有一条有效收藏，并发收藏同一词典时由唯一索引拒绝后到的请求。
+-- 已有重复收藏时保留最早的一条，其余移入回收站
+UPDATE favorite SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM favorite AS earlier
+    WHERE earlier.user_id = favorite.user_id
+      AND earlier.dictionary_id = favorite.dictionary_id
+      AND earlier.deleted_at IS NULL
+      AND (COALESCE(earlier.create_at, 'epoch'), earlier.id) < (COALESCE(favorite.create_at, 'epoch'), favorite.id)
+  );
+
+DROP INDEX IF EXISTS idx_favorite_user_dictionary;
+CREATE UNIQUE INDEX idx_favorite_user_dictionary ON favorite (user_id, dictionary_id) WHERE deleted_at IS NULL;
@@ -1,10 +1,30 @@
 package database
 
 import (
+	"context"
+
 	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // Module 数据库模块
 var Module = fx.Options(
 	fx.Provide(NewDatabase),
+	CloseOnStop,
 )
+
+// CloseOnStop 停止时关闭数据库连接。
+// 数据库先于依赖它的模块启动，fx按相反顺序停止，因此在服务器和后台任务停止后才关闭连接
+var CloseOnStop = fx.Invoke(func(lc fx.Lifecycle, db *gorm.DB, logger *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			logger.Info("Closing database connections")
+			return sqlDB.Close()
+		},
+	})
+})
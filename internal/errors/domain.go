@@ -0,0 +1,89 @@
+package errorsvar
+
+// 错误原因，作为ErrorInfo.Reason返回给调用方，客户端应依据Reason而不是Message做判断
+const (
+	ReasonInternal               = "INTERNAL"
+	ReasonInvalidArgument        = "INVALID_ARGUMENT"
+	ReasonCanceled               = "CANCELLED"
+	ReasonDeadlineExceeded       = "DEADLINE_EXCEEDED"
+	ReasonTokenMissing           = "TOKEN_MISSING"
+	ReasonTokenInvalid           = "TOKEN_INVALID"
+	ReasonPermissionDenied       = "PERMISSION_DENIED"
+	ReasonIdempotencyKeyInvalid  = "IDEMPOTENCY_KEY_INVALID"
+	ReasonIdempotencyKeyReused   = "IDEMPOTENCY_KEY_REUSED"
+	ReasonIdempotencyInProgress  = "IDEMPOTENCY_REQUEST_IN_PROGRESS"
+	ReasonRateLimited            = "RATE_LIMITED"
+	ReasonRequestBodyTooLarge    = "REQUEST_BODY_TOO_LARGE"
+	ReasonShuttingDown           = "SERVER_SHUTTING_DOWN"
+	ReasonUserNotFound           = "USER_NOT_FOUND"
+	ReasonUserExists             = "USER_ALREADY_EXISTS"
+	ReasonInvalidCredentials     = "INVALID_CREDENTIALS"
+	ReasonAccountDisabled        = "ACCOUNT_DISABLED"
+	ReasonUserSettingsNotFound   = "USER_SETTINGS_NOT_FOUND"
+	ReasonUserPreferenceNotFound = "USER_PREFERENCES_NOT_FOUND"
+	ReasonInvalidRefreshToken    = "INVALID_REFRESH_TOKEN"
+	ReasonUserNotInTrash         = "USER_NOT_IN_TRASH"
+	ReasonCaptchaInvalid         = "CAPTCHA_INVALID"
+	ReasonDictionaryNotFound     = "DICTIONARY_NOT_FOUND"
+	ReasonDictionaryExists       = "DICTIONARY_ALREADY_EXISTS"
+	ReasonDictionaryNotInTrash   = "DICTIONARY_NOT_IN_TRASH"
+	ReasonFavoriteNotFound       = "FAVORITE_NOT_FOUND"
+	ReasonFavoriteExists         = "FAVORITE_ALREADY_EXISTS"
+	ReasonInvalidCursor          = "INVALID_CURSOR"
+	ReasonTranslationUnavailable = "TRANSLATION_ENGINE_UNAVAILABLE"
+	ReasonImageTooLarge          = "IMAGE_TOO_LARGE"
+	ReasonImageUnsupported       = "IMAGE_FORMAT_UNSUPPORTED"
+)
+
+// 通用错误
+var (
+	ErrCanceled            = New(CodeCanceled, ReasonCanceled, "请求已取消")
+	ErrDeadlineExceeded    = New(CodeDeadlineExceeded, ReasonDeadlineExceeded, "请求超时")
+	ErrTokenMissing        = Unauthenticated(ReasonTokenMissing, "缺少认证信息")
+	ErrTokenInvalid        = Unauthenticated(ReasonTokenInvalid, "无效的访问令牌")
+	ErrPermissionDenied    = PermissionDenied(ReasonPermissionDenied, "没有权限执行该操作")
+	ErrRateLimited         = QuotaExceeded(ReasonRateLimited, "请求过于频繁，请稍后再试")
+	ErrRequestBodyTooLarge = InvalidArgument(ReasonRequestBodyTooLarge, "请求体过大")
+	ErrShuttingDown        = New(CodeUnavailable, ReasonShuttingDown, "服务正在关闭，请重新连接")
+)
+
+// 幂等键相关错误
+var (
+	ErrIdempotencyKeyInvalid = InvalidArgument(ReasonIdempotencyKeyInvalid, "幂等键格式错误")
+	ErrIdempotencyKeyReused  = InvalidArgument(ReasonIdempotencyKeyReused, "幂等键已用于其他请求")
+	ErrIdempotencyInProgress = New(CodeAborted, ReasonIdempotencyInProgress, "相同幂等键的请求正在处理中，请稍后重试")
+)
+
+// 用户相关错误
+var (
+	ErrUserNotFound            = NotFound(ReasonUserNotFound, "用户不存在")
+	ErrUserExists              = AlreadyExists(ReasonUserExists, "该邮箱已注册")
+	ErrInvalidCredentials      = Unauthenticated(ReasonInvalidCredentials, "用户名或密码错误")
+	ErrAccountDisabled         = PermissionDenied(ReasonAccountDisabled, "账户已被禁用")
+	ErrUserSettingsNotFound    = NotFound(ReasonUserSettingsNotFound, "用户设置不存在")
+	ErrUserPreferencesNotFound = NotFound(ReasonUserPreferenceNotFound, "用户喜好设置不存在")
+	ErrInvalidRefreshToken     = Unauthenticated(ReasonInvalidRefreshToken, "无效的刷新令牌")
+	ErrUserNotInTrash          = NotFound(ReasonUserNotInTrash, "回收站中不存在该用户")
+	ErrCaptchaInvalid          = InvalidArgument(ReasonCaptchaInvalid, "验证码错误或已过期")
+)
+
+// 词典相关错误
+var (
+	ErrDictionaryNotFound   = NotFound(ReasonDictionaryNotFound, "词典记录不存在")
+	ErrDictionaryExists     = AlreadyExists(ReasonDictionaryExists, "该翻译记录已存在")
+	ErrDictionaryNotInTrash = NotFound(ReasonDictionaryNotInTrash, "回收站中不存在该词典记录")
+)
+
+// 收藏相关错误
+var (
+	ErrFavoriteNotFound = NotFound(ReasonFavoriteNotFound, "收藏记录不存在")
+	ErrFavoriteExists   = AlreadyExists(ReasonFavoriteExists, "该单词已经收藏")
+	ErrInvalidCursor    = InvalidArgument(ReasonInvalidCursor, "无效的分页游标")
+)
+
+// 翻译相关错误
+var (
+	ErrTranslationUnavailable = New(CodeUnavailable, ReasonTranslationUnavailable, "翻译服务暂时不可用，请稍后再试")
+	ErrImageTooLarge          = InvalidArgument(ReasonImageTooLarge, "图片过大")
+	ErrImageUnsupported       = InvalidArgument(ReasonImageUnsupported, "不支持的图片格式，请上传JPEG、PNG或BMP图片")
+)
@@ -1,7 +1,239 @@
 package errorsvar
 
-import "errors"
+import (
+	"errors"
+	"fmt"
 
-var (
-	CaptchaErrors = errors.New("CaptchaErrors")
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
+
+// Domain 错误详情ErrorInfo中的错误域
+const Domain = "flashcard"
+
+// Code 领域错误码，与gRPC状态码一一对应
+type Code int
+
+const (
+	CodeInvalidArgument Code = iota + 1
+	CodeNotFound
+	CodeAlreadyExists
+	CodeUnauthenticated
+	CodePermissionDenied
+	CodeQuotaExceeded
+	CodeFailedPrecondition
+	CodeUnavailable
+	CodeInternal
+	CodeCanceled
+	CodeDeadlineExceeded
+	CodeAborted
+)
+
+// grpcCodes 领域错误码对应的gRPC状态码
+var grpcCodes = map[Code]codes.Code{
+	CodeInvalidArgument:    codes.InvalidArgument,
+	CodeNotFound:           codes.NotFound,
+	CodeAlreadyExists:      codes.AlreadyExists,
+	CodeUnauthenticated:    codes.Unauthenticated,
+	CodePermissionDenied:   codes.PermissionDenied,
+	CodeQuotaExceeded:      codes.ResourceExhausted,
+	CodeFailedPrecondition: codes.FailedPrecondition,
+	CodeUnavailable:        codes.Unavailable,
+	CodeInternal:           codes.Internal,
+	CodeCanceled:           codes.Canceled,
+	CodeDeadlineExceeded:   codes.DeadlineExceeded,
+	CodeAborted:            codes.Aborted,
+}
+
+// GRPCCode 返回对应的gRPC状态码
+func (c Code) GRPCCode() codes.Code {
+	if code, ok := grpcCodes[c]; ok {
+		return code
+	}
+	return codes.Unknown
+}
+
+// FieldViolation 请求字段错误
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error 领域错误。Reason是机器可读的错误原因，Message是返回给调用方的描述，
+// cause只用于日志，不会返回给调用方
+type Error struct {
+	Code       Code
+	Reason     string
+	Message    string
+	Metadata   map[string]string
+	Violations []FieldViolation
+	cause      error
+}
+
+// New 创建领域错误
+func New(code Code, reason, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+// InvalidArgument 创建参数错误，可以附带字段错误
+func InvalidArgument(reason, message string, violations ...FieldViolation) *Error {
+	err := New(CodeInvalidArgument, reason, message)
+	err.Violations = violations
+	return err
+}
+
+// NotFound 创建资源不存在错误
+func NotFound(reason, message string) *Error {
+	return New(CodeNotFound, reason, message)
+}
+
+// AlreadyExists 创建资源已存在错误
+func AlreadyExists(reason, message string) *Error {
+	return New(CodeAlreadyExists, reason, message)
+}
+
+// Unauthenticated 创建未认证错误
+func Unauthenticated(reason, message string) *Error {
+	return New(CodeUnauthenticated, reason, message)
+}
+
+// PermissionDenied 创建无权限错误
+func PermissionDenied(reason, message string) *Error {
+	return New(CodePermissionDenied, reason, message)
+}
+
+// QuotaExceeded 创建配额超限错误
+func QuotaExceeded(reason, message string) *Error {
+	return New(CodeQuotaExceeded, reason, message)
+}
+
+// Internal 创建内部错误，cause只记录到日志中
+func Internal(message string, cause error) *Error {
+	err := New(CodeInternal, ReasonInternal, message)
+	err.cause = cause
+	return err
+}
+
+// Error 实现error
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.cause)
+	}
+	return e.Message
+}
+
+// Unwrap 返回底层错误
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码和错误原因相同即视为同一错误，哨兵错误附加信息后仍可以用errors.Is判断
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code && e.Reason == t.Reason
+}
+
+// Cause 返回底层错误
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// clone 复制错误，避免修改共享的哨兵错误
+func (e *Error) clone() *Error {
+	c := *e
+	if e.Metadata != nil {
+		c.Metadata = make(map[string]string, len(e.Metadata))
+		for k, v := range e.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	c.Violations = append([]FieldViolation(nil), e.Violations...)
+	return &c
+}
+
+// WithCause 返回附带底层错误的副本
+func (e *Error) WithCause(cause error) *Error {
+	c := e.clone()
+	c.cause = cause
+	return c
+}
+
+// WithMessage 返回替换了描述的副本
+func (e *Error) WithMessage(format string, args ...interface{}) *Error {
+	c := e.clone()
+	c.Message = fmt.Sprintf(format, args...)
+	return c
+}
+
+// WithMetadata 返回附带元数据的副本
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := e.clone()
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[key] = value
+	return c
+}
+
+// WithViolations 返回附带字段错误的副本
+func (e *Error) WithViolations(violations ...FieldViolation) *Error {
+	c := e.clone()
+	c.Violations = append(c.Violations, violations...)
+	return c
+}
+
+// GRPCStatus 转换为带有ErrorInfo和BadRequest详情的gRPC状态，status.FromError会调用该方法
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.Code.GRPCCode(), e.Message)
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   e.Reason,
+		Domain:   Domain,
+		Metadata: e.Metadata,
+	})
+	if err != nil {
+		return st
+	}
+	st = withDetails
+
+	if len(e.Violations) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range e.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		if withViolations, err := st.WithDetails(badRequest); err == nil {
+			st = withViolations
+		}
+	}
+	return st
+}
+
+// As 获取错误链中的领域错误
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// Wrap 领域错误原样返回，其他错误包装为内部错误，message作为返回给调用方的描述
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := As(err); ok {
+		return e
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return Internal(message, err)
+}
@@ -0,0 +1,194 @@
+package errorsvar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCodeGRPCCode(t *testing.T) {
+	tests := []struct {
+		code Code
+		want codes.Code
+	}{
+		{CodeInvalidArgument, codes.InvalidArgument},
+		{CodeNotFound, codes.NotFound},
+		{CodeAlreadyExists, codes.AlreadyExists},
+		{CodeUnauthenticated, codes.Unauthenticated},
+		{CodePermissionDenied, codes.PermissionDenied},
+		{CodeQuotaExceeded, codes.ResourceExhausted},
+		{CodeFailedPrecondition, codes.FailedPrecondition},
+		{CodeUnavailable, codes.Unavailable},
+		{CodeInternal, codes.Internal},
+		{CodeCanceled, codes.Canceled},
+		{CodeDeadlineExceeded, codes.DeadlineExceeded},
+		{CodeAborted, codes.Aborted},
+		{Code(0), codes.Unknown},
+		{Code(1000), codes.Unknown},
+	}
+	for _, tt := range tests {
+		if got := tt.code.GRPCCode(); got != tt.want {
+			t.Errorf("Code(%d).GRPCCode() = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	grpcErr := status.Error(codes.NotFound, "not found")
+	plain := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   codes.Code
+		wantReason string
+		wantSame   error // 期望原样返回的错误
+	}{
+		{name: "domain error", err: ErrUserNotFound, wantCode: codes.NotFound, wantReason: ReasonUserNotFound, wantSame: ErrUserNotFound},
+		{name: "wrapped domain error", err: fmt.Errorf("get user: %w", ErrUserNotFound), wantCode: codes.NotFound, wantReason: ReasonUserNotFound, wantSame: ErrUserNotFound},
+		{name: "grpc status", err: grpcErr, wantCode: codes.NotFound, wantSame: grpcErr},
+		{name: "plain error", err: plain, wantCode: codes.Internal, wantReason: ReasonInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(tt.err, "获取用户失败")
+			if tt.wantSame != nil && got != tt.wantSame {
+				t.Fatalf("Wrap() = %v, want %v unchanged", got, tt.wantSame)
+			}
+			if code := status.Code(got); code != tt.wantCode {
+				t.Errorf("status code = %s, want %s", code, tt.wantCode)
+			}
+			if tt.wantReason == "" {
+				return
+			}
+			e, ok := As(got)
+			if !ok || e.Reason != tt.wantReason {
+				t.Fatalf("Wrap() = %v, want reason %s", got, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("plain error message", func(t *testing.T) {
+		e, _ := As(Wrap(plain, "获取用户失败"))
+		if e.Message != "获取用户失败" || !errors.Is(e, plain) {
+			t.Errorf("Wrap() = %q with cause %v, want message 获取用户失败 and cause %v", e.Message, e.Cause(), plain)
+		}
+		if st := status.Convert(e); strings.Contains(st.Message(), plain.Error()) {
+			t.Errorf("status message %q exposes the cause", st.Message())
+		}
+	})
+
+	if err := Wrap(nil, "获取用户失败"); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+}
+
+func TestWithCause(t *testing.T) {
+	cause := errors.New("pool stopped")
+	err := ErrShuttingDown.WithCause(cause)
+
+	if !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("errors.Is(%v, ErrShuttingDown) = false", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v, cause) = false", err)
+	}
+	if errors.Is(err, ErrUserNotFound) {
+		t.Errorf("errors.Is(%v, ErrUserNotFound) = true", err)
+	}
+	if want := ErrShuttingDown.Message + ": pool stopped"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	// 哨兵错误不会被修改
+	if ErrShuttingDown.Cause() != nil {
+		t.Errorf("ErrShuttingDown.Cause() = %v, want nil", ErrShuttingDown.Cause())
+	}
+	if st := status.Convert(err); st.Code() != codes.Unavailable || st.Message() != ErrShuttingDown.Message {
+		t.Errorf("status = %s %q, want %s %q", st.Code(), st.Message(), codes.Unavailable, ErrShuttingDown.Message)
+	}
+}
+
+func TestGRPCStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       codes.Code
+		wantReason     string
+		wantMetadata   map[string]string
+		wantViolations []FieldViolation
+	}{
+		{
+			name:       "error info",
+			err:        ErrUserNotFound,
+			wantCode:   codes.NotFound,
+			wantReason: ReasonUserNotFound,
+		},
+		{
+			name:         "metadata",
+			err:          fmt.Errorf("limit: %w", ErrRateLimited.WithMetadata("retry_after", "3")),
+			wantCode:     codes.ResourceExhausted,
+			wantReason:   ReasonRateLimited,
+			wantMetadata: map[string]string{"retry_after": "3"},
+		},
+		{
+			name:           "bad request",
+			err:            InvalidArgument(ReasonInvalidArgument, "参数错误", FieldViolation{Field: "email", Description: "邮箱格式错误"}),
+			wantCode:       codes.InvalidArgument,
+			wantReason:     ReasonInvalidArgument,
+			wantViolations: []FieldViolation{{Field: "email", Description: "邮箱格式错误"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, ok := status.FromError(tt.err)
+			if !ok {
+				t.Fatalf("status.FromError(%v) ok = false", tt.err)
+			}
+			if st.Code() != tt.wantCode {
+				t.Errorf("code = %s, want %s", st.Code(), tt.wantCode)
+			}
+
+			var info *errdetails.ErrorInfo
+			var badRequest *errdetails.BadRequest
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.BadRequest:
+					badRequest = d
+				}
+			}
+			if info == nil || info.Reason != tt.wantReason || info.Domain != Domain {
+				t.Fatalf("ErrorInfo = %v, want reason %s in domain %s", info, tt.wantReason, Domain)
+			}
+			if len(info.Metadata) != len(tt.wantMetadata) {
+				t.Errorf("metadata = %v, want %v", info.Metadata, tt.wantMetadata)
+			}
+			for k, v := range tt.wantMetadata {
+				if info.Metadata[k] != v {
+					t.Errorf("metadata = %v, want %v", info.Metadata, tt.wantMetadata)
+				}
+			}
+
+			if len(tt.wantViolations) == 0 {
+				if badRequest != nil {
+					t.Errorf("unexpected BadRequest %v", badRequest)
+				}
+				return
+			}
+			if badRequest == nil || len(badRequest.FieldViolations) != len(tt.wantViolations) {
+				t.Fatalf("BadRequest = %v, want %v", badRequest, tt.wantViolations)
+			}
+			for i, want := range tt.wantViolations {
+				if got := badRequest.FieldViolations[i]; got.Field != want.Field || got.Description != want.Description {
+					t.Errorf("violation %d = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
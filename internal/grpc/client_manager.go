@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"sync"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/cheel98/flashcard-backend/proto/generated/user"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
@@ -32,6 +34,7 @@ type ClientConfig struct {
 	KeepAliveTimeout time.Duration
 	MaxRetries       int
 	RetryDelay       time.Duration
+	TLS              *tls.Config // 为空时使用明文连接
 }
 
 // Clients 包含所有gRPC客户端
@@ -68,7 +71,7 @@ func (cm *ClientManager) Connect(ctx context.Context) error {
 
 	// 配置连接选项
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(transportCredentials(cm.config.TLS)),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                cm.config.KeepAliveTime,
 			Timeout:             cm.config.KeepAliveTimeout,
@@ -105,6 +108,14 @@ func (cm *ClientManager) Connect(ctx context.Context) error {
 	return nil
 }
 
+// transportCredentials 配置了TLS时使用TLS连接，否则使用明文连接
+func transportCredentials(tlsConfig *tls.Config) credentials.TransportCredentials {
+	if tlsConfig == nil {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(tlsConfig)
+}
+
 // GetClients 获取gRPC客户端
 func (cm *ClientManager) GetClients() *Clients {
 	cm.mu.RLock()
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"sync"
 	"time"
@@ -21,6 +22,7 @@ type ConnectionPoolConfig struct {
 	ConnectTimeout    time.Duration `json:"connect_timeout"`
 	KeepAliveTime     time.Duration `json:"keep_alive_time"`
 	KeepAliveTimeout  time.Duration `json:"keep_alive_timeout"`
+	TLS               *tls.Config   `json:"-"` // 为空时使用明文连接
 }
 
 // DefaultConnectionPoolConfig 返回默认连接池配置
@@ -199,7 +201,7 @@ func (cp *ConnectionPool) createConnection(target string) (*grpc.ClientConn, err
 	defer cancel()
 	
 	opts := []grpc.DialOption{
-		grpc.WithInsecure(),
+		grpc.WithTransportCredentials(transportCredentials(cp.config.TLS)),
 		grpc.WithBlock(),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                cp.config.KeepAliveTime,
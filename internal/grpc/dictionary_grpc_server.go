@@ -4,12 +4,12 @@ import (
 	"context"
 	"time"
 
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
 	"github.com/cheel98/flashcard-backend/internal/model"
 	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
 	"github.com/cheel98/flashcard-backend/proto/generated/dictionary"
 	"go.uber.org/zap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -30,17 +30,11 @@ func NewDictionaryGRPCServer(dictionaryRepo repository.DictionaryRepository, log
 
 // CreateDictionary 创建词典记录
 func (s *DictionaryGRPCServer) CreateDictionary(ctx context.Context, req *dictionary.CreateDictionaryRequest) (*dictionary.CreateDictionaryResponse, error) {
-	s.logger.Info("创建词典记录",
+	logger.FromContext(ctx, s.logger).Info("创建词典记录",
 		zap.String("sourceLang", req.SourceLang),
 		zap.String("targetLang", req.TargetLang),
 		zap.String("sourceText", req.SourceText))
 
-	// 验证必填字段
-	if req.SourceLang == "" || req.TargetLang == "" || req.SourceText == "" || req.TranslatedText == "" {
-		s.logger.Error("创建词典记录失败：必填字段为空")
-		return nil, status.Errorf(codes.InvalidArgument, "源语言、目标语言、源文本和翻译文本不能为空")
-	}
-
 	// 创建词典记录
 	dict := &model.Dictionary{
 		SourceLang:      req.SourceLang,
@@ -56,14 +50,14 @@ func (s *DictionaryGRPCServer) CreateDictionary(ctx context.Context, req *dictio
 		},
 	}
 
-	err := s.dictionaryRepo.CreateDictionary(dict)
+	err := s.dictionaryRepo.CreateDictionary(ctx, dict)
 	if err != nil {
-		s.logger.Error("创建词典记录失败",
+		logger.FromContext(ctx, s.logger).Error("创建词典记录失败",
 			zap.String("sourceLang", req.SourceLang),
 			zap.String("targetLang", req.TargetLang),
 			zap.String("sourceText", req.SourceText),
 			zap.Error(err))
-		return nil, status.Errorf(codes.InvalidArgument, "创建词典记录失败: %v", err)
+		return nil, errorsvar.Wrap(err, "创建词典记录失败")
 	}
 
 	// 转换响应
@@ -71,31 +65,26 @@ func (s *DictionaryGRPCServer) CreateDictionary(ctx context.Context, req *dictio
 		Dictionary: s.convertModelToProto(dict),
 	}
 
-	s.logger.Info("词典记录创建成功", zap.Uint64("dictionaryID", dict.ID))
+	logger.FromContext(ctx, s.logger).Info("词典记录创建成功", zap.Uint64("dictionaryID", dict.ID))
 	return response, nil
 }
 
 // GetDictionaryByUniqueTranslation 根据唯一翻译信息查询词典
 func (s *DictionaryGRPCServer) GetDictionaryByUniqueTranslation(ctx context.Context, req *dictionary.GetDictionaryByUniqueTranslationRequest) (*dictionary.GetDictionaryByUniqueTranslationResponse, error) {
-	s.logger.Debug("根据唯一翻译信息查询词典",
+	logger.FromContext(ctx, s.logger).Debug("根据唯一翻译信息查询词典",
 		zap.String("sourceLang", req.SourceLang),
 		zap.String("targetLang", req.TargetLang),
 		zap.String("sourceText", req.SourceText))
 
-	if req.SourceLang == "" || req.TargetLang == "" || req.SourceText == "" {
-		s.logger.Error("查询词典失败：参数不能为空")
-		return nil, status.Errorf(codes.InvalidArgument, "源语言、目标语言和源文本参数不能为空")
-	}
-
 	// 调用repository层
-	dict, err := s.dictionaryRepo.GetDictionaryByUniqueTranslation(req.SourceLang, req.TargetLang, req.SourceText)
+	dict, err := s.dictionaryRepo.GetDictionaryByUniqueTranslation(ctx, req.SourceLang, req.TargetLang, req.SourceText)
 	if err != nil {
-		s.logger.Error("查询词典记录失败",
+		logger.FromContext(ctx, s.logger).Error("查询词典记录失败",
 			zap.String("sourceLang", req.SourceLang),
 			zap.String("targetLang", req.TargetLang),
 			zap.String("sourceText", req.SourceText),
 			zap.Error(err))
-		return nil, status.Errorf(codes.NotFound, "查询词典记录失败: %v", err)
+		return nil, errorsvar.Wrap(err, "查询词典记录失败")
 	}
 
 	// 转换响应
@@ -106,6 +95,67 @@ func (s *DictionaryGRPCServer) GetDictionaryByUniqueTranslation(ctx context.Cont
 	return response, nil
 }
 
+// DeleteDictionary 删除词典记录（移入回收站）
+func (s *DictionaryGRPCServer) DeleteDictionary(ctx context.Context, req *dictionary.DeleteDictionaryRequest) (*dictionary.DeleteDictionaryResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("删除词典记录", zap.Uint64("dictionaryID", req.Id))
+
+	err := s.dictionaryRepo.DeleteDictionary(ctx, req.Id)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("删除词典记录失败",
+			zap.Uint64("dictionaryID", req.Id),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "删除词典记录失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("词典记录删除成功", zap.Uint64("dictionaryID", req.Id))
+	return &dictionary.DeleteDictionaryResponse{Success: true}, nil
+}
+
+// RestoreDictionary 从回收站恢复词典记录
+func (s *DictionaryGRPCServer) RestoreDictionary(ctx context.Context, req *dictionary.RestoreDictionaryRequest) (*dictionary.RestoreDictionaryResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("恢复词典记录", zap.Uint64("dictionaryID", req.Id))
+
+	dict, err := s.dictionaryRepo.RestoreDictionary(ctx, req.Id)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("恢复词典记录失败",
+			zap.Uint64("dictionaryID", req.Id),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "恢复词典记录失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("词典记录恢复成功", zap.Uint64("dictionaryID", req.Id))
+	return &dictionary.RestoreDictionaryResponse{
+		Dictionary: s.convertModelToProto(dict),
+	}, nil
+}
+
+// ListTrashedDictionaries 查询回收站中的词典记录
+func (s *DictionaryGRPCServer) ListTrashedDictionaries(ctx context.Context, req *dictionary.ListTrashedDictionariesRequest) (*dictionary.ListTrashedDictionariesResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("查询词典回收站",
+		zap.Int32("limit", req.Limit),
+		zap.Int32("offset", req.Offset))
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	dicts, err := s.dictionaryRepo.ListTrashedDictionaries(ctx, limit, int(req.Offset))
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("查询词典回收站失败", zap.Error(err))
+		return nil, errorsvar.Wrap(err, "查询词典回收站失败")
+	}
+
+	var protoDicts []*dictionary.Dictionary
+	for _, dict := range dicts {
+		protoDicts = append(protoDicts, s.convertModelToProto(dict))
+	}
+
+	return &dictionary.ListTrashedDictionariesResponse{
+		Dictionaries: protoDicts,
+	}, nil
+}
+
 // convertModelToProto 将模型转换为protobuf消息
 func (s *DictionaryGRPCServer) convertModelToProto(dict *model.Dictionary) *dictionary.Dictionary {
 	protoDict := &dictionary.Dictionary{
@@ -120,6 +170,9 @@ func (s *DictionaryGRPCServer) convertModelToProto(dict *model.Dictionary) *dict
 		CreatedAt:       timestamppb.New(dict.CreatedAt),
 		UpdatedAt:       timestamppb.New(dict.UpdatedAt),
 	}
+	if dict.DeletedAt.Valid {
+		protoDict.DeletedAt = timestamppb.New(dict.DeletedAt.Time)
+	}
 
 	// 转换音频数据
 	for _, audio := range dict.Audios {
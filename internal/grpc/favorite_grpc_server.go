@@ -4,16 +4,25 @@ import (
 	"context"
 	"time"
 
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
 	"github.com/cheel98/flashcard-backend/internal/model"
 	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
 	"github.com/cheel98/flashcard-backend/proto/generated/favorite"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// favoriteSortFields protobuf排序字段与仓储排序字段的对应关系
+var favoriteSortFields = map[favorite.FavoriteSort_Field]repository.FavoriteSortField{
+	favorite.FavoriteSort_CREATED_AT:   repository.FavoriteSortCreatedAt,
+	favorite.FavoriteSort_UPDATED_AT:   repository.FavoriteSortUpdatedAt,
+	favorite.FavoriteSort_MEMORY_DEPTH: repository.FavoriteSortMemoryDepth,
+	favorite.FavoriteSort_DUE_AT:       repository.FavoriteSortDueAt,
+	favorite.FavoriteSort_SOURCE_TEXT:  repository.FavoriteSortSourceText,
+}
+
 // FavoriteGRPCServer gRPC收藏服务实现
 type FavoriteGRPCServer struct {
 	favorite.UnimplementedFavoriteServiceServer
@@ -31,35 +40,30 @@ func NewFavoriteGRPCServer(favoriteRepo repository.FavoriteRepository, logger *z
 
 // AddFavorite 添加收藏
 func (s *FavoriteGRPCServer) AddFavorite(ctx context.Context, req *favorite.AddFavoriteRequest) (*favorite.AddFavoriteResponse, error) {
-	s.logger.Info("添加收藏",
+	logger.FromContext(ctx, s.logger).Info("添加收藏",
 		zap.String("userID", req.UserId),
 		zap.Uint64("dictionaryID", req.DictionaryId))
 
-	// 验证必填字段
-	if req.UserId == "" || req.DictionaryId == 0 {
-		s.logger.Error("添加收藏失败：必填字段为空")
-		return nil, status.Errorf(codes.InvalidArgument, "用户ID和词典ID不能为空")
-	}
-
 	// 创建收藏记录
 	fav := &model.Favorite{
 		ID:           uuid.New().String(),
 		UserID:       req.UserId,
 		DictionaryID: req.DictionaryId,
 		MemoryDepth:  req.MemoryDepth,
+		DueAt:        time.Now(),
 		Model: model.Model{
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
 	}
 
-	err := s.favoriteRepo.AddFavorite(fav)
+	err := s.favoriteRepo.AddFavorite(ctx, fav)
 	if err != nil {
-		s.logger.Error("添加收藏失败",
+		logger.FromContext(ctx, s.logger).Error("添加收藏失败",
 			zap.String("userID", req.UserId),
 			zap.Uint64("dictionaryID", req.DictionaryId),
 			zap.Error(err))
-		return nil, status.Errorf(codes.InvalidArgument, "添加收藏失败: %v", err)
+		return nil, errorsvar.Wrap(err, "添加收藏失败")
 	}
 
 	// 转换响应
@@ -67,29 +71,79 @@ func (s *FavoriteGRPCServer) AddFavorite(ctx context.Context, req *favorite.AddF
 		Favorite: s.convertModelToProto(fav),
 	}
 
-	s.logger.Info("收藏添加成功", zap.String("favoriteID", fav.ID))
+	logger.FromContext(ctx, s.logger).Info("收藏添加成功", zap.String("favoriteID", fav.ID))
+	return response, nil
+}
+
+// ListFavorites 统一查询收藏
+func (s *FavoriteGRPCServer) ListFavorites(ctx context.Context, req *favorite.ListFavoritesRequest) (*favorite.ListFavoritesResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("查询收藏",
+		zap.String("userID", req.UserId),
+		zap.Int32("pageSize", req.PageSize),
+		zap.Bool("hasPageToken", req.PageToken != ""))
+
+	query := &repository.FavoriteQuery{
+		UserID:       req.UserId,
+		Cursor:       req.PageToken,
+		Limit:        int(req.PageSize),
+		IncludeTotal: req.IncludeTotal,
+	}
+	if filter := req.Filter; filter != nil {
+		query.MinMemoryDepth = filter.MinMemoryDepth
+		query.MaxMemoryDepth = filter.MaxMemoryDepth
+		query.LastResult = filter.LastResult
+		query.Tag = filter.Tag
+		query.SourceLang = filter.SourceLang
+		query.TargetLang = filter.TargetLang
+		query.CreatedAfter = timestampToTime(filter.CreatedAfter)
+		query.CreatedBefore = timestampToTime(filter.CreatedBefore)
+		query.DueAfter = timestampToTime(filter.DueAfter)
+		query.DueBefore = timestampToTime(filter.DueBefore)
+		query.Search = filter.Query
+	}
+	for _, sort := range req.Sort {
+		field, ok := favoriteSortFields[sort.Field]
+		if !ok {
+			return nil, errorsvar.InvalidArgument(errorsvar.ReasonInvalidArgument, "请求参数错误", errorsvar.FieldViolation{
+				Field:       "sort",
+				Description: "不支持的排序字段: " + sort.Field.String(),
+			})
+		}
+		query.Sort = append(query.Sort, repository.FavoriteSort{Field: field, Desc: sort.Descending})
+	}
+
+	page, err := s.favoriteRepo.ListFavorites(ctx, query)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("查询收藏失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "查询收藏失败")
+	}
+
+	response := &favorite.ListFavoritesResponse{
+		NextPageToken: page.NextCursor,
+		TotalCount:    page.Total,
+	}
+	for _, fav := range page.Favorites {
+		response.Favorites = append(response.Favorites, s.convertModelToProto(fav))
+	}
 	return response, nil
 }
 
 // GetFavoritesByMemoryAsc 按memory升序查询收藏
 func (s *FavoriteGRPCServer) GetFavoritesByMemoryAsc(ctx context.Context, req *favorite.GetFavoritesByMemoryAscRequest) (*favorite.GetFavoritesByMemoryAscResponse, error) {
-	s.logger.Debug("按memory升序查询收藏",
+	logger.FromContext(ctx, s.logger).Debug("按memory升序查询收藏",
 		zap.String("userID", req.UserId),
 		zap.Int32("limit", req.Limit),
 		zap.Int32("offset", req.Offset))
 
-	if req.UserId == "" {
-		s.logger.Error("查询收藏失败：用户ID不能为空")
-		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
-	}
-
 	// 调用repository层
-	favorites, err := s.favoriteRepo.GetFavoritesByMemoryAsc(req.UserId, int(req.Limit), int(req.Offset))
+	favorites, err := s.favoriteRepo.GetFavoritesByMemoryAsc(ctx, req.UserId, int(req.Limit), int(req.Offset))
 	if err != nil {
-		s.logger.Error("按memory升序查询收藏失败",
+		logger.FromContext(ctx, s.logger).Error("按memory升序查询收藏失败",
 			zap.String("userID", req.UserId),
 			zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "查询收藏失败: %v", err)
+		return nil, errorsvar.Wrap(err, "查询收藏失败")
 	}
 
 	// 转换响应
@@ -107,36 +161,20 @@ func (s *FavoriteGRPCServer) GetFavoritesByMemoryAsc(ctx context.Context, req *f
 
 // GetFavoritesByStudyRecord 按学习记录查询收藏
 func (s *FavoriteGRPCServer) GetFavoritesByStudyRecord(ctx context.Context, req *favorite.GetFavoritesByStudyRecordRequest) (*favorite.GetFavoritesByStudyRecordResponse, error) {
-	s.logger.Debug("按收藏日志查询收藏",
+	logger.FromContext(ctx, s.logger).Debug("按收藏日志查询收藏",
 		zap.String("userID", req.UserId),
 		zap.String("result", req.Result),
 		zap.Int32("limit", req.Limit),
 		zap.Int32("offset", req.Offset))
 
-	if req.UserId == "" {
-		s.logger.Error("查询收藏失败：用户ID不能为空")
-		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
-	}
-
-	if req.Result == "" {
-		s.logger.Error("查询收藏失败：学习结果参数不能为空")
-		return nil, status.Errorf(codes.InvalidArgument, "学习结果参数不能为空")
-	}
-
-	// 验证result参数
-	if req.Result != "remembered" && req.Result != "fuzzy" && req.Result != "strange" {
-		s.logger.Error("查询收藏失败：学习结果参数无效", zap.String("result", req.Result))
-		return nil, status.Errorf(codes.InvalidArgument, "学习结果参数无效: %s", req.Result)
-	}
-
 	// 调用repository层
-	favorites, err := s.favoriteRepo.GetFavoritesByStudyRecord(req.UserId, req.Result, int(req.Limit), int(req.Offset))
+	favorites, err := s.favoriteRepo.GetFavoritesByStudyRecord(ctx, req.UserId, req.Result, int(req.Limit), int(req.Offset))
 	if err != nil {
-		s.logger.Error("按收藏日志查询收藏失败",
+		logger.FromContext(ctx, s.logger).Error("按收藏日志查询收藏失败",
 			zap.String("userID", req.UserId),
 			zap.String("result", req.Result),
 			zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "查询收藏失败: %v", err)
+		return nil, errorsvar.Wrap(err, "查询收藏失败")
 	}
 
 	// 转换响应
@@ -154,25 +192,20 @@ func (s *FavoriteGRPCServer) GetFavoritesByStudyRecord(ctx context.Context, req
 
 // GetFavoritesByMemoryDepth 按记忆深度查询收藏
 func (s *FavoriteGRPCServer) GetFavoritesByMemoryDepth(ctx context.Context, req *favorite.GetFavoritesByMemoryDepthRequest) (*favorite.GetFavoritesByMemoryDepthResponse, error) {
-	s.logger.Debug("按记忆深度查询收藏",
+	logger.FromContext(ctx, s.logger).Debug("按记忆深度查询收藏",
 		zap.String("userID", req.UserId),
 		zap.Uint64("memoryDepth", req.MemoryDepth),
 		zap.Int32("limit", req.Limit),
 		zap.Int32("offset", req.Offset))
 
-	if req.UserId == "" {
-		s.logger.Error("查询收藏失败：用户ID不能为空")
-		return nil, status.Errorf(codes.InvalidArgument, "用户ID不能为空")
-	}
-
 	// 调用repository层
-	favorites, err := s.favoriteRepo.GetFavoritesByMemoryDepth(req.UserId, req.MemoryDepth, int(req.Limit), int(req.Offset))
+	favorites, err := s.favoriteRepo.GetFavoritesByMemoryDepth(ctx, req.UserId, req.MemoryDepth, int(req.Limit), int(req.Offset))
 	if err != nil {
-		s.logger.Error("按记忆深度查询收藏失败",
+		logger.FromContext(ctx, s.logger).Error("按记忆深度查询收藏失败",
 			zap.String("userID", req.UserId),
 			zap.Uint64("memoryDepth", req.MemoryDepth),
 			zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "查询收藏失败: %v", err)
+		return nil, errorsvar.Wrap(err, "查询收藏失败")
 	}
 
 	// 转换响应
@@ -190,31 +223,37 @@ func (s *FavoriteGRPCServer) GetFavoritesByMemoryDepth(ctx context.Context, req
 
 // AddStudyRecord 添加学习记录
 func (s *FavoriteGRPCServer) AddStudyRecord(ctx context.Context, req *favorite.AddStudyRecordRequest) (*favorite.AddStudyRecordResponse, error) {
-	s.logger.Info("添加学习记录", zap.String("result", req.Result))
-
-	// 验证result参数
-	if req.Result != "remembered" && req.Result != "fuzzy" && req.Result != "strange" {
-		s.logger.Error("添加学习记录失败：学习结果参数无效", zap.String("result", req.Result))
-		return nil, status.Errorf(codes.InvalidArgument, "学习结果参数无效")
+	logger.FromContext(ctx, s.logger).Info("添加学习记录", zap.String("result", req.Result))
+
+	// 关联收藏时检查收藏是否属于该用户
+	if req.FavoriteId != "" {
+		if _, err := s.favoriteRepo.GetFavorite(ctx, req.UserId, req.FavoriteId); err != nil {
+			logger.FromContext(ctx, s.logger).Error("添加学习记录失败：收藏不可用",
+				zap.String("userID", req.UserId),
+				zap.String("favoriteID", req.FavoriteId),
+				zap.Error(err))
+			return nil, errorsvar.Wrap(err, "添加学习记录失败")
+		}
 	}
 
 	// 创建学习记录
 	studyRecord := &model.StudyRecord{
-		ID:     uuid.New().String(),
-		Result: req.Result,
-		Remark: req.Remark,
+		ID:         uuid.New().String(),
+		FavoriteID: req.FavoriteId,
+		Result:     req.Result,
+		Remark:     req.Remark,
 		Model: model.Model{
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
 	}
 
-	err := s.favoriteRepo.AddStudyRecord(studyRecord)
+	err := s.favoriteRepo.AddStudyRecord(ctx, studyRecord)
 	if err != nil {
-		s.logger.Error("添加学习记录失败",
+		logger.FromContext(ctx, s.logger).Error("添加学习记录失败",
 			zap.String("result", req.Result),
 			zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "添加学习记录失败: %v", err)
+		return nil, errorsvar.Wrap(err, "添加学习记录失败")
 	}
 
 	// 转换响应
@@ -222,19 +261,370 @@ func (s *FavoriteGRPCServer) AddStudyRecord(ctx context.Context, req *favorite.A
 		StudyRecord: s.convertStudyRecordToProto(studyRecord),
 	}
 
-	s.logger.Info("学习记录添加成功", zap.String("studyRecordID", studyRecord.ID))
+	logger.FromContext(ctx, s.logger).Info("学习记录添加成功", zap.String("studyRecordID", studyRecord.ID))
 	return response, nil
 }
 
+// GetFavorite 获取收藏详情
+func (s *FavoriteGRPCServer) GetFavorite(ctx context.Context, req *favorite.GetFavoriteRequest) (*favorite.GetFavoriteResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("获取收藏详情",
+		zap.String("userID", req.UserId),
+		zap.String("favoriteID", req.FavoriteId))
+
+	fav, err := s.favoriteRepo.GetFavorite(ctx, req.UserId, req.FavoriteId)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("获取收藏详情失败",
+			zap.String("userID", req.UserId),
+			zap.String("favoriteID", req.FavoriteId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "获取收藏详情失败")
+	}
+
+	return &favorite.GetFavoriteResponse{
+		Favorite: s.convertModelToProto(fav),
+	}, nil
+}
+
+// RemoveFavorite 取消收藏
+func (s *FavoriteGRPCServer) RemoveFavorite(ctx context.Context, req *favorite.RemoveFavoriteRequest) (*favorite.RemoveFavoriteResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("取消收藏",
+		zap.String("userID", req.UserId),
+		zap.String("favoriteID", req.FavoriteId))
+
+	err := s.favoriteRepo.RemoveFavorite(ctx, req.UserId, req.FavoriteId)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("取消收藏失败",
+			zap.String("userID", req.UserId),
+			zap.String("favoriteID", req.FavoriteId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "取消收藏失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("取消收藏成功", zap.String("favoriteID", req.FavoriteId))
+	return &favorite.RemoveFavoriteResponse{Success: true}, nil
+}
+
+// BatchAddFavorites 批量添加收藏
+func (s *FavoriteGRPCServer) BatchAddFavorites(ctx context.Context, req *favorite.BatchAddFavoritesRequest) (*favorite.BatchAddFavoritesResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("批量添加收藏",
+		zap.String("userID", req.UserId),
+		zap.Int("count", len(req.DictionaryIds)))
+
+	favorites := make([]*model.Favorite, 0, len(req.DictionaryIds))
+	for _, dictionaryID := range req.DictionaryIds {
+		favorites = append(favorites, &model.Favorite{
+			ID:           uuid.New().String(),
+			UserID:       req.UserId,
+			DictionaryID: dictionaryID,
+			MemoryDepth:  req.MemoryDepth,
+			DueAt:        time.Now(),
+			Model: model.Model{
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			},
+		})
+	}
+
+	created, skipped, err := s.favoriteRepo.BatchAddFavorites(ctx, favorites, req.Tags)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("批量添加收藏失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "批量添加收藏失败")
+	}
+
+	response := &favorite.BatchAddFavoritesResponse{
+		SkippedDictionaryIds: skipped,
+	}
+	for _, fav := range created {
+		response.Favorites = append(response.Favorites, s.convertModelToProto(fav))
+	}
+
+	logger.FromContext(ctx, s.logger).Info("批量添加收藏成功",
+		zap.Int("created", len(created)),
+		zap.Int("skipped", len(skipped)))
+	return response, nil
+}
+
+// BatchRemoveFavorites 批量取消收藏
+func (s *FavoriteGRPCServer) BatchRemoveFavorites(ctx context.Context, req *favorite.BatchRemoveFavoritesRequest) (*favorite.BatchRemoveFavoritesResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("批量取消收藏",
+		zap.String("userID", req.UserId),
+		zap.Int("count", len(req.FavoriteIds)))
+
+	removed, err := s.favoriteRepo.BatchRemoveFavorites(ctx, req.UserId, req.FavoriteIds)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("批量取消收藏失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "批量取消收藏失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("批量取消收藏成功", zap.Int64("removed", removed))
+	return &favorite.BatchRemoveFavoritesResponse{RemovedCount: removed}, nil
+}
+
+// UpdateFavoriteNote 修改收藏笔记
+func (s *FavoriteGRPCServer) UpdateFavoriteNote(ctx context.Context, req *favorite.UpdateFavoriteNoteRequest) (*favorite.UpdateFavoriteNoteResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("修改收藏笔记",
+		zap.String("userID", req.UserId),
+		zap.String("favoriteID", req.FavoriteId))
+
+	fav, err := s.favoriteRepo.UpdateFavoriteNote(ctx, req.UserId, req.FavoriteId, req.Note, req.CustomExample)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("修改收藏笔记失败",
+			zap.String("userID", req.UserId),
+			zap.String("favoriteID", req.FavoriteId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "修改收藏笔记失败")
+	}
+
+	return &favorite.UpdateFavoriteNoteResponse{
+		Favorite: s.convertModelToProto(fav),
+	}, nil
+}
+
+// AddFavoriteTags 添加收藏标签
+func (s *FavoriteGRPCServer) AddFavoriteTags(ctx context.Context, req *favorite.FavoriteTagsRequest) (*favorite.FavoriteTagsResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("添加收藏标签",
+		zap.String("userID", req.UserId),
+		zap.String("favoriteID", req.FavoriteId),
+		zap.Strings("tags", req.Tags))
+
+	tags, err := s.favoriteRepo.AddFavoriteTags(ctx, req.UserId, req.FavoriteId, req.Tags)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("添加收藏标签失败",
+			zap.String("userID", req.UserId),
+			zap.String("favoriteID", req.FavoriteId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "添加收藏标签失败")
+	}
+
+	return &favorite.FavoriteTagsResponse{Tags: tags}, nil
+}
+
+// RemoveFavoriteTags 移除收藏标签
+func (s *FavoriteGRPCServer) RemoveFavoriteTags(ctx context.Context, req *favorite.FavoriteTagsRequest) (*favorite.FavoriteTagsResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("移除收藏标签",
+		zap.String("userID", req.UserId),
+		zap.String("favoriteID", req.FavoriteId),
+		zap.Strings("tags", req.Tags))
+
+	tags, err := s.favoriteRepo.RemoveFavoriteTags(ctx, req.UserId, req.FavoriteId, req.Tags)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("移除收藏标签失败",
+			zap.String("userID", req.UserId),
+			zap.String("favoriteID", req.FavoriteId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "移除收藏标签失败")
+	}
+
+	return &favorite.FavoriteTagsResponse{Tags: tags}, nil
+}
+
+// MoveFavorites 将收藏从一个标签移动到另一个标签
+func (s *FavoriteGRPCServer) MoveFavorites(ctx context.Context, req *favorite.MoveFavoritesRequest) (*favorite.MoveFavoritesResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("移动收藏",
+		zap.String("userID", req.UserId),
+		zap.String("fromTag", req.FromTag),
+		zap.String("toTag", req.ToTag),
+		zap.Int("count", len(req.FavoriteIds)))
+
+	moved, err := s.favoriteRepo.MoveFavorites(ctx, req.UserId, req.FavoriteIds, req.FromTag, req.ToTag)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("移动收藏失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "移动收藏失败")
+	}
+
+	return &favorite.MoveFavoritesResponse{MovedCount: moved}, nil
+}
+
+// ListFavoriteTags 查询用户的全部标签
+func (s *FavoriteGRPCServer) ListFavoriteTags(ctx context.Context, req *favorite.ListFavoriteTagsRequest) (*favorite.ListFavoriteTagsResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("查询收藏标签", zap.String("userID", req.UserId))
+
+	tags, err := s.favoriteRepo.ListFavoriteTags(ctx, req.UserId)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("查询收藏标签失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "查询收藏标签失败")
+	}
+
+	response := &favorite.ListFavoriteTagsResponse{}
+	for _, tag := range tags {
+		response.Tags = append(response.Tags, &favorite.TagCount{
+			Name:  tag.Name,
+			Count: tag.Count,
+		})
+	}
+	return response, nil
+}
+
+// ListFavoritesByTag 按标签查询收藏
+func (s *FavoriteGRPCServer) ListFavoritesByTag(ctx context.Context, req *favorite.ListFavoritesByTagRequest) (*favorite.ListFavoritesByTagResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("按标签查询收藏",
+		zap.String("userID", req.UserId),
+		zap.String("tag", req.Tag),
+		zap.Int32("limit", req.Limit),
+		zap.Int32("offset", req.Offset))
+
+	favorites, err := s.favoriteRepo.ListFavoritesByTag(ctx, req.UserId, req.Tag, int(req.Limit), int(req.Offset))
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("按标签查询收藏失败",
+			zap.String("userID", req.UserId),
+			zap.String("tag", req.Tag),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "查询收藏失败")
+	}
+
+	var protoFavorites []*favorite.Favorite
+	for _, fav := range favorites {
+		protoFavorites = append(protoFavorites, s.convertModelToProto(fav))
+	}
+
+	return &favorite.ListFavoritesByTagResponse{
+		Favorites: protoFavorites,
+	}, nil
+}
+
+// ListTrashedFavorites 查询回收站
+func (s *FavoriteGRPCServer) ListTrashedFavorites(ctx context.Context, req *favorite.ListTrashedFavoritesRequest) (*favorite.ListTrashedFavoritesResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("查询回收站",
+		zap.String("userID", req.UserId),
+		zap.Int32("limit", req.Limit),
+		zap.Int32("offset", req.Offset))
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	favorites, err := s.favoriteRepo.ListTrashedFavorites(ctx, req.UserId, limit, int(req.Offset))
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("查询回收站失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "查询回收站失败")
+	}
+
+	var protoFavorites []*favorite.Favorite
+	for _, fav := range favorites {
+		protoFavorites = append(protoFavorites, s.convertModelToProto(fav))
+	}
+
+	return &favorite.ListTrashedFavoritesResponse{
+		Favorites: protoFavorites,
+	}, nil
+}
+
+// ListTrashedStudyRecords 查询回收站中的学习记录
+func (s *FavoriteGRPCServer) ListTrashedStudyRecords(ctx context.Context, req *favorite.ListTrashedStudyRecordsRequest) (*favorite.ListTrashedStudyRecordsResponse, error) {
+	logger.FromContext(ctx, s.logger).Debug("查询学习记录回收站",
+		zap.String("userID", req.UserId),
+		zap.Int32("limit", req.Limit),
+		zap.Int32("offset", req.Offset))
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = 20
+	}
+
+	records, err := s.favoriteRepo.ListTrashedStudyRecords(ctx, req.UserId, limit, int(req.Offset))
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("查询学习记录回收站失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "查询学习记录回收站失败")
+	}
+
+	var protoRecords []*favorite.StudyRecord
+	for _, record := range records {
+		protoRecords = append(protoRecords, s.convertStudyRecordToProto(record))
+	}
+
+	return &favorite.ListTrashedStudyRecordsResponse{
+		StudyRecords: protoRecords,
+	}, nil
+}
+
+// RestoreFavorites 从回收站恢复收藏
+func (s *FavoriteGRPCServer) RestoreFavorites(ctx context.Context, req *favorite.RestoreFavoritesRequest) (*favorite.RestoreFavoritesResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("恢复收藏",
+		zap.String("userID", req.UserId),
+		zap.Int("count", len(req.FavoriteIds)))
+
+	restored, err := s.favoriteRepo.RestoreFavorites(ctx, req.UserId, req.FavoriteIds)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("恢复收藏失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "恢复收藏失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("恢复收藏成功", zap.Int64("restored", restored))
+	return &favorite.RestoreFavoritesResponse{RestoredCount: restored}, nil
+}
+
+// EmptyTrash 清空回收站
+func (s *FavoriteGRPCServer) EmptyTrash(ctx context.Context, req *favorite.EmptyTrashRequest) (*favorite.EmptyTrashResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("清空回收站", zap.String("userID", req.UserId))
+
+	purged, err := s.favoriteRepo.EmptyTrash(ctx, req.UserId)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("清空回收站失败",
+			zap.String("userID", req.UserId),
+			zap.Error(err))
+		return nil, errorsvar.Wrap(err, "清空回收站失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("清空回收站成功", zap.Int64("purged", purged))
+	return &favorite.EmptyTrashResponse{PurgedCount: purged}, nil
+}
+
+// timestampToTime 将可选的protobuf时间戳转换为时间指针
+func timestampToTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
 // convertModelToProto 将模型转换为protobuf消息
 func (s *FavoriteGRPCServer) convertModelToProto(fav *model.Favorite) *favorite.Favorite {
 	protoFav := &favorite.Favorite{
-		Id:           fav.ID,
-		UserId:       fav.UserID,
-		DictionaryId: fav.DictionaryID,
-		MemoryDepth:  fav.MemoryDepth,
-		CreatedAt:    timestamppb.New(fav.CreatedAt),
-		UpdatedAt:    timestamppb.New(fav.UpdatedAt),
+		Id:            fav.ID,
+		UserId:        fav.UserID,
+		DictionaryId:  fav.DictionaryID,
+		MemoryDepth:   fav.MemoryDepth,
+		Note:          fav.Note,
+		CustomExample: fav.CustomExample,
+		Tags:          fav.TagNames(),
+		LastResult:    fav.LastResult,
+		DueAt:         timestamppb.New(fav.DueAt),
+		CreatedAt:     timestamppb.New(fav.CreatedAt),
+		UpdatedAt:     timestamppb.New(fav.UpdatedAt),
+	}
+
+	if fav.DeletedAt.Valid {
+		protoFav.DeletedAt = timestamppb.New(fav.DeletedAt.Time)
+	}
+
+	// 转换关联的词典记录
+	if fav.Dictionary != nil {
+		protoFav.Dictionary = &favorite.FavoriteDictionary{
+			Id:              fav.Dictionary.ID,
+			SourceLang:      fav.Dictionary.SourceLang,
+			TargetLang:      fav.Dictionary.TargetLang,
+			SourceText:      fav.Dictionary.SourceText,
+			TranslatedText:  fav.Dictionary.TranslatedText,
+			PartOfSpeech:    fav.Dictionary.PartOfSpeech,
+			Ipa:             fav.Dictionary.IPA,
+			ExampleSentence: fav.Dictionary.ExampleSentence,
+		}
 	}
 
 	// 转换学习记录
@@ -248,11 +638,16 @@ func (s *FavoriteGRPCServer) convertModelToProto(fav *model.Favorite) *favorite.
 
 // convertStudyRecordToProto 将学习记录模型转换为protobuf消息
 func (s *FavoriteGRPCServer) convertStudyRecordToProto(record *model.StudyRecord) *favorite.StudyRecord {
-	return &favorite.StudyRecord{
-		Id:        record.ID,
-		Result:    record.Result,
-		Remark:    record.Remark,
-		CreatedAt: timestamppb.New(record.CreatedAt),
-		UpdatedAt: timestamppb.New(record.UpdatedAt),
+	protoRecord := &favorite.StudyRecord{
+		Id:         record.ID,
+		FavoriteId: record.FavoriteID,
+		Result:     record.Result,
+		Remark:     record.Remark,
+		CreatedAt:  timestamppb.New(record.CreatedAt),
+		UpdatedAt:  timestamppb.New(record.UpdatedAt),
+	}
+	if record.DeletedAt.Valid {
+		protoRecord.DeletedAt = timestamppb.New(record.DeletedAt.Time)
 	}
+	return protoRecord
 }
@@ -6,20 +6,62 @@ import (
 	"sync"
 	"time"
 
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	healthcheck "github.com/cheel98/flashcard-backend/internal/health"
+	"github.com/cheel98/flashcard-backend/proto/generated/dictionary"
+	"github.com/cheel98/flashcard-backend/proto/generated/favorite"
 	"github.com/cheel98/flashcard-backend/proto/generated/health"
+	"github.com/cheel98/flashcard-backend/proto/generated/translation"
+	"github.com/cheel98/flashcard-backend/proto/generated/user"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// serviceDependencies 每个服务依赖的组件，任一组件不可用时服务状态为NOT_SERVING
+// SMTP只影响发送验证码，不作为任何服务的硬依赖，其状态可单独查询；
+// 翻译引擎熔断器断开时翻译接口降级查询词典缓存，Translation的状态不计入整个服务器的状态（见softServices）
+var serviceDependencies = map[string][]string{
+	"UserService":       {healthcheck.ComponentPostgres, healthcheck.ComponentRedis},
+	"DictionaryService": {healthcheck.ComponentPostgres},
+	"FavoriteService":   {healthcheck.ComponentPostgres},
+	"Translation":       {healthcheck.ComponentTranslation},
+	"HealthService":     {},
+}
+
+// softServices 依赖可降级组件的服务，状态只供单独查询，不影响整个服务器的状态，
+// 避免翻译引擎故障时负载均衡器摘除所有实例
+var softServices = map[string]bool{
+	"Translation": true,
+}
+
+// standardServiceNames 服务在grpc.health.v1中使用的完整名称
+var standardServiceNames = map[string]string{
+	"UserService":       user.UserService_ServiceDesc.ServiceName,
+	"DictionaryService": dictionary.DictionaryService_ServiceDesc.ServiceName,
+	"FavoriteService":   favorite.FavoriteService_ServiceDesc.ServiceName,
+	"Translation":       translation.Translation_ServiceDesc.ServiceName,
+	"HealthService":     health.HealthService_ServiceDesc.ServiceName,
+}
+
 // HealthGRPCServer 健康检查gRPC服务实现
 type HealthGRPCServer struct {
 	health.UnimplementedHealthServiceServer
-	logger    *zap.Logger
-	services  map[string]health.HealthCheckResponse_ServingStatus
-	mu        sync.RWMutex
-	startTime time.Time
-	stats     *ServerStats
-	serverId  string
+	logger     *zap.Logger
+	services   map[string]health.HealthCheckResponse_ServingStatus
+	components map[string]healthcheck.Result
+	watchers   map[chan struct{}]struct{}
+	mu         sync.RWMutex
+	startTime  time.Time
+	stats      *ServerStats
+	cpu        *healthcheck.CPUSampler
+	standard   *grpchealth.Server
+	shutdown   bool
+	serverId   string
+	watchStop  chan struct{}
+	stopOnce   sync.Once
 }
 
 // ServerStats 服务器统计信息
@@ -33,27 +75,163 @@ type ServerStats struct {
 // NewHealthGRPCServer 创建新的健康检查gRPC服务
 func NewHealthGRPCServer(logger *zap.Logger) *HealthGRPCServer {
 	return &HealthGRPCServer{
-		logger:    logger,
-		services:  make(map[string]health.HealthCheckResponse_ServingStatus),
-		startTime: time.Now(),
-		stats:     &ServerStats{},
-		serverId:  "flashcard-backend-server",
+		logger:     logger,
+		services:   make(map[string]health.HealthCheckResponse_ServingStatus),
+		components: make(map[string]healthcheck.Result),
+		watchers:   make(map[chan struct{}]struct{}),
+		startTime:  time.Now(),
+		stats:      &ServerStats{},
+		cpu:        healthcheck.NewCPUSampler(),
+		standard:   grpchealth.NewServer(),
+		serverId:   "flashcard-backend-server",
+		watchStop:  make(chan struct{}),
 	}
 }
 
-// SetServingStatus 设置服务状态
+// StandardServer 返回标准的grpc.health.v1服务，供负载均衡器使用
+func (s *HealthGRPCServer) StandardServer() healthpb.HealthServer {
+	return s.standard
+}
+
+// SetServingStatus 设置服务状态，状态变化时通知所有Watch
 func (s *HealthGRPCServer) SetServingStatus(service string, status health.HealthCheckResponse_ServingStatus) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.setServingStatusLocked(service, status)
+}
+
+// UpdateDependencies 根据依赖组件的检查结果更新各服务状态
+func (s *HealthGRPCServer) UpdateDependencies(results []healthcheck.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdown {
+		return
+	}
+
+	changed := false
+	for _, result := range results {
+		previous, exists := s.components[result.Component]
+		if !exists || previous.Healthy() != result.Healthy() {
+			changed = true
+			s.logger.Info("Dependency status updated",
+				zap.String("component", result.Component),
+				zap.Bool("healthy", result.Healthy()),
+				zap.Error(result.Err))
+		}
+		s.components[result.Component] = result
+	}
+
+	for service, dependencies := range serviceDependencies {
+		status := health.HealthCheckResponse_SERVING
+		for _, component := range dependencies {
+			// 未配置检查器的组件视为可用
+			if result, ok := s.components[component]; ok && !result.Healthy() {
+				status = health.HealthCheckResponse_NOT_SERVING
+				break
+			}
+		}
+		s.setServingStatusLocked(service, status)
+	}
+
+	// 组件状态变化但服务状态不变时，仍需通知查询组件状态的Watch
+	if changed {
+		s.notifyWatchersLocked()
+	}
+}
+
+// Shutdown 将所有服务标记为NOT_SERVING，用于停机前让负载均衡器摘除流量
+func (s *HealthGRPCServer) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdown = true
+	for service := range s.services {
+		s.setServingStatusLocked(service, health.HealthCheckResponse_NOT_SERVING)
+	}
+	s.standard.Shutdown()
+}
+
+// StopWatches 结束所有Watch流，客户端收到UNAVAILABLE后应重新连接其他实例。
+// 在Shutdown之后调用，保证客户端先收到NOT_SERVING；否则长连接的流会阻塞gRPC服务器的优雅关闭
+func (s *HealthGRPCServer) StopWatches() {
+	s.stopOnce.Do(func() {
+		close(s.watchStop)
+	})
+}
+
+// setServingStatusLocked 设置服务状态并同步到标准健康检查服务，调用方需持有写锁
+func (s *HealthGRPCServer) setServingStatusLocked(service string, status health.HealthCheckResponse_ServingStatus) {
+	if previous, exists := s.services[service]; exists && previous == status {
+		return
+	}
 	s.services[service] = status
 	s.logger.Info("Service status updated",
 		zap.String("service", service),
 		zap.String("status", status.String()))
+
+	if name, ok := standardServiceNames[service]; ok {
+		s.standard.SetServingStatus(name, toStandardStatus(status))
+	}
+	overall, _ := s.overallStatusLocked()
+	s.standard.SetServingStatus("", toStandardStatus(overall))
+	s.notifyWatchersLocked()
+}
+
+// overallStatusLocked 计算整个服务器的状态，调用方需持有锁
+func (s *HealthGRPCServer) overallStatusLocked() (health.HealthCheckResponse_ServingStatus, string) {
+	for serviceName, serviceStatus := range s.services {
+		if softServices[serviceName] {
+			continue
+		}
+		if serviceStatus != health.HealthCheckResponse_SERVING {
+			return health.HealthCheckResponse_NOT_SERVING, "Service " + serviceName + " is not healthy"
+		}
+	}
+	return health.HealthCheckResponse_SERVING, "Server is healthy"
+}
+
+// notifyWatchersLocked 通知所有Watch状态可能已变化，调用方需持有写锁
+func (s *HealthGRPCServer) notifyWatchersLocked() {
+	for ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// 已有未处理的通知，Watch会读取最新状态
+		}
+	}
+}
+
+// subscribe 注册状态变化通知
+func (s *HealthGRPCServer) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+// unsubscribe 取消状态变化通知
+func (s *HealthGRPCServer) unsubscribe(ch chan struct{}) {
+	s.mu.Lock()
+	delete(s.watchers, ch)
+	s.mu.Unlock()
+}
+
+// toStandardStatus 转换为grpc.health.v1的状态
+func toStandardStatus(status health.HealthCheckResponse_ServingStatus) healthpb.HealthCheckResponse_ServingStatus {
+	switch status {
+	case health.HealthCheckResponse_SERVING:
+		return healthpb.HealthCheckResponse_SERVING
+	case health.HealthCheckResponse_NOT_SERVING:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	case health.HealthCheckResponse_SERVICE_UNKNOWN:
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	default:
+		return healthpb.HealthCheckResponse_UNKNOWN
+	}
 }
 
-// Check 检查服务健康状态
+// Check 检查服务健康状态，service也可以是依赖组件名称，如postgres、redis
 func (s *HealthGRPCServer) Check(ctx context.Context, req *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
-	s.incrementTotalRequests()
 	s.logger.Debug("Health check requested", zap.String("service", req.Service))
 
 	s.mu.RLock()
@@ -63,28 +241,23 @@ func (s *HealthGRPCServer) Check(ctx context.Context, req *health.HealthCheckReq
 	var message string
 
 	if req.Service == "" {
-		// 检查整个服务器状态
+		// 检查整个服务器状态，softServices以外的任何服务不健康则整个服务器不健康
+		status, message = s.overallStatusLocked()
+	} else if serviceStatus, exists := s.services[req.Service]; exists {
+		// 检查特定服务状态
+		status = serviceStatus
+		message = "Service status: " + serviceStatus.String()
+	} else if result, exists := s.components[req.Service]; exists {
+		// 检查依赖组件状态
 		status = health.HealthCheckResponse_SERVING
-		message = "Server is healthy"
-
-		// 如果有任何服务不健康，则整个服务器状态为不健康
-		for serviceName, serviceStatus := range s.services {
-			if serviceStatus != health.HealthCheckResponse_SERVING {
-				status = health.HealthCheckResponse_NOT_SERVING
-				message = "Some services are not healthy"
-				s.logger.Warn("Unhealthy service detected", zap.String("service", serviceName))
-				break
-			}
+		message = "Component is healthy"
+		if !result.Healthy() {
+			status = health.HealthCheckResponse_NOT_SERVING
+			message = "Component is not healthy: " + result.Err.Error()
 		}
 	} else {
-		// 检查特定服务状态
-		if serviceStatus, exists := s.services[req.Service]; exists {
-			status = serviceStatus
-			message = "Service status: " + serviceStatus.String()
-		} else {
-			status = health.HealthCheckResponse_SERVICE_UNKNOWN
-			message = "Service not found"
-		}
+		status = health.HealthCheckResponse_SERVICE_UNKNOWN
+		message = "Service not found"
 	}
 
 	return &health.HealthCheckResponse{
@@ -94,49 +267,53 @@ func (s *HealthGRPCServer) Check(ctx context.Context, req *health.HealthCheckReq
 	}, nil
 }
 
-// Watch 监听服务健康状态变化（流式）
+// Watch 监听服务健康状态变化（流式），先发送当前状态，之后仅在状态变化时推送
 func (s *HealthGRPCServer) Watch(req *health.HealthCheckRequest, stream health.HealthService_WatchServer) error {
 	s.logger.Info("Health watch started", zap.String("service", req.Service))
 	s.incrementActiveConnections()
 	defer s.decrementActiveConnections()
 
+	updates := s.subscribe()
+	defer s.unsubscribe(updates)
+
 	// 首先发送当前状态
-	currentStatus, err := s.Check(stream.Context(), req)
+	lastSent, err := s.Check(stream.Context(), req)
 	if err != nil {
 		return err
 	}
-
-	if err := stream.Send(currentStatus); err != nil {
+	if err := stream.Send(lastSent); err != nil {
 		s.logger.Error("Failed to send initial health status", zap.Error(err))
 		return err
 	}
 
-	// 定期发送状态更新
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
 	for {
 		select {
 		case <-stream.Context().Done():
 			s.logger.Info("Health watch stream closed", zap.String("service", req.Service))
 			return stream.Context().Err()
-		case <-ticker.C:
+		case <-s.watchStop:
+			s.logger.Info("Health watch stream stopped for shutdown", zap.String("service", req.Service))
+			return errorsvar.ErrShuttingDown
+		case <-updates:
 			status, err := s.Check(stream.Context(), req)
 			if err != nil {
 				s.logger.Error("Failed to check health status", zap.Error(err))
 				return err
 			}
+			if status.Status == lastSent.Status {
+				continue
+			}
 			if err := stream.Send(status); err != nil {
 				s.logger.Error("Failed to send health status update", zap.Error(err))
 				return err
 			}
+			lastSent = status
 		}
 	}
 }
 
 // Heartbeat 心跳检查
 func (s *HealthGRPCServer) Heartbeat(ctx context.Context, req *health.HeartbeatRequest) (*health.HeartbeatResponse, error) {
-	s.incrementTotalRequests()
 	s.logger.Debug("Heartbeat received", zap.String("client_id", req.ClientId))
 
 	// 获取服务器统计信息
@@ -165,7 +342,7 @@ func (s *HealthGRPCServer) getServerStats() *health.ServerStats {
 	return &health.ServerStats{
 		UptimeSeconds:     int64(uptime.Seconds()),
 		ActiveConnections: s.stats.activeConnections,
-		CpuUsage:          0.0,                               // 简化实现，实际项目中可以使用第三方库获取CPU使用率
+		CpuUsage:          s.cpu.Usage(),                     // 自上次查询以来的进程CPU使用率
 		MemoryUsage:       float64(m.Alloc) / float64(m.Sys), // 内存使用率
 		TotalRequests:     s.stats.totalRequests,
 		FailedRequests:    s.stats.failedRequests,
@@ -200,13 +377,44 @@ func (s *HealthGRPCServer) incrementFailedRequests() {
 	s.stats.failedRequests++
 }
 
-// InitializeServices 初始化服务状态
+// UnaryInterceptor 统计所有一元RPC的请求数和失败数
+func (s *HealthGRPCServer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		s.incrementTotalRequests()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			s.incrementFailedRequests()
+		}
+		return resp, err
+	}
+}
+
+// StreamInterceptor 统计所有流式RPC的请求数和失败数
+func (s *HealthGRPCServer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		s.incrementTotalRequests()
+		err := handler(srv, ss)
+		if err != nil && ss.Context().Err() == nil {
+			s.incrementFailedRequests()
+		}
+		return err
+	}
+}
+
+// InitializeServices 初始化服务状态，依赖服务在首次检查完成前为UNKNOWN
 func (s *HealthGRPCServer) InitializeServices() {
-	// 设置默认服务状态
-	s.SetServingStatus("UserService", health.HealthCheckResponse_SERVING)
-	s.SetServingStatus("DictionaryService", health.HealthCheckResponse_SERVING)
-	s.SetServingStatus("FavoriteService", health.HealthCheckResponse_SERVING)
-	s.SetServingStatus("HealthService", health.HealthCheckResponse_SERVING)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for service := range serviceDependencies {
+		if _, exists := s.services[service]; exists {
+			continue
+		}
+		status := health.HealthCheckResponse_UNKNOWN
+		if len(serviceDependencies[service]) == 0 {
+			status = health.HealthCheckResponse_SERVING
+		}
+		s.setServingStatusLocked(service, status)
+	}
 
-	s.logger.Info("Health service initialized with default service statuses")
+	s.logger.Info("Health service initialized, waiting for dependency checks")
 }
@@ -1,11 +1,20 @@
 package grpc
 
-import "go.uber.org/fx"
+import (
+	healthcheck "github.com/cheel98/flashcard-backend/internal/health"
+	"go.uber.org/fx"
+)
 
 var Module = fx.Options(
 	fx.Provide(NewUserGRPCServer),
 	fx.Provide(NewDictionaryGRPCServer),
 	fx.Provide(NewFavoriteGRPCServer),
 	fx.Provide(NewTranslationServerWithConfig),
+	// 翻译引擎的熔断器同时供健康检查读取状态
+	fx.Provide(fx.Annotate(NewTranslationBreaker, fx.ResultTags(`name:"translation"`))),
 	fx.Provide(NewHealthGRPCServer),
+	// 工作池由批量翻译等服务使用，服务器关闭时等待其处理完排队的任务
+	fx.Provide(NewDefaultWorkerPool),
+	// 依赖检查结果推送给健康检查服务
+	fx.Provide(func(s *HealthGRPCServer) healthcheck.Sink { return s }),
 )
@@ -2,9 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime"
+	"sync"
 	"time"
 
+	applog "github.com/cheel98/flashcard-backend/pkg/logger"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -66,12 +70,12 @@ func CreateOptimizedServer(config *PerformanceConfig, logger *zap.Logger) *grpc.
 	return CreateOptimizedServerWithInterceptors(config, logger, nil, nil)
 }
 
-// CreateOptimizedServerWithInterceptors 创建带有自定义拦截器的性能优化gRPC服务器
+// CreateOptimizedServerWithInterceptors 创建带有自定义拦截器的性能优化gRPC服务器，拦截器按传入顺序执行
 func CreateOptimizedServerWithInterceptors(
 	config *PerformanceConfig, 
 	logger *zap.Logger,
-	unaryInterceptor grpc.UnaryServerInterceptor,
-	streamInterceptor grpc.StreamServerInterceptor,
+	unaryInterceptors []grpc.UnaryServerInterceptor,
+	streamInterceptors []grpc.StreamServerInterceptor,
 ) *grpc.Server {
 	if config == nil {
 		config = DefaultPerformanceConfig()
@@ -102,22 +106,10 @@ func CreateOptimizedServerWithInterceptors(
 		grpc.ConnectionTimeout(config.ConnectionTimeout),
 	}
 
-	// 创建拦截器链
-	var unaryInterceptors []grpc.UnaryServerInterceptor
-	var streamInterceptors []grpc.StreamServerInterceptor
-
-	// 添加性能监控拦截器
+	// 创建拦截器链，性能监控拦截器在传入的拦截器之后执行，可以使用请求级日志
 	unaryInterceptors = append(unaryInterceptors, performanceUnaryInterceptor(logger))
 	streamInterceptors = append(streamInterceptors, performanceStreamInterceptor(logger))
 
-	// 添加自定义拦截器（如JWT认证）
-	if unaryInterceptor != nil {
-		unaryInterceptors = append(unaryInterceptors, unaryInterceptor)
-	}
-	if streamInterceptor != nil {
-		streamInterceptors = append(streamInterceptors, streamInterceptor)
-	}
-
 	// 添加拦截器链到服务器选项
 	if len(unaryInterceptors) > 0 {
 		opts = append(opts, grpc.ChainUnaryInterceptor(unaryInterceptors...))
@@ -152,14 +144,15 @@ func performanceUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor
 		
 		// 记录性能指标
 		duration := time.Since(start)
-		logger.Debug("Unary RPC completed",
+		reqLogger := applog.FromContext(ctx, logger)
+		reqLogger.Debug("Unary RPC completed",
 			zap.String("method", info.FullMethod),
 			zap.Duration("duration", duration),
 			zap.Bool("success", err == nil))
 		
 		// 如果请求时间过长，记录警告
 		if duration > 5*time.Second {
-			logger.Warn("Slow RPC detected",
+			reqLogger.Warn("Slow RPC detected",
 				zap.String("method", info.FullMethod),
 				zap.Duration("duration", duration))
 		}
@@ -178,7 +171,7 @@ func performanceStreamInterceptor(logger *zap.Logger) grpc.StreamServerIntercept
 		
 		// 记录性能指标
 		duration := time.Since(start)
-		logger.Debug("Stream RPC completed",
+		applog.FromContext(stream.Context(), logger).Debug("Stream RPC completed",
 			zap.String("method", info.FullMethod),
 			zap.Duration("duration", duration),
 			zap.Bool("success", err == nil))
@@ -187,12 +180,19 @@ func performanceStreamInterceptor(logger *zap.Logger) grpc.StreamServerIntercept
 	}
 }
 
+// ErrWorkerPoolStopped 工作池已停止，不再接受新任务
+var ErrWorkerPoolStopped = errors.New("worker pool stopped")
+
 // WorkerPool 工作池结构
 type WorkerPool struct {
 	workerCount int
 	jobQueue    chan func()
 	quit        chan bool
 	logger      *zap.Logger
+	wg          sync.WaitGroup
+	stopOnce    sync.Once
+	mutex       sync.RWMutex // 保证Stop之后不会再有任务进入队列
+	stopped     bool
 }
 
 // NewWorkerPool 创建新的工作池
@@ -205,47 +205,98 @@ func NewWorkerPool(workerCount int, queueSize int, logger *zap.Logger) *WorkerPo
 	}
 }
 
+// NewDefaultWorkerPool 按默认性能配置创建并启动工作池，服务器关闭时等待其处理完排队的任务
+func NewDefaultWorkerPool(logger *zap.Logger) *WorkerPool {
+	perfConfig := DefaultPerformanceConfig()
+	workerPool := NewWorkerPool(perfConfig.WorkerPoolSize, perfConfig.RequestBufferSize, logger)
+	workerPool.Start()
+	return workerPool
+}
+
 // Start 启动工作池
 func (wp *WorkerPool) Start() {
+	wp.wg.Add(wp.workerCount)
 	for i := 0; i < wp.workerCount; i++ {
 		go wp.worker(i)
 	}
 	wp.logger.Info("Worker pool started", zap.Int("workers", wp.workerCount))
 }
 
-// Stop 停止工作池
+// Stop 停止工作池，之后提交的任务返回ErrWorkerPoolStopped；工作协程处理完队列中已有的任务后退出，不等待
 func (wp *WorkerPool) Stop() {
-	close(wp.quit)
-	wp.logger.Info("Worker pool stopped")
+	wp.stopOnce.Do(func() {
+		wp.mutex.Lock()
+		wp.stopped = true
+		wp.mutex.Unlock()
+		close(wp.quit)
+	})
+}
+
+// Shutdown 停止工作池并等待队列中的任务处理完，ctx结束时返回剩余任务数对应的错误
+func (wp *WorkerPool) Shutdown(ctx context.Context) error {
+	wp.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		wp.logger.Info("Worker pool stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("worker pool drain timed out with %d queued jobs: %w", wp.QueueLength(), ctx.Err())
+	}
 }
 
-// Submit 提交任务到工作池
-func (wp *WorkerPool) Submit(job func()) {
+// Submit 提交任务到工作池。队列已满时等待，直到有空位或ctx结束；
+// 返回nil表示任务一定会被执行，工作池已停止时返回ErrWorkerPoolStopped，ctx结束时返回ctx.Err()
+func (wp *WorkerPool) Submit(ctx context.Context, job func()) error {
+	wp.mutex.RLock()
+	defer wp.mutex.RUnlock()
+	if wp.stopped {
+		return ErrWorkerPoolStopped
+	}
+
 	select {
 	case wp.jobQueue <- job:
-		// 任务已提交
-	default:
-		// 队列已满，直接执行
-		wp.logger.Warn("Worker pool queue full, executing job directly")
-		go job()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 // worker 工作协程
 func (wp *WorkerPool) worker(id int) {
+	defer wp.wg.Done()
 	for {
 		select {
 		case job := <-wp.jobQueue:
 			// 执行任务
 			job()
 		case <-wp.quit:
-			// 退出工作协程
+			// 处理完队列中剩余的任务后退出工作协程
+			wp.drain()
 			wp.logger.Debug("Worker stopped", zap.Int("worker_id", id))
 			return
 		}
 	}
 }
 
+// drain 执行队列中剩余的任务，队列为空时返回
+func (wp *WorkerPool) drain() {
+	for {
+		select {
+		case job := <-wp.jobQueue:
+			job()
+		default:
+			return
+		}
+	}
+}
+
 // GetWorkerPoolStats 获取工作池统计信息
 func (wp *WorkerPool) GetWorkerPoolStats() map[string]interface{} {
 	return map[string]interface{}{
@@ -254,4 +305,19 @@ func (wp *WorkerPool) GetWorkerPoolStats() map[string]interface{} {
 		"queue_capacity":  cap(wp.jobQueue),
 		"queue_usage":     float64(len(wp.jobQueue)) / float64(cap(wp.jobQueue)) * 100,
 	}
-}
\ No newline at end of file
+}
+
+// QueueLength 获取工作池当前排队的任务数
+func (wp *WorkerPool) QueueLength() int {
+	return len(wp.jobQueue)
+}
+
+// QueueCapacity 获取工作池队列容量
+func (wp *WorkerPool) QueueCapacity() int {
+	return cap(wp.jobQueue)
+}
+
+// WorkerCount 获取工作池的工作协程数
+func (wp *WorkerPool) WorkerCount() int {
+	return wp.workerCount
+}
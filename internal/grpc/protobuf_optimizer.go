@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
@@ -387,4 +388,91 @@ func (po *ProtobufOptimizer) GetCompressionStats() map[string]interface{} {
 	}
 
 	return stats
-}
\ No newline at end of file
+}
+// CompressorName 优化器压缩器在gRPC中注册的名称。与内置的gzip区分，不替换进程内其他客户端和服务器使用的gzip压缩器；
+// 数据格式仍是gzip，客户端以该名称注册gzip压缩器并通过grpc.UseCompressor(CompressorName)选择
+const CompressorName = "flashcard-gzip"
+
+// DefaultProtobufOptimizer 注册为gRPC压缩器的优化器，服务器从它读取压缩统计
+var DefaultProtobufOptimizer = NewProtobufOptimizer(DefaultProtobufOptimizerConfig(), zap.NewNop())
+
+// gRPC要求压缩器在init中注册，注册不是并发安全的
+func init() {
+	encoding.RegisterCompressor(DefaultProtobufOptimizer.Compressor())
+}
+
+// Compressor 返回使用优化器压缩器池的gRPC压缩器，压缩的消息计入压缩统计
+func (po *ProtobufOptimizer) Compressor() encoding.Compressor {
+	return &optimizerCompressor{optimizer: po}
+}
+
+// optimizerCompressor 实现encoding.Compressor
+type optimizerCompressor struct {
+	optimizer *ProtobufOptimizer
+}
+
+// Name 返回CompressorName
+func (c *optimizerCompressor) Name() string {
+	return CompressorName
+}
+
+// Compress 返回的writer在Close时完成压缩并记录压缩前后的字节数
+func (c *optimizerCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	po := c.optimizer
+	counter := &countingWriter{w: w}
+	var writer *gzip.Writer
+	if po.config.EnablePooling && po.compressors != nil {
+		writer = po.compressors.Get().(*gzip.Writer)
+		writer.Reset(counter)
+	} else {
+		var err error
+		writer, err = gzip.NewWriterLevel(counter, po.config.CompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &compressWriter{optimizer: po, writer: writer, counter: counter}, nil
+}
+
+// Decompress 解压gzip消息，大小限制由gRPC的MaxRecvMsgSize负责
+func (c *optimizerCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// countingWriter 统计写入底层writer的字节数
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
+
+// compressWriter 统计压缩前的字节数，Close时更新压缩指标并归还压缩器
+type compressWriter struct {
+	optimizer *ProtobufOptimizer
+	writer    *gzip.Writer
+	counter   *countingWriter
+	size      int
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.size += n
+	return n, err
+}
+
+func (c *compressWriter) Close() error {
+	po := c.optimizer
+	err := c.writer.Close()
+	if po.config.EnablePooling && po.compressors != nil {
+		po.compressors.Put(c.writer)
+	}
+	if err == nil && po.config.EnableMetrics {
+		po.updateCompressionMetrics(c.size, c.counter.n)
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestOptimizerCompressorRegistration(t *testing.T) {
+	if _, ok := encoding.GetCompressor(CompressorName).(*optimizerCompressor); !ok {
+		t.Fatalf("%s is not registered as the optimizer compressor", CompressorName)
+	}
+	// 内置的gzip压缩器不能被替换，进程内的其他客户端和服务器仍然使用它
+	if _, ok := encoding.GetCompressor("gzip").(*optimizerCompressor); ok {
+		t.Fatal("gzip compressor replaced by the optimizer compressor")
+	}
+}
+
+func TestOptimizerCompressorRoundTripAndMetrics(t *testing.T) {
+	for _, pooling := range []bool{true, false} {
+		config := DefaultProtobufOptimizerConfig()
+		config.EnablePooling = pooling
+		optimizer := NewProtobufOptimizer(config, zap.NewNop())
+		compressor := optimizer.Compressor()
+		if compressor.Name() != CompressorName {
+			t.Fatalf("Name() = %q, want %s", compressor.Name(), CompressorName)
+		}
+
+		message := bytes.Repeat([]byte("flashcard "), 200)
+		for i := 0; i < 2; i++ {
+			var compressed bytes.Buffer
+			writer, err := compressor.Compress(&compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writer.Write(message); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := compressor.Decompress(&compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decompressed, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decompressed, message) {
+				t.Fatalf("pooling=%v: decompressed message differs from original", pooling)
+			}
+		}
+
+		metrics := optimizer.GetMetrics()
+		if metrics.CompressionCount != 2 {
+			t.Errorf("pooling=%v: CompressionCount = %d, want 2", pooling, metrics.CompressionCount)
+		}
+		if metrics.TotalSerializedSize != int64(2*len(message)) {
+			t.Errorf("pooling=%v: TotalSerializedSize = %d, want %d", pooling, metrics.TotalSerializedSize, 2*len(message))
+		}
+		if metrics.AvgCompressionRatio <= 0 || metrics.AvgCompressionRatio >= 1 {
+			t.Errorf("pooling=%v: AvgCompressionRatio = %v, want between 0 and 1", pooling, metrics.AvgCompressionRatio)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/pkg/langdetect"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"github.com/cheel98/flashcard-backend/proto/generated/translation"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// autoLanguage 源语言为auto时自动检测
+const autoLanguage = "auto"
+
+// 批量翻译结果的来源
+const (
+	sourceCache  = "cache"
+	sourceEngine = "engine"
+)
+
+// detectMinConfidence 离线语言检测的置信度下限，低于该值的文本以auto交给翻译引擎识别
+const detectMinConfidence = 0.5
+
+// detectLanguages 离线检测的候选语言（ISO 639-1），均为翻译引擎支持的语言
+var detectLanguages = []string{"en", "zh", "ja", "ko", "fr", "de", "es", "pt", "it", "ru", "vi", "th", "id", "ar", "nl"}
+
+// youdaoLanguages ISO 639-1代码与有道语言代码不一致的部分
+var youdaoLanguages = map[string]string{"zh": "zh-CHS"}
+
+// newLanguageDetector 创建翻译使用的离线语言检测器
+func newLanguageDetector() *langdetect.Detector {
+	return langdetect.New(langdetect.Options{Languages: detectLanguages, MinConfidence: detectMinConfidence})
+}
+
+// batchItem 批量翻译中去重后的一条文本
+type batchItem struct {
+	text   string
+	from   string // 调用翻译引擎和查询缓存使用的源语言，检测失败时为auto
+	result *translation.BatchTranslateItem
+
+	dictionaryID uint64 // 命中缓存时的词典记录ID
+}
+
+// BatchTranslate 批量翻译。重复的文本只翻译一次；词典中已有的翻译直接返回，其余文本通过工作池并发调用翻译引擎，
+// 单个请求的并发数不超过 transfer_config.batch_concurrency。from为auto时先在本地检测语言，检测失败的文本由翻译引擎识别
+func (y *YouDaoTranslationServer) BatchTranslate(ctx context.Context, request *translation.BatchTranslateRequest) (*translation.BatchTranslateResponse, error) {
+	items := make(map[string]*batchItem, len(request.Q))
+	unique := make([]*batchItem, 0, len(request.Q))
+	for _, text := range request.Q {
+		if _, ok := items[text]; ok {
+			continue
+		}
+		item := &batchItem{text: text, from: request.From, result: &translation.BatchTranslateItem{Q: text}}
+		if request.From == autoLanguage {
+			if language, ok := y.detector.Detect(text); ok {
+				item.from = youdaoLanguage(language)
+				item.result.DetectedLanguage = item.from
+			}
+		}
+		items[text] = item
+		unique = append(unique, item)
+	}
+
+	pending := y.answerFromCache(ctx, unique, request.To)
+	if err := y.translateBatch(ctx, pending, request.To); err != nil {
+		return nil, err
+	}
+
+	response := &translation.BatchTranslateResponse{Items: make([]*translation.BatchTranslateItem, 0, len(request.Q))}
+	returned := make(map[string]bool, len(unique))
+	for _, text := range request.Q {
+		result := items[text].result
+		if returned[text] {
+			// 重复的文本返回结果的副本，避免同一条消息在响应中出现多次
+			result = proto.Clone(result).(*translation.BatchTranslateItem)
+		}
+		returned[text] = true
+		response.Items = append(response.Items, result)
+	}
+	return response, nil
+}
+
+// answerFromCache 按源语言分组批量查询词典，命中的条目直接填充结果，返回需要调用翻译引擎的条目。
+// 源语言未知的条目无法查询缓存；查询失败时全部交给翻译引擎
+func (y *YouDaoTranslationServer) answerFromCache(ctx context.Context, items []*batchItem, to string) []*batchItem {
+	groups := make(map[string][]*batchItem)
+	var pending []*batchItem
+	for _, item := range items {
+		if item.from == autoLanguage {
+			pending = append(pending, item)
+			continue
+		}
+		groups[item.from] = append(groups[item.from], item)
+	}
+
+	for from, group := range groups {
+		texts := make([]string, len(group))
+		for i, item := range group {
+			texts[i] = item.text
+		}
+		dictionaries, err := y.dicRepo.ListDictionariesBySourceTexts(ctx, from, to, texts)
+		if err != nil {
+			logger.FromContext(ctx, y.logger).Error("批量查询词典缓存失败", zap.String("from", from), zap.Error(err))
+			pending = append(pending, group...)
+			continue
+		}
+
+		cached := make(map[string]model.Dictionary, len(dictionaries))
+		for _, dictionary := range dictionaries {
+			cached[dictionary.SourceText] = dictionary
+		}
+		for _, item := range group {
+			dictionary, ok := cached[item.text]
+			if !ok {
+				pending = append(pending, item)
+				continue
+			}
+			item.dictionaryID = dictionary.ID
+			item.result.Translation = []string{dictionary.TranslatedText}
+			item.result.Source = sourceCache
+		}
+	}
+	return pending
+}
+
+// translateBatch 通过工作池并发翻译，ctx结束后不再提交新的任务；工作池已停止时返回ErrShuttingDown
+func (y *YouDaoTranslationServer) translateBatch(ctx context.Context, items []*batchItem, to string) error {
+	slots := make(chan struct{}, y.batchConcurrency)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			// 等待已提交的任务结束，它们仍在写入条目的结果
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		err := y.workerPool.Submit(ctx, func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			y.translateItem(ctx, item, to)
+		})
+		if err != nil {
+			<-slots
+			wg.Done()
+			wg.Wait()
+			if errors.Is(err, ErrWorkerPoolStopped) {
+				return errorsvar.ErrShuttingDown.WithCause(err)
+			}
+			return err
+		}
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// translateItem 调用翻译引擎翻译单条文本，失败时在结果中记录错误码
+func (y *YouDaoTranslationServer) translateItem(ctx context.Context, item *batchItem, to string) {
+	res, err := y.translate(ctx, &translation.TranslationRequest{Q: item.text, From: item.from, To: to})
+	switch {
+	case err != nil:
+		item.result.ErrorCode = errorsvar.ReasonTranslationUnavailable
+	case res.ErrorCode != "0":
+		item.result.ErrorCode = res.ErrorCode
+	default:
+		item.result.Translation = res.Translation
+		item.result.Source = sourceEngine
+		if item.from == autoLanguage {
+			// 翻译引擎在l中返回识别到的语言方向，例如en2zh-CHS
+			item.result.DetectedLanguage, _, _ = strings.Cut(res.L, "2")
+		}
+	}
+}
+
+// youdaoLanguage 将ISO 639-1代码转换为有道的语言代码
+func youdaoLanguage(language string) string {
+	if code, ok := youdaoLanguages[language]; ok {
+		return code
+	}
+	return language
+}
@@ -0,0 +1,343 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
+	"github.com/cheel98/flashcard-backend/proto/generated/translation"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	englishText   = "Where is the nearest train station, please?"
+	englishText2  = "This is a simple sentence written in plain English for the test"
+	japaneseText  = "こんにちは、元気ですか"
+	koreanText    = "안녕하세요 반갑습니다"
+	chineseText   = "今天天气很好"
+	ukrainianText = "Привіт, як справи? Сьогодні гарна погода"
+)
+
+// batchResponses 模拟翻译引擎对各原文的响应
+var batchResponses = engineTransport{
+	"card":        `{"errorCode":"0","translation":["卡片"],"l":"en2zh-CHS"}`,
+	"deck":        `{"errorCode":"0","translation":["牌组"],"l":"en2zh-CHS"}`,
+	"ok":          `{"errorCode":"0","translation":["好"],"l":"en2zh-CHS"}`,
+	japaneseText:  `{"errorCode":"0","translation":["你好吗"],"l":"ja2zh-CHS"}`,
+	ukrainianText: `{"errorCode":"0","translation":["Hi"],"l":"ru2en"}`,
+}
+
+// batchTransport 模拟翻译引擎，记录每次调用的原文和源语言以及同时进行的最大调用数。
+// release不为nil时每次调用都等待release关闭，started收到已开始调用的原文
+type batchTransport struct {
+	responses engineTransport
+	release   chan struct{}
+	started   chan string
+
+	mu          sync.Mutex
+	calls       map[string]string // 原文 -> 源语言
+	inFlight    int
+	maxInFlight int
+}
+
+func newBatchTransport(blocking bool) *batchTransport {
+	transport := &batchTransport{responses: batchResponses, calls: make(map[string]string)}
+	if blocking {
+		transport.release = make(chan struct{})
+		transport.started = make(chan string, 16)
+	}
+	return transport
+}
+
+func (t *batchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.calls[req.PostForm.Get("q")] = req.PostForm.Get("from")
+	t.inFlight++
+	t.maxInFlight = max(t.maxInFlight, t.inFlight)
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+
+	if t.release != nil {
+		t.started <- req.PostForm.Get("q")
+		<-t.release
+	}
+	return t.responses.RoundTrip(req)
+}
+
+// callCount 返回翻译引擎被调用的次数
+func (t *batchTransport) callCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.calls)
+}
+
+// waitStarted 等待n次引擎调用开始
+func (t *batchTransport) waitStarted(tb testing.TB, n int) {
+	tb.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-t.started:
+		case <-time.After(time.Second):
+			tb.Fatalf("engine calls started = %d, want %d", i, n)
+		}
+	}
+}
+
+func newBatchTestServer(t *testing.T, dicRepo repository.DictionaryRepository, transport http.RoundTripper, concurrency int) *YouDaoTranslationServer {
+	t.Helper()
+	workerPool := NewWorkerPool(4, 16, zap.NewNop())
+	workerPool.Start()
+	t.Cleanup(workerPool.Stop)
+
+	server := newTranslationServer(dicRepo, "https://openapi.youdao.com/api", "key", "secret")
+	server.client = httpclient.New(httpclient.Options{Transport: transport}, zap.NewNop())
+	server.workerPool = workerPool
+	server.detector = newLanguageDetector()
+	server.batchConcurrency = concurrency
+	return server
+}
+
+// cachedDictionary 返回源语言到目标语言的词典记录
+func cachedDictionary(id uint64, from, to, text, translated string) *model.Dictionary {
+	return &model.Dictionary{ID: id, SourceLang: from, TargetLang: to, SourceText: text, TranslatedText: translated}
+}
+
+func TestBatchTranslate(t *testing.T) {
+	tests := []struct {
+		name         string
+		dictionaries []*model.Dictionary
+		listErr      error
+		from, to     string
+		q            []string
+		wantQueries  []string          // 按源语言排序
+		wantCalls    map[string]string // 原文 -> 调用引擎时的源语言
+		want         []*translation.BatchTranslateItem
+	}{
+		{
+			name:        "deduplicate",
+			from:        "en",
+			to:          "zh-CHS",
+			q:           []string{"card", "deck", "card"},
+			wantQueries: []string{"en"},
+			wantCalls:   map[string]string{"card": "en", "deck": "en"},
+			want: []*translation.BatchTranslateItem{
+				{Q: "card", Translation: []string{"卡片"}, Source: sourceEngine},
+				{Q: "deck", Translation: []string{"牌组"}, Source: sourceEngine},
+				{Q: "card", Translation: []string{"卡片"}, Source: sourceEngine},
+			},
+		},
+		{
+			name: "cache hits grouped by source language",
+			dictionaries: []*model.Dictionary{
+				cachedDictionary(1, "en", "zh-CHS", englishText, "最近的火车站在哪里？"),
+				cachedDictionary(2, "en", "zh-CHS", englishText2, "测试用的英文句子"),
+				cachedDictionary(3, "ko", "zh-CHS", koreanText, "你好，很高兴见到你"),
+			},
+			from:        autoLanguage,
+			to:          "zh-CHS",
+			q:           []string{englishText, japaneseText, koreanText, englishText2},
+			wantQueries: []string{"en", "ja", "ko"},
+			wantCalls:   map[string]string{japaneseText: "ja"},
+			want: []*translation.BatchTranslateItem{
+				{Q: englishText, Translation: []string{"最近的火车站在哪里？"}, Source: sourceCache, DetectedLanguage: "en"},
+				{Q: japaneseText, Translation: []string{"你好吗"}, Source: sourceEngine, DetectedLanguage: "ja"},
+				{Q: koreanText, Translation: []string{"你好，很高兴见到你"}, Source: sourceCache, DetectedLanguage: "ko"},
+				{Q: englishText2, Translation: []string{"测试用的英文句子"}, Source: sourceCache, DetectedLanguage: "en"},
+			},
+		},
+		{
+			name:         "detect within supported languages",
+			dictionaries: []*model.Dictionary{cachedDictionary(1, "zh-CHS", "en", chineseText, "The weather is nice today")},
+			from:         autoLanguage,
+			to:           "en",
+			q:            []string{chineseText, ukrainianText},
+			wantQueries:  []string{"ru", "zh-CHS"},
+			wantCalls:    map[string]string{ukrainianText: "ru"},
+			want: []*translation.BatchTranslateItem{
+				{Q: chineseText, Translation: []string{"The weather is nice today"}, Source: sourceCache, DetectedLanguage: "zh-CHS"},
+				{Q: ukrainianText, Translation: []string{"Hi"}, Source: sourceEngine, DetectedLanguage: "ru"},
+			},
+		},
+		{
+			name:      "undetected text left to engine",
+			from:      autoLanguage,
+			to:        "zh-CHS",
+			q:         []string{"ok"},
+			wantCalls: map[string]string{"ok": autoLanguage},
+			want: []*translation.BatchTranslateItem{
+				{Q: "ok", Translation: []string{"好"}, Source: sourceEngine, DetectedLanguage: "en"},
+			},
+		},
+		{
+			name:        "engine error code",
+			from:        "en",
+			to:          "zh-CHS",
+			q:           []string{"unknown"},
+			wantQueries: []string{"en"},
+			wantCalls:   map[string]string{"unknown": "en"},
+			want:        []*translation.BatchTranslateItem{{Q: "unknown", ErrorCode: "113"}},
+		},
+		{
+			name:         "cache error falls back to engine",
+			dictionaries: []*model.Dictionary{cachedDictionary(1, "en", "zh-CHS", "card", "缓存的卡片")},
+			listErr:      errors.New("connection refused"),
+			from:         "en",
+			to:           "zh-CHS",
+			q:            []string{"card"},
+			wantQueries:  []string{"en"},
+			wantCalls:    map[string]string{"card": "en"},
+			want:         []*translation.BatchTranslateItem{{Q: "card", Translation: []string{"卡片"}, Source: sourceEngine}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dicRepo := &fakeDictionaryRepository{dictionaries: tt.dictionaries, listErr: tt.listErr}
+			transport := newBatchTransport(false)
+			server := newBatchTestServer(t, dicRepo, transport, 2)
+
+			response, err := server.BatchTranslate(context.Background(), &translation.BatchTranslateRequest{Q: tt.q, From: tt.from, To: tt.to})
+			if err != nil {
+				t.Fatalf("BatchTranslate: %v", err)
+			}
+
+			if len(response.Items) != len(tt.want) {
+				t.Fatalf("got %d items, want %d", len(response.Items), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !proto.Equal(response.Items[i], want) {
+					t.Errorf("item %d = %v, want %v", i, response.Items[i], want)
+				}
+				for j := 0; j < i; j++ {
+					if response.Items[j] == response.Items[i] {
+						t.Errorf("items %d and %d share the same message", j, i)
+					}
+				}
+			}
+
+			sort.Strings(dicRepo.queries)
+			if len(dicRepo.queries) != len(tt.wantQueries) {
+				t.Fatalf("cache queries = %v, want %v", dicRepo.queries, tt.wantQueries)
+			}
+			for i := range tt.wantQueries {
+				if dicRepo.queries[i] != tt.wantQueries[i] {
+					t.Fatalf("cache queries = %v, want %v", dicRepo.queries, tt.wantQueries)
+				}
+			}
+
+			if len(transport.calls) != len(tt.wantCalls) {
+				t.Fatalf("engine calls = %v, want %v", transport.calls, tt.wantCalls)
+			}
+			for text, from := range tt.wantCalls {
+				if got, ok := transport.calls[text]; !ok || got != from {
+					t.Errorf("engine call for %q from = %q, want %q", text, got, from)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchTranslateConcurrencyLimit(t *testing.T) {
+	transport := newBatchTransport(true)
+	server := newBatchTestServer(t, &fakeDictionaryRepository{}, transport, 2)
+
+	q := []string{"a", "b", "c", "d", "e", "f"}
+	type result struct {
+		response *translation.BatchTranslateResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := server.BatchTranslate(context.Background(), &translation.BatchTranslateRequest{Q: q, From: "en", To: "zh-CHS"})
+		done <- result{response, err}
+	}()
+
+	// 工作池有4个工作协程，同时调用引擎的请求数仍然受batch_concurrency限制
+	transport.waitStarted(t, 2)
+	select {
+	case text := <-transport.started:
+		t.Fatalf("engine call for %q started beyond the concurrency limit", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(transport.release)
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("BatchTranslate: %v", res.err)
+	}
+	if len(res.response.Items) != len(q) {
+		t.Fatalf("got %d items, want %d", len(res.response.Items), len(q))
+	}
+	if transport.callCount() != len(q) {
+		t.Errorf("engine called %d times, want %d", transport.callCount(), len(q))
+	}
+	if transport.maxInFlight != 2 {
+		t.Errorf("max concurrent engine calls = %d, want 2", transport.maxInFlight)
+	}
+}
+
+func TestBatchTranslateStopsMidBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(cancel context.CancelFunc, server *YouDaoTranslationServer)
+		want error
+	}{
+		{
+			name: "context canceled",
+			stop: func(cancel context.CancelFunc, server *YouDaoTranslationServer) { cancel() },
+			want: context.Canceled,
+		},
+		{
+			name: "worker pool stopped",
+			stop: func(cancel context.CancelFunc, server *YouDaoTranslationServer) { server.workerPool.Stop() },
+			want: errorsvar.ErrShuttingDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newBatchTransport(true)
+			server := newBatchTestServer(t, &fakeDictionaryRepository{}, transport, 2)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() {
+				_, err := server.BatchTranslate(ctx, &translation.BatchTranslateRequest{Q: []string{"a", "b", "c", "d", "e"}, From: "en", To: "zh-CHS"})
+				done <- err
+			}()
+
+			transport.waitStarted(t, 2)
+			tt.stop(cancel, server)
+
+			// 返回前等待已提交的任务结束
+			select {
+			case err := <-done:
+				t.Fatalf("BatchTranslate returned %v before in-flight calls finished", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+			close(transport.release)
+
+			if err := <-done; !errors.Is(err, tt.want) {
+				t.Fatalf("BatchTranslate: got %v, want %v", err, tt.want)
+			}
+			if transport.callCount() != 2 {
+				t.Errorf("engine called %d times, want 2", transport.callCount())
+			}
+		})
+	}
+}
@@ -2,25 +2,135 @@ package grpc
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
 	"github.com/cheel98/flashcard-backend/internal/config"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/metrics"
+	"github.com/cheel98/flashcard-backend/internal/ocr"
 	"github.com/cheel98/flashcard-backend/internal/repository"
-	"github.com/cheel98/flashcard-backend/internal/utils"
 	"github.com/cheel98/flashcard-backend/internal/utils/authv3"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
+	"github.com/cheel98/flashcard-backend/pkg/langdetect"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"github.com/cheel98/flashcard-backend/pkg/resilience"
 	"github.com/cheel98/flashcard-backend/proto/generated/translation"
-	"log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// translationEngine 翻译引擎名称，用于指标标签
+const translationEngine = "youdao"
+
+// TranslationSourceHeader 响应头，值为cache时表示翻译引擎不可用，结果来自词典缓存
+const TranslationSourceHeader = "translation-source"
+
 type YouDaoTranslationServer struct {
 	translation.UnimplementedTranslationServer
 	dicRepo   repository.DictionaryRepository
 	url       string
 	appKey    string
 	appSecret string
+	client    *httpclient.Client
+	metrics   *metrics.Metrics
+	logger    *zap.Logger
+
+	// 文本翻译和图片翻译引擎各自的熔断器和并发隔离
+	textGuard  engineGuard
+	imageGuard engineGuard
+
+	// 批量翻译
+	workerPool       *WorkerPool
+	detector         *langdetect.Detector
+	batchConcurrency int
+
+	// 拍照翻译
+	ocr          ocr.Engine
+	favoriteRepo repository.FavoriteRepository
+	maxImageSize int
+}
+
+// TranslationServerParams 翻译服务依赖
+type TranslationServerParams struct {
+	fx.In
+
+	DictRepo     repository.DictionaryRepository
+	FavoriteRepo repository.FavoriteRepository
+	Config       *config.Config
+	Client       *httpclient.Client
+	OCR          ocr.Engine
+	Breaker      *resilience.Breaker `name:"translation"`
+	WorkerPool   *WorkerPool
+	Metrics      *metrics.Metrics
+	Logger       *zap.Logger
+}
+
+func NewTranslationServerWithConfig(p TranslationServerParams) *YouDaoTranslationServer {
+	cfg := p.Config.TransferConfig
+	server := newTranslationServer(p.DictRepo, cfg.URL, cfg.AppKey, cfg.AppSecret)
+	server.client = p.Client
+	server.textGuard.breaker = p.Breaker
+	server.textGuard.bulkhead = newEngineBulkhead(cfg, p.Metrics, "translation_in_flight", "正在调用翻译引擎的请求数")
+	server.imageGuard = engineGuard{
+		engine:   p.OCR.Name(),
+		breaker:  newEngineBreaker(p.OCR.Name(), p.Config, p.Metrics, p.Logger),
+		bulkhead: newEngineBulkhead(cfg, p.Metrics, "ocr_in_flight", "正在调用图片翻译引擎的请求数"),
+	}
+	server.workerPool = p.WorkerPool
+	server.detector = newLanguageDetector()
+	server.batchConcurrency = cfg.BatchConcurrency
+	server.ocr = p.OCR
+	server.favoriteRepo = p.FavoriteRepo
+	server.maxImageSize = cfg.OCR.MaxImageSize * 1024
+	server.metrics = p.Metrics
+	server.logger = p.Logger
+	return server
 }
 
-func NewTranslationServerWithConfig(dictRepo repository.DictionaryRepository, config *config.Config) *YouDaoTranslationServer {
-	return newTranslationServer(dictRepo, config.TransferConfig.URL, config.TransferConfig.AppKey, config.TransferConfig.AppSecret)
+// NewTranslationBreaker 创建翻译引擎的熔断器，未开启时返回nil
+func NewTranslationBreaker(cfg *config.Config, m *metrics.Metrics, logger *zap.Logger) *resilience.Breaker {
+	return newEngineBreaker(translationEngine, cfg, m, logger)
+}
+
+// newEngineBreaker 按transfer_config.circuit_breaker为一个引擎创建熔断器，熔断器以引擎名称作为指标标签，未开启时返回nil
+func newEngineBreaker(engine string, cfg *config.Config, m *metrics.Metrics, logger *zap.Logger) *resilience.Breaker {
+	breakerCfg := cfg.TransferConfig.CircuitBreaker
+	if !breakerCfg.Enabled {
+		return nil
+	}
+	m.SetCircuitState(engine, resilience.StateClosed)
+	return resilience.NewBreaker(engine, resilience.BreakerOptions{
+		Window:           time.Duration(breakerCfg.Window) * time.Second,
+		MinRequests:      breakerCfg.MinRequests,
+		FailureRate:      breakerCfg.FailureRate,
+		OpenTimeout:      time.Duration(breakerCfg.OpenTimeout) * time.Second,
+		HalfOpenRequests: breakerCfg.HalfOpenRequests,
+		OnStateChange: func(name string, from, to resilience.State) {
+			m.SetCircuitState(name, to)
+			logger.Warn("翻译引擎熔断器状态变化",
+				zap.String("engine", name),
+				zap.Stringer("from", from),
+				zap.Stringer("to", to))
+		},
+	})
+}
+
+// newEngineBulkhead 按transfer_config.max_concurrency为一个引擎创建并发隔离，并注册正在调用引擎的请求数指标，未限制并发时返回nil
+func newEngineBulkhead(cfg config.TransferConfig, m *metrics.Metrics, name, help string) *resilience.Bulkhead {
+	if cfg.MaxConcurrency <= 0 {
+		return nil
+	}
+	bulkhead := resilience.NewBulkhead(cfg.MaxConcurrency, time.Duration(cfg.MaxWait)*time.Millisecond)
+	m.RegisterGaugeFunc(name, help, func() float64 {
+		return float64(bulkhead.InFlight())
+	})
+	return bulkhead
 }
 
 func newTranslationServer(dicRepo repository.DictionaryRepository, url, appKey, appSecret string) *YouDaoTranslationServer {
@@ -29,29 +139,180 @@ func newTranslationServer(dicRepo repository.DictionaryRepository, url, appKey,
 		url:       url,
 		appKey:    appKey,
 		appSecret: appSecret,
+		logger:    zap.NewNop(),
+		textGuard: engineGuard{engine: translationEngine},
 	}
 }
 
+// Translation 调用翻译引擎翻译。熔断器断开、并发已满、引擎请求失败或返回非请求导致的错误码时降级查询词典缓存，
+// 缓存中没有时返回UNAVAILABLE
 func (y *YouDaoTranslationServer) Translation(ctx context.Context, request *translation.TranslationRequest) (*translation.TranslationResponse, error) {
+	res, err := y.translate(ctx, request)
+	if err == nil {
+		return res, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if cached, ok := y.fallback(ctx, request); ok {
+		return cached, nil
+	}
+	return nil, errorsvar.ErrTranslationUnavailable.WithCause(err)
+}
+
+// engineGuard 一个引擎的熔断器和并发隔离。文本翻译和图片翻译各用一个，
+// 图片翻译引擎故障或上传缓慢时不会断开文本翻译的熔断器或占满其并发
+type engineGuard struct {
+	engine   string
+	breaker  *resilience.Breaker
+	bulkhead *resilience.Bulkhead
+}
+
+// guard 经过引擎的并发隔离和熔断器获取调用引擎的许可。成功时返回done，调用方必须在请求结束后以请求是否成功调用一次
+func (y *YouDaoTranslationServer) guard(ctx context.Context, g *engineGuard) (done func(success bool), err error) {
+	release := func() {}
+	if g.bulkhead != nil {
+		if release, err = g.bulkhead.Acquire(ctx); err != nil {
+			y.reject(g.engine, "bulkhead_full")
+			logger.FromContext(ctx, y.logger).Warn("翻译引擎并发已满", zap.String("engine", g.engine), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	record := func(bool) {}
+	if g.breaker != nil {
+		if record, err = g.breaker.Allow(); err != nil {
+			release()
+			y.reject(g.engine, "circuit_open")
+			return nil, err
+		}
+	}
+	return func(success bool) {
+		record(success)
+		release()
+	}, nil
+}
+
+// translate 经过并发隔离和熔断器调用翻译引擎
+func (y *YouDaoTranslationServer) translate(ctx context.Context, request *translation.TranslationRequest) (*translation.TranslationResponse, error) {
+	done, err := y.guard(ctx, &y.textGuard)
+	if err != nil {
+		return nil, err
+	}
+
 	params := make(map[string][]string)
 	params["q"] = []string{request.Q}
 	params["from"] = []string{request.From}
 	params["to"] = []string{request.To}
 	authv3.AddAuthParams(y.appKey, y.appSecret, params)
 	res := &translation.TranslationResponse{}
-	result := y.SendToEngine(params)
-	log.Println(string(result))
-	err := json.Unmarshal(result, res)
+	start := time.Now()
+	err = y.SendToEngine(ctx, params, res)
+	if err == nil && res.ErrorCode != "0" && !callerErrorCode(res.ErrorCode) {
+		// 引擎以HTTP 200返回访问频率受限、账户欠费等错误码时同样视为引擎不可用
+		err = &engineCodeError{code: res.ErrorCode}
+	}
+	y.observe(start, res, err)
+	done(!engineFailure(ctx, err))
 	if err != nil {
+		logger.FromContext(ctx, y.logger).Error("调用翻译引擎失败", zap.Error(err))
 		return nil, err
 	}
 	return res, nil
 }
 
-func (y *YouDaoTranslationServer) SendToEngine(params map[string][]string) []byte {
-	header := map[string][]string{
-		"Content-Type": {"application/x-www-form-urlencoded"},
+// fallback 从词典表查询相同语言和原文的翻译，命中时在响应头中标记结果来自缓存
+func (y *YouDaoTranslationServer) fallback(ctx context.Context, request *translation.TranslationRequest) (*translation.TranslationResponse, bool) {
+	dictionary, err := y.dicRepo.GetDictionaryByUniqueTranslation(ctx, request.From, request.To, request.Q)
+	if y.metrics != nil {
+		y.metrics.ObserveTranslationFallback(translationEngine, err == nil)
+	}
+	if err != nil {
+		if !errors.Is(err, errorsvar.ErrDictionaryNotFound) {
+			logger.FromContext(ctx, y.logger).Error("查询词典缓存失败", zap.Error(err))
+		}
+		return nil, false
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(TranslationSourceHeader, "cache")); err != nil {
+		logger.FromContext(ctx, y.logger).Warn("设置响应头失败", zap.Error(err))
+	}
+	return &translation.TranslationResponse{
+		ErrorCode:   "0",
+		Query:       request.Q,
+		Translation: []string{dictionary.TranslatedText},
+		L:           dictionary.SourceLang + "2" + dictionary.TargetLang,
+	}, true
+}
+
+// engineFailure 请求失败是否说明翻译引擎不可用。调用方取消的请求和除429以外的4xx响应不计入熔断器的失败率，
+// engineCodeError计入
+func engineFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var statusErr *httpclient.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode < http.StatusInternalServerError {
+		return statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// callerErrorCodes 有道返回的由请求本身导致的错误码（参数缺失、语言不支持、文本或图片不合法等），
+// 直接返回给调用方且不计入熔断器的失败率；其他错误码（访问频率受限、账户欠费、服务异常等）说明引擎当前不可用
+var callerErrorCodes = map[string]bool{
+	"101":  true, // 缺少必填的参数
+	"102":  true, // 不支持的语言类型
+	"103":  true, // 翻译文本过长
+	"113":  true, // q不能为空
+	"114":  true, // 不支持的图片传输方式
+	"116":  true, // strict字段取值无效
+	"1001": true, // 无效的OCR类型
+	"1002": true, // 不支持的OCR image类型
+	"1003": true, // 不支持的OCR Language类型
+	"1004": true, // 识别图片过大
+	"1201": true, // 图片base64解密失败
+	"1412": true, // 超过最大识别字节数
+}
+
+// callerErrorCode 错误码是否由请求本身导致
+func callerErrorCode(code string) bool {
+	return callerErrorCodes[code]
+}
+
+// engineCodeError 翻译引擎返回了说明其不可用的错误码
+type engineCodeError struct {
+	code string
+}
+
+func (e *engineCodeError) Error() string {
+	return "翻译引擎返回错误码: " + e.code
+}
+
+// reject 记录未发送给引擎的请求
+func (y *YouDaoTranslationServer) reject(engine, reason string) {
+	if y.metrics != nil {
+		y.metrics.ObserveTranslationRejected(engine, reason)
+	}
+}
+
+// observe 记录翻译引擎请求指标，请求失败、响应无法解析或引擎返回错误码都计为失败
+func (y *YouDaoTranslationServer) observe(start time.Time, res *translation.TranslationResponse, err error) {
+	if y.metrics == nil {
+		return
+	}
+	if err == nil && res.ErrorCode != "0" {
+		err = fmt.Errorf("翻译引擎返回错误码: %s", res.ErrorCode)
+	}
+	y.metrics.ObserveTranslation(translationEngine, start, err)
+}
+
+// SendToEngine 将请求发送给翻译引擎并解析响应到res。请求在熔断器和隔舱内执行且按次计费，
+// 不在客户端重试，失败交给熔断器和词典缓存降级处理
+func (y *YouDaoTranslationServer) SendToEngine(ctx context.Context, params map[string][]string, res *translation.TranslationResponse) error {
+	response, err := y.client.PostForm(ctx, y.url, url.Values(params), nil, false)
+	if err != nil {
+		return err
 	}
-	res := utils.DoPost(y.url, header, params, "application/json")
-	return res
+	logger.FromContext(ctx, y.logger).Debug("翻译引擎响应", zap.ByteString("response", response.Body))
+	return response.DecodeJSON(res)
 }
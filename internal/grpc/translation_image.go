@@ -0,0 +1,256 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/ocr"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"github.com/cheel98/flashcard-backend/proto/generated/translation"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// imageContentTypes 拍照翻译支持的图片格式
+var imageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/bmp":  true,
+}
+
+// TranslateImage 拍照翻译。接收完整的图片后经过图片翻译引擎自己的并发隔离和熔断器调用引擎，
+// 引擎返回请求导致的错误码时在响应中返回；引擎不可用（包括访问频率受限、账户欠费等错误码）时返回UNAVAILABLE，
+// 图片翻译没有缓存可以降级
+func (y *YouDaoTranslationServer) TranslateImage(stream translation.Translation_TranslateImageServer) error {
+	ctx := stream.Context()
+	log := logger.FromContext(ctx, y.logger)
+	options, image, err := y.receiveImage(stream)
+	if err != nil {
+		return err
+	}
+	if !imageContentTypes[http.DetectContentType(image)] {
+		return errorsvar.ErrImageUnsupported
+	}
+	log.Info("拍照翻译",
+		zap.String("from", options.From),
+		zap.String("to", options.To),
+		zap.Int("size", len(image)))
+
+	done, err := y.guard(ctx, &y.imageGuard)
+	if err != nil {
+		return errorsvar.ErrTranslationUnavailable.WithCause(err)
+	}
+	start := time.Now()
+	result, err := y.ocr.Translate(ctx, image, options.From, options.To)
+	if y.metrics != nil {
+		y.metrics.ObserveTranslation(y.ocr.Name(), start, err)
+	}
+	var engineErr *ocr.EngineError
+	if errors.As(err, &engineErr) && callerErrorCode(engineErr.Code) {
+		// 请求本身导致的错误码说明引擎已正常处理请求，不计入熔断器的失败率
+		done(true)
+		log.Warn("图片翻译引擎返回错误码", zap.String("errorCode", engineErr.Code))
+		return stream.SendAndClose(&translation.TranslateImageResponse{
+			ErrorCode: engineErr.Code,
+			From:      options.From,
+			To:        options.To,
+		})
+	}
+	done(!engineFailure(ctx, err))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Error("调用图片翻译引擎失败", zap.Error(err))
+		return errorsvar.ErrTranslationUnavailable.WithCause(err)
+	}
+
+	response := &translation.TranslateImageResponse{
+		ErrorCode:   "0",
+		From:        result.From,
+		To:          result.To,
+		Orientation: result.Orientation,
+		Regions:     make([]*translation.ImageRegion, 0, len(result.Regions)),
+	}
+	for _, region := range result.Regions {
+		response.Regions = append(response.Regions, &translation.ImageRegion{
+			Text:        region.Text,
+			Translation: region.Translation,
+			X:           int32(region.X),
+			Y:           int32(region.Y),
+			Width:       int32(region.Width),
+			Height:      int32(region.Height),
+			LinesCount:  int32(region.Lines),
+		})
+	}
+	return stream.SendAndClose(response)
+}
+
+// receiveImage 接收翻译选项和图片分片，图片超过transfer_config.ocr.max_image_size时立即停止接收
+func (y *YouDaoTranslationServer) receiveImage(stream translation.Translation_TranslateImageServer) (*translation.TranslateImageOptions, []byte, error) {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, imageRequestError("options", "缺少翻译选项")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	options := first.GetOptions()
+	if options == nil {
+		return nil, nil, imageRequestError("options", "第一条消息必须是翻译选项")
+	}
+
+	var image bytes.Buffer
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		chunk := request.GetChunk()
+		if chunk == nil {
+			return nil, nil, imageRequestError("options", "翻译选项只能发送一次")
+		}
+		if image.Len()+len(chunk) > y.maxImageSize {
+			return nil, nil, errorsvar.ErrImageTooLarge
+		}
+		image.Write(chunk)
+	}
+	if image.Len() == 0 {
+		return nil, nil, imageRequestError("chunk", "缺少图片内容")
+	}
+	return options, image.Bytes(), nil
+}
+
+// imageRequestError 拍照翻译的消息顺序错误
+func imageRequestError(field, description string) error {
+	return errorsvar.InvalidArgument(errorsvar.ReasonInvalidArgument, "请求参数错误", errorsvar.FieldViolation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+// SaveImageWords 为拍照翻译中选中的单词创建词典记录并按需加入收藏。重复的单词只处理一次；
+// 词典中已有的单词直接使用已有记录，其余单词与批量翻译相同地并发调用翻译引擎，翻译失败的单词不影响其他单词
+func (y *YouDaoTranslationServer) SaveImageWords(ctx context.Context, req *translation.SaveImageWordsRequest) (*translation.SaveImageWordsResponse, error) {
+	log := logger.FromContext(ctx, y.logger)
+	log.Info("保存图片中的单词",
+		zap.String("userID", req.UserId),
+		zap.Int("count", len(req.Words)),
+		zap.Bool("addFavorites", req.AddFavorites))
+
+	items := make(map[string]*batchItem, len(req.Words))
+	unique := make([]*batchItem, 0, len(req.Words))
+	for _, word := range req.Words {
+		if _, ok := items[word]; ok {
+			continue
+		}
+		item := &batchItem{text: word, from: req.From, result: &translation.BatchTranslateItem{Q: word}}
+		items[word] = item
+		unique = append(unique, item)
+	}
+
+	pending := y.answerFromCache(ctx, unique, req.To)
+	if err := y.translateBatch(ctx, pending, req.To); err != nil {
+		return nil, err
+	}
+	for _, item := range pending {
+		if item.result.ErrorCode != "" || len(item.result.Translation) == 0 {
+			continue
+		}
+		if err := y.saveDictionary(ctx, item, req.From, req.To); err != nil {
+			log.Error("创建词典记录失败", zap.String("word", item.text), zap.Error(err))
+			return nil, errorsvar.Wrap(err, "创建词典记录失败")
+		}
+	}
+
+	favoriteIDs := make(map[uint64]string)
+	alreadyFavorited := make(map[uint64]bool)
+	if req.AddFavorites {
+		var favorites []*model.Favorite
+		for _, item := range unique {
+			if item.dictionaryID == 0 {
+				continue
+			}
+			favorites = append(favorites, &model.Favorite{
+				ID:           uuid.New().String(),
+				UserID:       req.UserId,
+				DictionaryID: item.dictionaryID,
+				DueAt:        time.Now(),
+				Model: model.Model{
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				},
+			})
+		}
+		created, skipped, err := y.favoriteRepo.BatchAddFavorites(ctx, favorites, req.Tags)
+		if err != nil {
+			log.Error("批量添加收藏失败", zap.String("userID", req.UserId), zap.Error(err))
+			return nil, errorsvar.Wrap(err, "批量添加收藏失败")
+		}
+		for _, favorite := range created {
+			favoriteIDs[favorite.DictionaryID] = favorite.ID
+		}
+		for _, dictionaryID := range skipped {
+			alreadyFavorited[dictionaryID] = true
+		}
+	}
+
+	response := &translation.SaveImageWordsResponse{Words: make([]*translation.SavedImageWord, 0, len(req.Words))}
+	for _, word := range req.Words {
+		item := items[word]
+		saved := &translation.SavedImageWord{
+			Word:             word,
+			DictionaryId:     item.dictionaryID,
+			ErrorCode:        item.result.ErrorCode,
+			FavoriteId:       favoriteIDs[item.dictionaryID],
+			AlreadyFavorited: alreadyFavorited[item.dictionaryID],
+		}
+		if len(item.result.Translation) > 0 {
+			saved.Translation = item.result.Translation[0]
+		}
+		response.Words = append(response.Words, saved)
+	}
+
+	log.Info("保存图片中的单词成功",
+		zap.Int("words", len(unique)),
+		zap.Int("favorites", len(favoriteIDs)))
+	return response, nil
+}
+
+// saveDictionary 为翻译引擎翻译的单词创建词典记录，并发请求已经创建时使用已有记录
+func (y *YouDaoTranslationServer) saveDictionary(ctx context.Context, item *batchItem, from, to string) error {
+	dictionary := &model.Dictionary{
+		SourceLang:     from,
+		TargetLang:     to,
+		SourceText:     item.text,
+		TranslatedText: item.result.Translation[0],
+		Model: model.Model{
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	err := y.dicRepo.CreateDictionary(ctx, dictionary)
+	if errors.Is(err, errorsvar.ErrDictionaryExists) {
+		existing, getErr := y.dicRepo.GetDictionaryByUniqueTranslation(ctx, from, to, item.text)
+		if getErr != nil {
+			return getErr
+		}
+		item.dictionaryID = existing.ID
+		item.result.Translation = []string{existing.TranslatedText}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	item.dictionaryID = dictionary.ID
+	return nil
+}
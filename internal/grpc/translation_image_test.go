@@ -0,0 +1,328 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/ocr"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
+	"github.com/cheel98/flashcard-backend/pkg/resilience"
+	"github.com/cheel98/flashcard-backend/proto/generated/translation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// pngHeader 能被http.DetectContentType识别为image/png的最小内容
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// imageStream 按顺序返回预先设置的消息的拍照翻译请求流
+type imageStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*translation.TranslateImageRequest
+	response *translation.TranslateImageResponse
+}
+
+func newImageStream(options *translation.TranslateImageOptions, chunks ...[]byte) *imageStream {
+	stream := &imageStream{ctx: context.Background()}
+	stream.requests = append(stream.requests, &translation.TranslateImageRequest{
+		Payload: &translation.TranslateImageRequest_Options{Options: options},
+	})
+	for _, chunk := range chunks {
+		stream.requests = append(stream.requests, &translation.TranslateImageRequest{
+			Payload: &translation.TranslateImageRequest_Chunk{Chunk: chunk},
+		})
+	}
+	return stream
+}
+
+func (s *imageStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *imageStream) Recv() (*translation.TranslateImageRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	request := s.requests[0]
+	s.requests = s.requests[1:]
+	return request, nil
+}
+
+func (s *imageStream) SendAndClose(response *translation.TranslateImageResponse) error {
+	s.response = response
+	return nil
+}
+
+// fakeDictionaryRepository 内存中的词典仓储，只实现批量翻译和拍照翻译用到的方法
+type fakeDictionaryRepository struct {
+	repository.DictionaryRepository
+
+	mu           sync.Mutex
+	dictionaries []*model.Dictionary
+	created      []string
+	queries      []string // 每次批量查询的源语言
+	listErr      error
+}
+
+func (r *fakeDictionaryRepository) ListDictionariesBySourceTexts(ctx context.Context, sourceLang, targetLang string, sourceTexts []string) ([]model.Dictionary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, sourceLang)
+	if r.listErr != nil {
+		return nil, r.listErr
+	}
+	var result []model.Dictionary
+	for _, dictionary := range r.dictionaries {
+		for _, text := range sourceTexts {
+			if dictionary.SourceLang == sourceLang && dictionary.TargetLang == targetLang && dictionary.SourceText == text {
+				result = append(result, *dictionary)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeDictionaryRepository) CreateDictionary(ctx context.Context, dictionary *model.Dictionary) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.dictionaries {
+		if existing.SourceLang == dictionary.SourceLang && existing.TargetLang == dictionary.TargetLang && existing.SourceText == dictionary.SourceText {
+			return errorsvar.ErrDictionaryExists
+		}
+	}
+	dictionary.ID = uint64(len(r.dictionaries) + 1)
+	r.dictionaries = append(r.dictionaries, dictionary)
+	r.created = append(r.created, dictionary.SourceText)
+	return nil
+}
+
+// fakeFavoriteRepository 记录批量收藏请求，alreadyFavorited中的词典按已收藏跳过
+type fakeFavoriteRepository struct {
+	repository.FavoriteRepository
+
+	alreadyFavorited map[uint64]bool
+	tags             []string
+}
+
+func (r *fakeFavoriteRepository) BatchAddFavorites(ctx context.Context, favorites []*model.Favorite, tags []string) ([]*model.Favorite, []uint64, error) {
+	r.tags = tags
+	var created []*model.Favorite
+	var skipped []uint64
+	for _, favorite := range favorites {
+		if r.alreadyFavorited[favorite.DictionaryID] {
+			skipped = append(skipped, favorite.DictionaryID)
+			continue
+		}
+		created = append(created, favorite)
+	}
+	return created, skipped, nil
+}
+
+// engineTransport 模拟翻译引擎，按原文返回响应
+type engineTransport map[string]string
+
+func (t engineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	body, ok := t[req.PostForm.Get("q")]
+	if !ok {
+		body = `{"errorCode":"113"}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func newImageTestServer(t *testing.T, engine ocr.Engine, dicRepo repository.DictionaryRepository, favoriteRepo repository.FavoriteRepository, transport http.RoundTripper) *YouDaoTranslationServer {
+	t.Helper()
+	workerPool := NewWorkerPool(2, 8, zap.NewNop())
+	workerPool.Start()
+	t.Cleanup(workerPool.Stop)
+
+	server := newTranslationServer(dicRepo, "https://openapi.youdao.com/api", "key", "secret")
+	server.client = httpclient.New(httpclient.Options{Transport: transport}, zap.NewNop())
+	server.workerPool = workerPool
+	server.batchConcurrency = 2
+	server.ocr = engine
+	server.imageGuard.engine = engine.Name()
+	server.favoriteRepo = favoriteRepo
+	server.maxImageSize = 1024
+	return server
+}
+
+func TestTranslateImage(t *testing.T) {
+	engine := ocr.NewFake()
+	server := newImageTestServer(t, engine, nil, nil, nil)
+
+	image := append(append([]byte{}, pngHeader...), make([]byte, 100)...)
+	stream := newImageStream(&translation.TranslateImageOptions{From: "auto", To: "zh-CHS"}, image[:50], image[50:])
+	if err := server.TranslateImage(stream); err != nil {
+		t.Fatalf("TranslateImage: %v", err)
+	}
+
+	response := stream.response
+	if response.ErrorCode != "0" || response.From != "en" || response.To != "zh-CHS" {
+		t.Fatalf("unexpected response: %v", response)
+	}
+	if len(response.Regions) != len(ocr.DefaultFakeRegions) {
+		t.Fatalf("got %d regions, want %d", len(response.Regions), len(ocr.DefaultFakeRegions))
+	}
+	if region := response.Regions[0]; region.Text != "hello" || region.Translation != "[zh-CHS] hello" || region.Width != 120 {
+		t.Errorf("unexpected region: %v", region)
+	}
+	if engine.Calls() != 1 {
+		t.Errorf("engine called %d times, want 1", engine.Calls())
+	}
+}
+
+func TestTranslateImageRejectsInvalidImages(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   error
+	}{
+		{"too large", [][]byte{pngHeader, make([]byte, 1024)}, errorsvar.ErrImageTooLarge},
+		{"unsupported", [][]byte{[]byte("plain text")}, errorsvar.ErrImageUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := ocr.NewFake()
+			server := newImageTestServer(t, engine, nil, nil, nil)
+
+			stream := newImageStream(&translation.TranslateImageOptions{From: "en", To: "zh-CHS"}, tt.chunks...)
+			if err := server.TranslateImage(stream); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if engine.Calls() != 0 {
+				t.Errorf("engine called %d times, want 0", engine.Calls())
+			}
+		})
+	}
+}
+
+func TestTranslateImageEngineErrors(t *testing.T) {
+	t.Run("caller error code", func(t *testing.T) {
+		engine := &ocr.Fake{Err: &ocr.EngineError{Code: "1004"}}
+		server := newImageTestServer(t, engine, nil, nil, nil)
+
+		stream := newImageStream(&translation.TranslateImageOptions{From: "en", To: "zh-CHS"}, pngHeader)
+		if err := server.TranslateImage(stream); err != nil {
+			t.Fatalf("TranslateImage: %v", err)
+		}
+		if stream.response.ErrorCode != "1004" {
+			t.Errorf("got error code %q, want 1004", stream.response.ErrorCode)
+		}
+	})
+
+	t.Run("engine unavailable", func(t *testing.T) {
+		engine := &ocr.Fake{Err: &ocr.EngineError{Code: "411"}}
+		server := newImageTestServer(t, engine, nil, nil, nil)
+
+		stream := newImageStream(&translation.TranslateImageOptions{From: "en", To: "zh-CHS"}, pngHeader)
+		if err := server.TranslateImage(stream); !errors.Is(err, errorsvar.ErrTranslationUnavailable) {
+			t.Fatalf("got %v, want %v", err, errorsvar.ErrTranslationUnavailable)
+		}
+	})
+}
+
+func TestTranslateImageFailuresKeepTranslationBreakerClosed(t *testing.T) {
+	newBreaker := func(name string) *resilience.Breaker {
+		return resilience.NewBreaker(name, resilience.BreakerOptions{
+			Window:           time.Minute,
+			MinRequests:      2,
+			FailureRate:      0.5,
+			OpenTimeout:      time.Minute,
+			HalfOpenRequests: 1,
+		})
+	}
+	engine := &ocr.Fake{Err: &ocr.EngineError{Code: "411"}}
+	transport := engineTransport{"card": `{"errorCode":"0","translation":["卡片"]}`}
+	server := newImageTestServer(t, engine, nil, nil, transport)
+	server.textGuard.breaker = newBreaker(translationEngine)
+	server.textGuard.bulkhead = resilience.NewBulkhead(1, 0)
+	server.imageGuard.breaker = newBreaker(engine.Name())
+	server.imageGuard.bulkhead = resilience.NewBulkhead(1, 0)
+
+	for i := 0; i < 3; i++ {
+		stream := newImageStream(&translation.TranslateImageOptions{From: "en", To: "zh-CHS"}, pngHeader)
+		if err := server.TranslateImage(stream); !errors.Is(err, errorsvar.ErrTranslationUnavailable) {
+			t.Fatalf("TranslateImage #%d: got %v, want %v", i, err, errorsvar.ErrTranslationUnavailable)
+		}
+	}
+	if state := server.imageGuard.breaker.State(); state != resilience.StateOpen {
+		t.Fatalf("OCR breaker state = %s, want %s", state, resilience.StateOpen)
+	}
+	if engine.Calls() != 2 {
+		t.Errorf("engine called %d times, want 2", engine.Calls())
+	}
+
+	// 文本翻译使用自己的熔断器和并发隔离，不受图片翻译引擎故障影响
+	if state := server.textGuard.breaker.State(); state != resilience.StateClosed {
+		t.Fatalf("translation breaker state = %s, want %s", state, resilience.StateClosed)
+	}
+	response, err := server.Translation(context.Background(), &translation.TranslationRequest{Q: "card", From: "en", To: "zh-CHS"})
+	if err != nil {
+		t.Fatalf("Translation: %v", err)
+	}
+	if len(response.Translation) != 1 || response.Translation[0] != "卡片" {
+		t.Errorf("got translation %v, want [卡片]", response.Translation)
+	}
+}
+
+func TestSaveImageWords(t *testing.T) {
+	dicRepo := &fakeDictionaryRepository{dictionaries: []*model.Dictionary{
+		{ID: 1, SourceLang: "en", TargetLang: "zh-CHS", SourceText: "hello", TranslatedText: "你好"},
+	}}
+	favoriteRepo := &fakeFavoriteRepository{alreadyFavorited: map[uint64]bool{1: true}}
+	transport := engineTransport{"card": `{"errorCode":"0","translation":["卡片"]}`}
+	server := newImageTestServer(t, ocr.NewFake(), dicRepo, favoriteRepo, transport)
+
+	response, err := server.SaveImageWords(context.Background(), &translation.SaveImageWordsRequest{
+		UserId:       "user-1",
+		From:         "en",
+		To:           "zh-CHS",
+		Words:        []string{"hello", "card", "unknown", "card"},
+		AddFavorites: true,
+		Tags:         []string{"photo"},
+	})
+	if err != nil {
+		t.Fatalf("SaveImageWords: %v", err)
+	}
+
+	if len(response.Words) != 4 {
+		t.Fatalf("got %d words, want 4", len(response.Words))
+	}
+	hello, card, unknown := response.Words[0], response.Words[1], response.Words[2]
+	if hello.DictionaryId != 1 || hello.Translation != "你好" || !hello.AlreadyFavorited || hello.FavoriteId != "" {
+		t.Errorf("unexpected result for cached word: %v", hello)
+	}
+	if card.DictionaryId == 0 || card.Translation != "卡片" || card.FavoriteId == "" || card.AlreadyFavorited {
+		t.Errorf("unexpected result for translated word: %v", card)
+	}
+	if unknown.DictionaryId != 0 || unknown.ErrorCode != "113" || unknown.FavoriteId != "" {
+		t.Errorf("unexpected result for failed word: %v", unknown)
+	}
+	if repeated := response.Words[3]; repeated.DictionaryId != card.DictionaryId || repeated.FavoriteId != card.FavoriteId {
+		t.Errorf("repeated word got %v, want %v", repeated, card)
+	}
+	if len(dicRepo.created) != 1 || dicRepo.created[0] != "card" {
+		t.Errorf("created dictionaries %v, want [card]", dicRepo.created)
+	}
+	if len(favoriteRepo.tags) != 1 || favoriteRepo.tags[0] != "photo" {
+		t.Errorf("got tags %v, want [photo]", favoriteRepo.tags)
+	}
+}
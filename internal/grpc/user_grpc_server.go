@@ -3,15 +3,15 @@ package grpc
 import (
 	"context"
 	"github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
 	"github.com/cheel98/flashcard-backend/internal/model"
 	"github.com/cheel98/flashcard-backend/internal/repository"
 	"github.com/cheel98/flashcard-backend/pkg/email"
 	"github.com/cheel98/flashcard-backend/pkg/jwt"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
 	"github.com/cheel98/flashcard-backend/pkg/redis"
 	"github.com/cheel98/flashcard-backend/proto/generated/user"
 	"go.uber.org/zap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -36,7 +36,7 @@ func NewUserGRPCServer(userRepo repository.UserRepository, jwtManager *jwt.JWTMa
 	}
 }
 func (s *UserGRPCServer) Register(ctx context.Context, req *user.RegisterRequest) (*user.RegisterResponse, error) {
-	s.logger.Info("Register", zap.String("email", req.Email), zap.String("name", req.Name))
+	logger.FromContext(ctx, s.logger).Info("Register", zap.String("email", req.Email), zap.String("name", req.Name))
 	_, err := s.VerifyCaptcha(ctx, &user.CaptchaRequest{
 		Email:   req.Email,
 		Captcha: req.Captcha,
@@ -45,23 +45,23 @@ func (s *UserGRPCServer) Register(ctx context.Context, req *user.RegisterRequest
 		return nil, err
 	}
 	// 创建用户
-	user_, err := s.userRepo.Create(&model.User{
+	user_, err := s.userRepo.Create(ctx, &model.User{
 		Name:         req.Name,
 		Email:        req.Email,
 		PasswordHash: req.PasswordHash,
 	})
 	if err != nil {
-		s.logger.Error("用户注册失败", zap.String("email", req.Email), zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "用户注册失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("用户注册失败", zap.String("email", req.Email), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "用户注册失败")
 	}
 
 	// 注册成功后删除Redis中的验证码
 	err = s.redisClient.DeleteCaptcha(ctx, req.Email)
 	if err != nil {
-		s.logger.Warn("删除验证码失败", zap.String("email", req.Email), zap.Error(err))
+		logger.FromContext(ctx, s.logger).Warn("删除验证码失败", zap.String("email", req.Email), zap.Error(err))
 	}
 
-	s.logger.Info("用户注册成功", zap.String("email", req.Email), zap.String("userID", user_.ID))
+	logger.FromContext(ctx, s.logger).Info("用户注册成功", zap.String("email", req.Email), zap.String("userID", user_.ID))
 	return &user.RegisterResponse{
 		UserId: user_.ID,
 	}, nil
@@ -73,12 +73,12 @@ var SuccessBool = &user.BoolResponse{Success: true}
 func (s *UserGRPCServer) VerifyCaptcha(ctx context.Context, request *user.CaptchaRequest) (*user.BoolResponse, error) {
 	captcha, err := s.redisClient.GetCaptcha(ctx, request.Email)
 	if err != nil {
-		return FailedBool, errorsvar.CaptchaErrors
+		return FailedBool, errorsvar.ErrCaptchaInvalid
 	}
 	if captcha == request.GetCaptcha() {
 		return SuccessBool, nil
 	}
-	return FailedBool, errorsvar.CaptchaErrors
+	return FailedBool, errorsvar.ErrCaptchaInvalid
 }
 
 func (s *UserGRPCServer) SendEmailCaptcha(ctx context.Context, request *user.SendCaptchaRequest) (*user.BoolResponse, error) {
@@ -93,7 +93,7 @@ func (s *UserGRPCServer) SendEmailCaptcha(ctx context.Context, request *user.Sen
 		return FailedBool, err
 	}
 	// 3.将验证码发送到用户邮箱
-	err = s.emailService.SendCaptcha(request.Email, captcha)
+	err = s.emailService.SendCaptcha(request.Email, captcha, i18n.FromContext(ctx))
 	if err != nil {
 		return FailedBool, err
 	}
@@ -102,31 +102,31 @@ func (s *UserGRPCServer) SendEmailCaptcha(ctx context.Context, request *user.Sen
 
 // Login 用户登录
 func (s *UserGRPCServer) Login(ctx context.Context, req *user.LoginRequest) (*user.LoginResponse, error) {
-	s.logger.Info("gRPC Login called",
+	logger.FromContext(ctx, s.logger).Info("gRPC Login called",
 		zap.String("email", req.Email))
 
 	// 直接调用repository层进行用户验证
-	user_, err := s.userRepo.Login(req.Email, req.PasswordHash)
+	user_, err := s.userRepo.Login(ctx, req.Email, req.PasswordHash)
 	if err != nil {
-		s.logger.Error("用户登录失败", zap.String("email", req.Email), zap.Error(err))
-		return nil, status.Errorf(codes.Unauthenticated, "登录失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("用户登录失败", zap.String("email", req.Email), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "登录失败")
 	}
 
 	// 生成token对
 	tokenPair, err := s.jwtManager.GenerateTokenPair(user_.ID, user_.Email)
 	if err != nil {
-		s.logger.Error("生成token失败", zap.String("userID", user_.ID), zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "生成token失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("生成token失败", zap.String("userID", user_.ID), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "生成token失败")
 	}
 
 	// 保存refresh token到数据库
-	err = s.userRepo.SaveRefreshToken(user_.ID, tokenPair.RefreshToken)
+	err = s.userRepo.SaveRefreshToken(ctx, user_.ID, tokenPair.RefreshToken)
 	if err != nil {
-		s.logger.Error("保存refresh token失败", zap.String("userID", user_.ID), zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "保存refresh token失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("保存refresh token失败", zap.String("userID", user_.ID), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "保存refresh token失败")
 	}
 
-	s.logger.Info("用户登录成功", zap.String("email", req.Email), zap.String("userID", user_.ID))
+	logger.FromContext(ctx, s.logger).Info("用户登录成功", zap.String("email", req.Email), zap.String("userID", user_.ID))
 	return &user.LoginResponse{
 		AccessToken:  tokenPair.AccessToken,
 		RefreshToken: tokenPair.RefreshToken,
@@ -135,25 +135,25 @@ func (s *UserGRPCServer) Login(ctx context.Context, req *user.LoginRequest) (*us
 
 // RefreshToken 刷新访问令牌
 func (s *UserGRPCServer) RefreshToken(ctx context.Context, req *user.RefreshTokenRequest) (*user.RefreshTokenResponse, error) {
-	s.logger.Debug("刷新访问令牌")
+	logger.FromContext(ctx, s.logger).Debug("刷新访问令牌")
 	// 验证refresh token
-	user_, err := s.userRepo.GetUserByRefreshToken(req.UserId, req.RefreshToken)
+	user_, err := s.userRepo.GetUserByRefreshToken(ctx, req.UserId, req.RefreshToken)
 	if err != nil {
-		s.logger.Error("无效的refresh token", zap.Error(err))
+		logger.FromContext(ctx, s.logger).Error("无效的refresh token", zap.Error(err))
 		return nil, err
 	}
 
 	// 生成新的access token
 	tokenPair, err := s.jwtManager.RefreshAccessToken(req.RefreshToken)
 	if err != nil {
-		s.logger.Error("刷新access token失败", zap.String("userID", user_.ID), zap.Error(err))
+		logger.FromContext(ctx, s.logger).Error("刷新access token失败", zap.String("userID", user_.ID), zap.Error(err))
 		return nil, err
 	}
-	err = s.userRepo.SaveRefreshToken(user_.ID, tokenPair.RefreshToken)
+	err = s.userRepo.SaveRefreshToken(ctx, user_.ID, tokenPair.RefreshToken)
 	if err != nil {
-		s.logger.Error("刷新access token失败", zap.String("userID", user_.ID), zap.Error(err))
+		logger.FromContext(ctx, s.logger).Error("刷新access token失败", zap.String("userID", user_.ID), zap.Error(err))
 	}
-	s.logger.Info("access token刷新成功", zap.String("userID", user_.ID))
+	logger.FromContext(ctx, s.logger).Info("access token刷新成功", zap.String("userID", user_.ID))
 	return &user.RefreshTokenResponse{
 		AccessToken:  tokenPair.AccessToken,
 		RefreshToken: tokenPair.RefreshToken,
@@ -162,29 +162,43 @@ func (s *UserGRPCServer) RefreshToken(ctx context.Context, req *user.RefreshToke
 
 // Logout 用户登出
 func (s *UserGRPCServer) Logout(ctx context.Context, req *user.LogoutRequest) (*user.LogoutResponse, error) {
-	s.logger.Info("gRPC Logout called",
+	logger.FromContext(ctx, s.logger).Info("gRPC Logout called",
 		zap.String("user_id", req.UserId))
 	// 清除refresh token
-	err := s.userRepo.ClearRefreshToken(req.UserId)
+	err := s.userRepo.ClearRefreshToken(ctx, req.UserId)
 	if err != nil {
-		s.logger.Error("清除refresh token失败", zap.String("userID", req.UserId), zap.Error(err))
+		logger.FromContext(ctx, s.logger).Error("清除refresh token失败", zap.String("userID", req.UserId), zap.Error(err))
 		return nil, err
 	}
 
-	s.logger.Info("用户登出成功", zap.String("userID", req.UserId))
+	logger.FromContext(ctx, s.logger).Info("用户登出成功", zap.String("userID", req.UserId))
 	return &user.LogoutResponse{
 		Success: true,
 	}, nil
 }
 
+// DeleteAccount 注销账户
+func (s *UserGRPCServer) DeleteAccount(ctx context.Context, req *user.DeleteAccountRequest) (*user.BoolResponse, error) {
+	logger.FromContext(ctx, s.logger).Info("注销账户", zap.String("userID", req.UserId))
+
+	err := s.userRepo.DeleteUser(ctx, req.UserId)
+	if err != nil {
+		logger.FromContext(ctx, s.logger).Error("注销账户失败", zap.String("userID", req.UserId), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "注销账户失败")
+	}
+
+	logger.FromContext(ctx, s.logger).Info("账户注销成功", zap.String("userID", req.UserId))
+	return SuccessBool, nil
+}
+
 // GetUserByEmail 获取用户信息
 func (s *UserGRPCServer) GetUserByEmail(ctx context.Context, req *user.GetUserByEmailRequest) (*user.GetUserByEmailResponse, error) {
-	s.logger.Debug("获取用户信息", zap.String("email", req.Email))
+	logger.FromContext(ctx, s.logger).Debug("获取用户信息", zap.String("email", req.Email))
 
-	userModel, err := s.userRepo.GetUserByEmail(req.Email)
+	userModel, err := s.userRepo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		s.logger.Error("获取用户信息失败", zap.String("email", req.Email), zap.Error(err))
-		return nil, status.Errorf(codes.NotFound, "获取用户信息失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("获取用户信息失败", zap.String("email", req.Email), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "获取用户信息失败")
 	}
 
 	return &user.GetUserByEmailResponse{
@@ -194,12 +208,12 @@ func (s *UserGRPCServer) GetUserByEmail(ctx context.Context, req *user.GetUserBy
 
 // GetUserSettings 获取用户设置
 func (s *UserGRPCServer) GetUserSettings(ctx context.Context, req *user.GetUserSettingsRequest) (*user.GetUserSettingsResponse, error) {
-	s.logger.Debug("获取用户设置", zap.String("userID", req.UserId))
+	logger.FromContext(ctx, s.logger).Debug("获取用户设置", zap.String("userID", req.UserId))
 
-	userSettings, err := s.userRepo.GetUserSettings(req.UserId)
+	userSettings, err := s.userRepo.GetUserSettings(ctx, req.UserId)
 	if err != nil {
-		s.logger.Error("获取用户设置失败", zap.String("userID", req.UserId), zap.Error(err))
-		return nil, status.Errorf(codes.NotFound, "获取用户设置失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("获取用户设置失败", zap.String("userID", req.UserId), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "获取用户设置失败")
 	}
 
 	return &user.GetUserSettingsResponse{
@@ -209,12 +223,12 @@ func (s *UserGRPCServer) GetUserSettings(ctx context.Context, req *user.GetUserS
 
 // GetUserPreferences 获取用户偏好
 func (s *UserGRPCServer) GetUserPreferences(ctx context.Context, req *user.GetUserPreferencesRequest) (*user.GetUserPreferencesResponse, error) {
-	s.logger.Debug("获取用户偏好设置", zap.String("userID", req.UserId))
+	logger.FromContext(ctx, s.logger).Debug("获取用户偏好设置", zap.String("userID", req.UserId))
 
-	userPreferences, err := s.userRepo.GetUserPreferences(req.UserId)
+	userPreferences, err := s.userRepo.GetUserPreferences(ctx, req.UserId)
 	if err != nil {
-		s.logger.Error("获取用户偏好设置失败", zap.String("userID", req.UserId), zap.Error(err))
-		return nil, status.Errorf(codes.NotFound, "获取用户偏好失败: %v", err)
+		logger.FromContext(ctx, s.logger).Error("获取用户偏好设置失败", zap.String("userID", req.UserId), zap.Error(err))
+		return nil, errorsvar.Wrap(err, "获取用户偏好失败")
 	}
 
 	return &user.GetUserPreferencesResponse{
@@ -224,17 +238,17 @@ func (s *UserGRPCServer) GetUserPreferences(ctx context.Context, req *user.GetUs
 
 // GetUserLogs 获取用户日志
 func (s *UserGRPCServer) GetUserLogs(ctx context.Context, req *user.GetUserLogsRequest) (*user.GetUserLogsResponse, error) {
-	s.logger.Debug("获取用户操作日志",
+	logger.FromContext(ctx, s.logger).Debug("获取用户操作日志",
 		zap.String("userID", req.UserId),
 		zap.Int32("limit", req.Limit),
 		zap.Int32("offset", req.Offset))
 
-	userLogs, err := s.userRepo.GetUserLogs(req.UserId, int(req.Limit), int(req.Offset))
+	userLogs, err := s.userRepo.GetUserLogs(ctx, req.UserId, int(req.Limit), int(req.Offset))
 	if err != nil {
-		s.logger.Error("获取用户操作日志失败",
+		logger.FromContext(ctx, s.logger).Error("获取用户操作日志失败",
 			zap.String("userID", req.UserId),
 			zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "获取用户日志失败: %v", err)
+		return nil, errorsvar.Wrap(err, "获取用户日志失败")
 	}
 
 	// 转换为proto格式
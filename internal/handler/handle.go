@@ -13,6 +13,7 @@ import (
 	"github.com/cheel98/flashcard-backend/proto/generated/user"
 	"go.uber.org/zap"
 	grpcServer "google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // Handler gRPC处理器
@@ -73,9 +74,11 @@ func (h *Handler) RegisterServices(server *grpcServer.Server) {
 	favorite.RegisterFavoriteServiceServer(server, h.favoriteServer)
 	health.RegisterHealthServiceServer(server, h.healthServer)
 	translation.RegisterTranslationServer(server, h.youdaoTranslation)
+	// 标准健康检查服务，供负载均衡器和Kubernetes探针使用
+	healthpb.RegisterHealthServer(server, h.healthServer.StandardServer())
 
 	h.logger.Info("gRPC services registered successfully",
-		zap.String("services", "UserService, DictionaryService, FavoriteService, HealthService"))
+		zap.String("services", "UserService, DictionaryService, FavoriteService, HealthService, Translation, grpc.health.v1.Health"))
 }
 
 // GetHealthServer 获取健康检查服务实例
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
+	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"github.com/cheel98/flashcard-backend/pkg/resilience"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// 依赖组件名称
+const (
+	ComponentPostgres    = "postgres"
+	ComponentRedis       = "redis"
+	ComponentSMTP        = "smtp"
+	ComponentTranslation = "translation"
+	// ComponentTranslationBreaker 翻译引擎熔断器，断开期间翻译接口降级查询词典缓存
+	ComponentTranslationBreaker = "translation_breaker"
+)
+
+// Checker 依赖组件健康检查器
+type Checker interface {
+	// Name 组件名称
+	Name() string
+	// Check 检查组件是否可用，不可用时返回错误
+	Check(ctx context.Context) error
+}
+
+// Result 单个组件的检查结果
+type Result struct {
+	Component string
+	Err       error
+	Latency   time.Duration
+	CheckedAt time.Time
+}
+
+// Healthy 组件是否可用
+func (r Result) Healthy() bool {
+	return r.Err == nil
+}
+
+// postgresChecker 检查数据库连接
+type postgresChecker struct {
+	db *gorm.DB
+}
+
+// NewPostgresChecker 创建数据库检查器
+func NewPostgresChecker(db *gorm.DB) Checker {
+	return &postgresChecker{db: db}
+}
+
+func (c *postgresChecker) Name() string {
+	return ComponentPostgres
+}
+
+func (c *postgresChecker) Check(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// redisChecker 检查Redis连接
+type redisChecker struct {
+	client *redis.RedisClient
+}
+
+// NewRedisChecker 创建Redis检查器
+func NewRedisChecker(client *redis.RedisClient) Checker {
+	return &redisChecker{client: client}
+}
+
+func (c *redisChecker) Name() string {
+	return ComponentRedis
+}
+
+func (c *redisChecker) Check(ctx context.Context) error {
+	return c.client.Ping(ctx)
+}
+
+// smtpChecker 检查SMTP服务器是否可连接，只读取欢迎信息，不进行认证
+type smtpChecker struct {
+	addr string
+}
+
+// NewSMTPChecker 创建SMTP检查器
+func NewSMTPChecker(cfg *config.Config) Checker {
+	return &smtpChecker{addr: net.JoinHostPort(cfg.Email.SMTPHost, fmt.Sprint(cfg.Email.SMTPPort))}
+}
+
+func (c *smtpChecker) Name() string {
+	return ComponentSMTP
+}
+
+func (c *smtpChecker) Check(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	greeting, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("读取SMTP欢迎信息失败: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "220") {
+		return fmt.Errorf("SMTP服务器不可用: %s", strings.TrimSpace(greeting))
+	}
+	_, _ = conn.Write([]byte("QUIT\r\n"))
+	return nil
+}
+
+// translationChecker 检查翻译引擎是否可访问，收到非5xx响应即认为可用
+type translationChecker struct {
+	url    string
+	client *httpclient.Client
+}
+
+// NewTranslationChecker 创建翻译引擎检查器，与翻译请求共用HTTP客户端的超时和响应大小限制
+func NewTranslationChecker(cfg *config.Config, client *httpclient.Client) Checker {
+	return &translationChecker{
+		url:    cfg.TransferConfig.URL,
+		client: client,
+	}
+}
+
+func (c *translationChecker) Name() string {
+	return ComponentTranslation
+}
+
+func (c *translationChecker) Check(ctx context.Context) error {
+	_, err := c.client.Get(ctx, c.url, nil, nil)
+	var statusErr *httpclient.StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("翻译引擎返回状态码%d", statusErr.StatusCode)
+		}
+		return nil
+	}
+	return err
+}
+
+// breakerChecker 将熔断器状态作为组件状态上报，闭合以外的状态视为不可用
+type breakerChecker struct {
+	name    string
+	breaker *resilience.Breaker
+}
+
+// TranslationBreakerParams 翻译引擎熔断器检查器依赖，未开启熔断器时Breaker为nil
+type TranslationBreakerParams struct {
+	fx.In
+
+	Breaker *resilience.Breaker `name:"translation" optional:"true"`
+}
+
+// NewTranslationBreakerChecker 创建翻译引擎熔断器检查器
+func NewTranslationBreakerChecker(p TranslationBreakerParams) Checker {
+	return &breakerChecker{name: ComponentTranslationBreaker, breaker: p.Breaker}
+}
+
+func (c *breakerChecker) Name() string {
+	return c.name
+}
+
+func (c *breakerChecker) Check(ctx context.Context) error {
+	if c.breaker == nil {
+		return nil
+	}
+	if state := c.breaker.State(); state != resilience.StateClosed {
+		return fmt.Errorf("熔断器状态为%s", state)
+	}
+	return nil
+}
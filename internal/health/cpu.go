@@ -0,0 +1,49 @@
+package health
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// CPUSampler 通过两次采样之间的进程CPU时间计算CPU使用率
+type CPUSampler struct {
+	mu       sync.Mutex
+	lastWall time.Time
+	lastCPU  time.Duration
+	usage    float64
+}
+
+// NewCPUSampler 创建CPU使用率采样器
+func NewCPUSampler() *CPUSampler {
+	cpu, _ := processCPUTime()
+	return &CPUSampler{
+		lastWall: time.Now(),
+		lastCPU:  cpu,
+	}
+}
+
+// Usage 返回自上次采样以来的CPU使用率（0-1，按核数归一化），不支持的平台返回0
+func (s *CPUSampler) Usage() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cpu, ok := processCPUTime()
+	if !ok {
+		return 0
+	}
+	now := time.Now()
+	wall := now.Sub(s.lastWall)
+	// 采样间隔过短时沿用上一次的结果，避免数值抖动
+	if wall < 100*time.Millisecond {
+		return s.usage
+	}
+
+	s.usage = float64(cpu-s.lastCPU) / (float64(wall) * float64(runtime.NumCPU()))
+	if s.usage > 1 {
+		s.usage = 1
+	}
+	s.lastWall = now
+	s.lastCPU = cpu
+	return s.usage
+}
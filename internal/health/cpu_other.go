@@ -0,0 +1,10 @@
+//go:build !unix
+
+package health
+
+import "time"
+
+// processCPUTime 当前平台不支持获取进程CPU时间
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+)
+
+// asChecker 将检查器加入health_checkers分组
+func asChecker(constructor interface{}) interface{} {
+	return fx.Annotate(constructor, fx.ResultTags(`group:"health_checkers"`))
+}
+
+// Module 健康检查模块
+var Module = fx.Options(
+	fx.Provide(
+		asChecker(NewPostgresChecker),
+		asChecker(NewRedisChecker),
+		asChecker(NewSMTPChecker),
+		asChecker(NewTranslationChecker),
+		asChecker(NewTranslationBreakerChecker),
+		NewMonitor,
+	),
+	fx.Invoke(func(lc fx.Lifecycle, monitor *Monitor) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				monitor.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				monitor.Stop()
+				return nil
+			},
+		})
+	}),
+)
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Sink 接收每轮检查结果，由健康检查服务实现
+type Sink interface {
+	UpdateDependencies(results []Result)
+}
+
+// MonitorParams 监控器依赖
+type MonitorParams struct {
+	fx.In
+
+	Config   *config.Config
+	Checkers []Checker `group:"health_checkers"`
+	Sink     Sink
+	Logger   *zap.Logger
+}
+
+// Monitor 按固定间隔并发执行所有检查器，并把结果推送给Sink
+type Monitor struct {
+	checkers []Checker
+	sink     Sink
+	interval time.Duration
+	timeout  time.Duration
+	logger   *zap.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewMonitor 创建健康检查监控器
+func NewMonitor(p MonitorParams) *Monitor {
+	return &Monitor{
+		checkers: p.Checkers,
+		sink:     p.Sink,
+		interval: time.Duration(p.Config.Health.CheckInterval) * time.Second,
+		timeout:  time.Duration(p.Config.Health.CheckTimeout) * time.Second,
+		logger:   p.Logger,
+	}
+}
+
+// Start 立即执行一次检查，然后在后台定期检查
+func (m *Monitor) Start() {
+	m.RunOnce(context.Background())
+	if m.interval <= 0 {
+		m.logger.Info("Health monitor periodic checks disabled")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.wg.Add(1)
+	go m.loop(ctx)
+
+	m.logger.Info("Health monitor started",
+		zap.Int("checkers", len(m.checkers)),
+		zap.Duration("interval", m.interval))
+}
+
+// Stop 停止后台检查
+func (m *Monitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+	m.logger.Info("Health monitor stopped")
+}
+
+// RunOnce 并发执行所有检查器，返回检查结果
+func (m *Monitor) RunOnce(ctx context.Context) []Result {
+	results := make([]Result, len(m.checkers))
+	var wg sync.WaitGroup
+	for i, checker := range m.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = m.check(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	m.sink.UpdateDependencies(results)
+	return results
+}
+
+// check 在超时时间内执行单个检查器
+func (m *Monitor) check(ctx context.Context, checker Checker) Result {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := checker.Check(ctx)
+	result := Result{
+		Component: checker.Name(),
+		Err:       err,
+		Latency:   time.Since(start),
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		m.logger.Warn("Dependency health check failed",
+			zap.String("component", result.Component),
+			zap.Duration("latency", result.Latency),
+			zap.Error(err))
+	}
+	return result
+}
+
+// loop 按固定间隔执行检查
+func (m *Monitor) loop(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.RunOnce(ctx)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package i18n
+
+import (
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"golang.org/x/text/language"
+)
+
+// 邮件文本的键
+const (
+	KeyCaptchaEmailSubject = "email.captcha.subject"
+	KeyCaptchaEmailBody    = "email.captcha.body"
+)
+
+// catalog 各语言的文本，错误文本以错误原因为键。新增错误原因时需要同时补充所有语言
+var catalog = map[language.Tag]map[string]string{
+	ChineseSimplified: {
+		errorsvar.ReasonInternal:               "服务器内部错误",
+		errorsvar.ReasonInvalidArgument:        "请求参数错误",
+		errorsvar.ReasonCanceled:               "请求已取消",
+		errorsvar.ReasonDeadlineExceeded:       "请求超时",
+		errorsvar.ReasonTokenMissing:           "缺少认证信息",
+		errorsvar.ReasonTokenInvalid:           "无效的访问令牌",
+		errorsvar.ReasonIdempotencyKeyInvalid:  "幂等键格式错误",
+		errorsvar.ReasonIdempotencyKeyReused:   "幂等键已用于其他请求",
+		errorsvar.ReasonIdempotencyInProgress:  "相同幂等键的请求正在处理中，请稍后重试",
+		errorsvar.ReasonRateLimited:            "请求过于频繁，请稍后再试",
+		errorsvar.ReasonRequestBodyTooLarge:    "请求体过大",
+		errorsvar.ReasonShuttingDown:           "服务正在关闭，请重新连接",
+		errorsvar.ReasonUserNotFound:           "用户不存在",
+		errorsvar.ReasonUserExists:             "该邮箱已注册",
+		errorsvar.ReasonInvalidCredentials:     "用户名或密码错误",
+		errorsvar.ReasonAccountDisabled:        "账户已被禁用",
+		errorsvar.ReasonPermissionDenied:       "没有权限执行该操作",
+		errorsvar.ReasonUserSettingsNotFound:   "用户设置不存在",
+		errorsvar.ReasonUserPreferenceNotFound: "用户喜好设置不存在",
+		errorsvar.ReasonInvalidRefreshToken:    "无效的刷新令牌",
+		errorsvar.ReasonUserNotInTrash:         "回收站中不存在该用户",
+		errorsvar.ReasonCaptchaInvalid:         "验证码错误或已过期",
+		errorsvar.ReasonDictionaryNotFound:     "词典记录不存在",
+		errorsvar.ReasonDictionaryExists:       "该翻译记录已存在",
+		errorsvar.ReasonDictionaryNotInTrash:   "回收站中不存在该词典记录",
+		errorsvar.ReasonFavoriteNotFound:       "收藏记录不存在",
+		errorsvar.ReasonFavoriteExists:         "该单词已经收藏",
+		errorsvar.ReasonInvalidCursor:          "无效的分页游标",
+		errorsvar.ReasonTranslationUnavailable: "翻译服务暂时不可用，请稍后再试",
+		errorsvar.ReasonImageTooLarge:          "图片过大",
+		errorsvar.ReasonImageUnsupported:       "不支持的图片格式，请上传JPEG、PNG或BMP图片",
+
+		KeyCaptchaEmailSubject: "验证码 - Flashcard App",
+		KeyCaptchaEmailBody: `
+		<html>
+		<body>
+			<h2>验证码</h2>
+			<p>您的验证码是：<strong>%s</strong></p>
+			<p>验证码有效期为5分钟，请及时使用。</p>
+			<p>如果您没有请求此验证码，请忽略此邮件。</p>
+			<br>
+			<p>此邮件由系统自动发送，请勿回复。</p>
+		</body>
+		</html>
+	`,
+	},
+	English: {
+		errorsvar.ReasonInternal:               "Internal server error",
+		errorsvar.ReasonInvalidArgument:        "Invalid request parameters",
+		errorsvar.ReasonCanceled:               "The request was cancelled",
+		errorsvar.ReasonDeadlineExceeded:       "The request timed out",
+		errorsvar.ReasonTokenMissing:           "Authentication credentials are missing",
+		errorsvar.ReasonTokenInvalid:           "Invalid access token",
+		errorsvar.ReasonIdempotencyKeyInvalid:  "Invalid idempotency key",
+		errorsvar.ReasonIdempotencyKeyReused:   "This idempotency key was already used for a different request",
+		errorsvar.ReasonIdempotencyInProgress:  "A request with this idempotency key is still being processed, please retry later",
+		errorsvar.ReasonRateLimited:            "Too many requests, please try again later",
+		errorsvar.ReasonRequestBodyTooLarge:    "Request body too large",
+		errorsvar.ReasonShuttingDown:           "The server is shutting down, please reconnect",
+		errorsvar.ReasonUserNotFound:           "User not found",
+		errorsvar.ReasonUserExists:             "This email address is already registered",
+		errorsvar.ReasonInvalidCredentials:     "Incorrect email or password",
+		errorsvar.ReasonAccountDisabled:        "This account has been disabled",
+		errorsvar.ReasonPermissionDenied:       "You do not have permission to perform this operation",
+		errorsvar.ReasonUserSettingsNotFound:   "User settings not found",
+		errorsvar.ReasonUserPreferenceNotFound: "User preferences not found",
+		errorsvar.ReasonInvalidRefreshToken:    "Invalid refresh token",
+		errorsvar.ReasonUserNotInTrash:         "The user is not in the trash",
+		errorsvar.ReasonCaptchaInvalid:         "The verification code is incorrect or has expired",
+		errorsvar.ReasonDictionaryNotFound:     "Dictionary entry not found",
+		errorsvar.ReasonDictionaryExists:       "This translation already exists",
+		errorsvar.ReasonDictionaryNotInTrash:   "The dictionary entry is not in the trash",
+		errorsvar.ReasonFavoriteNotFound:       "Favorite not found",
+		errorsvar.ReasonFavoriteExists:         "This word is already in your favorites",
+		errorsvar.ReasonInvalidCursor:          "Invalid page token",
+		errorsvar.ReasonTranslationUnavailable: "The translation service is temporarily unavailable, please try again later",
+		errorsvar.ReasonImageTooLarge:          "The image is too large",
+		errorsvar.ReasonImageUnsupported:       "Unsupported image format, please upload a JPEG, PNG or BMP image",
+
+		KeyCaptchaEmailSubject: "Verification code - Flashcard App",
+		KeyCaptchaEmailBody: `
+		<html>
+		<body>
+			<h2>Verification code</h2>
+			<p>Your verification code is: <strong>%s</strong></p>
+			<p>The code is valid for 5 minutes.</p>
+			<p>If you did not request this code, please ignore this email.</p>
+			<br>
+			<p>This email was sent automatically. Please do not reply.</p>
+		</body>
+		</html>
+	`,
+	},
+	Japanese: {
+		errorsvar.ReasonInternal:               "サーバー内部エラーが発生しました",
+		errorsvar.ReasonInvalidArgument:        "リクエストパラメータが正しくありません",
+		errorsvar.ReasonCanceled:               "リクエストがキャンセルされました",
+		errorsvar.ReasonDeadlineExceeded:       "リクエストがタイムアウトしました",
+		errorsvar.ReasonTokenMissing:           "認証情報がありません",
+		errorsvar.ReasonTokenInvalid:           "アクセストークンが無効です",
+		errorsvar.ReasonIdempotencyKeyInvalid:  "冪等キーの形式が正しくありません",
+		errorsvar.ReasonIdempotencyKeyReused:   "この冪等キーは別のリクエストで使用されています",
+		errorsvar.ReasonIdempotencyInProgress:  "同じ冪等キーのリクエストを処理中です。しばらくしてから再試行してください",
+		errorsvar.ReasonRateLimited:            "リクエストが多すぎます。しばらくしてから再試行してください",
+		errorsvar.ReasonRequestBodyTooLarge:    "リクエストボディが大きすぎます",
+		errorsvar.ReasonShuttingDown:           "サーバーを停止しています。再接続してください",
+		errorsvar.ReasonUserNotFound:           "ユーザーが存在しません",
+		errorsvar.ReasonUserExists:             "このメールアドレスは既に登録されています",
+		errorsvar.ReasonInvalidCredentials:     "メールアドレスまたはパスワードが正しくありません",
+		errorsvar.ReasonAccountDisabled:        "このアカウントは無効化されています",
+		errorsvar.ReasonPermissionDenied:       "この操作を実行する権限がありません",
+		errorsvar.ReasonUserSettingsNotFound:   "ユーザー設定が存在しません",
+		errorsvar.ReasonUserPreferenceNotFound: "ユーザーの好み設定が存在しません",
+		errorsvar.ReasonInvalidRefreshToken:    "リフレッシュトークンが無効です",
+		errorsvar.ReasonUserNotInTrash:         "ごみ箱にこのユーザーは存在しません",
+		errorsvar.ReasonCaptchaInvalid:         "認証コードが正しくないか、有効期限が切れています",
+		errorsvar.ReasonDictionaryNotFound:     "辞書データが存在しません",
+		errorsvar.ReasonDictionaryExists:       "この翻訳は既に存在します",
+		errorsvar.ReasonDictionaryNotInTrash:   "ごみ箱にこの辞書データは存在しません",
+		errorsvar.ReasonFavoriteNotFound:       "お気に入りが存在しません",
+		errorsvar.ReasonFavoriteExists:         "この単語は既にお気に入りに追加されています",
+		errorsvar.ReasonInvalidCursor:          "ページトークンが無効です",
+		errorsvar.ReasonTranslationUnavailable: "翻訳サービスは一時的に利用できません。しばらくしてから再試行してください",
+		errorsvar.ReasonImageTooLarge:          "画像が大きすぎます",
+		errorsvar.ReasonImageUnsupported:       "サポートされていない画像形式です。JPEG、PNG、BMP画像をアップロードしてください",
+
+		KeyCaptchaEmailSubject: "認証コード - Flashcard App",
+		KeyCaptchaEmailBody: `
+		<html>
+		<body>
+			<h2>認証コード</h2>
+			<p>認証コードは <strong>%s</strong> です。</p>
+			<p>有効期限は5分間です。お早めにご利用ください。</p>
+			<p>このメールに心当たりがない場合は、破棄してください。</p>
+			<br>
+			<p>このメールは送信専用です。返信しないでください。</p>
+		</body>
+		</html>
+	`,
+	},
+}
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// reasons 解析errors包中所有Reason开头的常量，返回常量名到错误原因的映射
+func reasons(t *testing.T) map[string]string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "../errors/domain.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]string)
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !strings.HasPrefix(name.Name, "Reason") || i >= len(spec.Values) {
+				continue
+			}
+			lit, ok := spec.Values[i].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				t.Fatalf("%s is not a string literal", name.Name)
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result[name.Name] = value
+		}
+		return true
+	})
+	return result
+}
+
+func TestCatalogCoversReasons(t *testing.T) {
+	all := reasons(t)
+	if len(all) == 0 {
+		t.Fatal("no Reason constants found")
+	}
+	for _, tag := range supported {
+		texts, ok := catalog[tag]
+		if !ok {
+			t.Errorf("no catalog for %s", tag)
+			continue
+		}
+		for name, reason := range all {
+			if texts[reason] == "" {
+				t.Errorf("catalog %s has no text for %s (%s)", tag, name, reason)
+			}
+		}
+	}
+}
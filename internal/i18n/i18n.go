@@ -0,0 +1,113 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// AcceptLanguageHeader 客户端指定语言的metadata名称，HTTP网关会转发同名请求头
+const AcceptLanguageHeader = "accept-language"
+
+// 支持的语言
+var (
+	ChineseSimplified = language.MustParse("zh-CN")
+	English           = language.English
+	Japanese          = language.Japanese
+)
+
+// Default 无法确定客户端语言时使用的默认语言
+var Default = ChineseSimplified
+
+// supported 支持的语言，顺序与matcher的索引一致
+var supported = []language.Tag{ChineseSimplified, English, Japanese}
+
+var matcher = language.NewMatcher(supported)
+
+// Match 依次尝试每个语言偏好（Accept-Language格式，例如 "en-US,en;q=0.9"），
+// 返回第一个能匹配到的支持语言；都无法匹配时返回默认语言和false
+func Match(preferences ...string) (language.Tag, bool) {
+	for _, preference := range preferences {
+		if preference == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := matcher.Match(tags...)
+		if confidence != language.No {
+			return supported[index], true
+		}
+	}
+	return Default, false
+}
+
+// Text 返回key在指定语言下的文本，该语言没有翻译时依次回退到默认语言和fallback
+func Text(tag language.Tag, key, fallback string) string {
+	if text, ok := catalog[tag][key]; ok {
+		return text
+	}
+	if text, ok := catalog[Default][key]; ok {
+		return text
+	}
+	return fallback
+}
+
+// Message 返回错误描述在指定语言下的文本。只有描述与默认语言中reason的文本相同，即来自预定义错误时才替换，
+// 调用方自定义的描述（例如带有具体字段的参数错误）原样返回
+func Message(tag language.Tag, reason, message string) string {
+	if text, ok := catalog[Default][reason]; !ok || text != message {
+		return message
+	}
+	return Text(tag, reason, message)
+}
+
+// Sprintf 使用key对应的文本作为格式化模板
+func Sprintf(tag language.Tag, key string, args ...interface{}) string {
+	return fmt.Sprintf(Text(tag, key, key), args...)
+}
+
+// contextKey 上下文中保存语言的键
+type contextKey struct{}
+
+// locale 请求的语言。保存为指针，内层拦截器（例如认证之后读取用户偏好）修改后外层拦截器也能读取到
+type locale struct {
+	mu  sync.RWMutex
+	tag language.Tag
+}
+
+// NewContext 根据请求指定的语言创建上下文，acceptLanguage为空或无法匹配时使用默认语言
+func NewContext(ctx context.Context, acceptLanguage string) context.Context {
+	tag, _ := Match(acceptLanguage)
+	return context.WithValue(ctx, contextKey{}, &locale{tag: tag})
+}
+
+// SetPreference preference（例如用户设置中的语言偏好）能匹配到支持的语言时覆盖请求指定的语言。
+// 浏览器总是携带Accept-Language，用户保存的设置优先
+func SetPreference(ctx context.Context, preference string) {
+	l, ok := ctx.Value(contextKey{}).(*locale)
+	if !ok {
+		return
+	}
+	tag, matched := Match(preference)
+	if !matched {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tag = tag
+}
+
+// FromContext 返回请求的语言，上下文中没有时返回默认语言
+func FromContext(ctx context.Context) language.Tag {
+	l, ok := ctx.Value(contextKey{}).(*locale)
+	if !ok {
+		return Default
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tag
+}
@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"golang.org/x/text/language"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences []string
+		want        language.Tag
+		wantOK      bool
+	}{
+		{name: "empty", want: Default},
+		{name: "exact", preferences: []string{"en"}, want: English, wantOK: true},
+		{name: "region", preferences: []string{"en-US,en;q=0.9"}, want: English, wantOK: true},
+		{name: "quality order", preferences: []string{"fr;q=0.5,ja;q=0.8,en;q=0.3"}, want: Japanese, wantOK: true},
+		{name: "chinese variant", preferences: []string{"zh-Hans-CN"}, want: ChineseSimplified, wantOK: true},
+		{name: "unsupported", preferences: []string{"de-DE,de;q=0.9"}, want: Default},
+		{name: "malformed", preferences: []string{"en;q=abc;;"}, want: Default},
+		{name: "first matching preference", preferences: []string{"", "de", "ja"}, want: Japanese, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(tt.preferences...)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("Match(%q) = %s, %v, want %s, %v", tt.preferences, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	notFound := errorsvar.ErrUserNotFound.Message
+
+	tests := []struct {
+		name    string
+		tag     language.Tag
+		reason  string
+		message string
+		want    string
+	}{
+		{name: "translated", tag: English, reason: errorsvar.ReasonUserNotFound, message: notFound, want: catalog[English][errorsvar.ReasonUserNotFound]},
+		{name: "default language", tag: Default, reason: errorsvar.ReasonUserNotFound, message: notFound, want: notFound},
+		{name: "missing locale", tag: language.French, reason: errorsvar.ReasonUserNotFound, message: notFound, want: notFound},
+		{name: "missing reason", tag: English, reason: "UNKNOWN_REASON", message: "未知错误", want: "未知错误"},
+		{name: "custom message", tag: English, reason: errorsvar.ReasonInvalidArgument, message: "email格式错误", want: "email格式错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.tag, tt.reason, tt.message); got != tt.want {
+				t.Fatalf("Message(%s, %s, %q) = %q, want %q", tt.tag, tt.reason, tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := Text(Japanese, KeyCaptchaEmailSubject, ""); got != catalog[Japanese][KeyCaptchaEmailSubject] {
+		t.Errorf("Text(ja) = %q", got)
+	}
+	if got := Text(language.French, KeyCaptchaEmailSubject, ""); got != catalog[Default][KeyCaptchaEmailSubject] {
+		t.Errorf("Text(fr) = %q, want default language text", got)
+	}
+	if got := Text(English, "missing.key", "fallback"); got != "fallback" {
+		t.Errorf("Text(missing key) = %q, want fallback", got)
+	}
+}
+
+func TestContextPreference(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		preference     string
+		want           language.Tag
+	}{
+		{name: "default", want: Default},
+		{name: "header", acceptLanguage: "en-US", want: English},
+		{name: "preference without header", preference: "ja", want: Japanese},
+		{name: "preference overrides header", acceptLanguage: "en", preference: "ja", want: Japanese},
+		{name: "unsupported preference keeps header", acceptLanguage: "en", preference: "de", want: English},
+		{name: "unsupported preference ignored", preference: "de", want: Default},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext(context.Background(), tt.acceptLanguage)
+			SetPreference(ctx, tt.preference)
+			if got := FromContext(ctx); got != tt.want {
+				t.Errorf("FromContext() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// 没有语言的上下文使用默认语言
+	ctx := context.Background()
+	SetPreference(ctx, "en")
+	if got := FromContext(ctx); got != Default {
+		t.Errorf("FromContext(background) = %s, want %s", got, Default)
+	}
+}
@@ -0,0 +1,27 @@
+package job
+
+import (
+	"context"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"go.uber.org/fx"
+)
+
+// Module 后台任务模块
+var Module = fx.Options(
+	fx.Provide(NewTrashPurgeJob),
+	// 回收站保留天数支持配置热加载
+	fx.Provide(config.AsSubscriber(func(job *TrashPurgeJob) *TrashPurgeJob { return job })),
+	fx.Invoke(func(lc fx.Lifecycle, purgeJob *TrashPurgeJob) {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				purgeJob.Start()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				purgeJob.Stop()
+				return nil
+			},
+		})
+	}),
+)
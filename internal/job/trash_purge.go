@@ -0,0 +1,163 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+// TrashPurgeJob 定期彻底删除超过保留期的软删除记录。
+// deleted_at为零值的旧记录由PurgeDeletedBefore排除，不依赖000004回填迁移，AutoMigrate的数据库同样可以清理
+type TrashPurgeJob struct {
+	trashRepo repository.TrashRepository
+	retention atomic.Int64 // time.Duration，支持配置热加载
+	logger    *zap.Logger
+
+	mu       sync.Mutex // 保护以下字段，配置热加载时重启清理循环
+	started  bool
+	interval time.Duration
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewTrashPurgeJob 创建回收站清理任务
+func NewTrashPurgeJob(cfg *config.Config, trashRepo repository.TrashRepository, logger *zap.Logger) *TrashPurgeJob {
+	job := &TrashPurgeJob{
+		trashRepo: trashRepo,
+		interval:  purgeInterval(cfg),
+		logger:    logger,
+	}
+	job.retention.Store(int64(retentionDuration(cfg)))
+	return job
+}
+
+// retentionDuration 软删除记录的保留时长
+func retentionDuration(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+}
+
+// purgeInterval 清理间隔
+func purgeInterval(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Trash.PurgeInterval) * time.Minute
+}
+
+// ConfigReloaded 实现config.Subscriber。保留天数在下一次清理时生效；
+// 清理间隔变化或任务在开启和关闭之间切换时重启清理循环
+func (j *TrashPurgeJob) ConfigReloaded(old, updated *config.Config) {
+	if old.Trash == updated.Trash {
+		return
+	}
+	j.logger.Info("回收站清理配置已修改",
+		zap.Int("retention_days", updated.Trash.RetentionDays),
+		zap.Int("purge_interval", updated.Trash.PurgeInterval))
+
+	enabled := j.enabled()
+	j.retention.Store(int64(retentionDuration(updated)))
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	interval := purgeInterval(updated)
+	if !j.started || (interval == j.interval && enabled == j.enabled()) {
+		j.interval = interval
+		return
+	}
+	j.stopLoop()
+	j.interval = interval
+	j.startLoop()
+}
+
+// Start 启动清理任务，保留天数或清理间隔为0时不清理，直到热加载为正数
+func (j *TrashPurgeJob) Start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.started = true
+	j.startLoop()
+}
+
+// Stop 停止清理任务，等待正在执行的清理完成
+func (j *TrashPurgeJob) Stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.started = false
+	if j.stopLoop() {
+		j.logger.Info("回收站清理任务已停止")
+	}
+}
+
+// enabled 保留天数是否为正数
+func (j *TrashPurgeJob) enabled() bool {
+	return j.retention.Load() > 0
+}
+
+// startLoop 按当前的清理间隔启动清理循环，调用方持有mu
+func (j *TrashPurgeJob) startLoop() {
+	if j.interval <= 0 || !j.enabled() {
+		j.logger.Info("回收站清理任务未开启")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.wg.Add(1)
+	go j.loop(ctx, j.interval)
+
+	j.logger.Info("回收站清理任务已启动",
+		zap.Duration("retention", time.Duration(j.retention.Load())),
+		zap.Duration("interval", j.interval))
+}
+
+// stopLoop 停止正在运行的清理循环，返回循环是否在运行，调用方持有mu
+func (j *TrashPurgeJob) stopLoop() bool {
+	if j.cancel == nil {
+		return false
+	}
+	j.cancel()
+	j.wg.Wait()
+	j.cancel = nil
+	return true
+}
+
+// RunOnce 立即执行一次清理，保留天数为0时不清理
+func (j *TrashPurgeJob) RunOnce(ctx context.Context) (*repository.PurgeResult, error) {
+	retention := time.Duration(j.retention.Load())
+	if retention <= 0 {
+		return &repository.PurgeResult{}, nil
+	}
+	before := time.Now().Add(-retention)
+	result, err := j.trashRepo.PurgeDeletedBefore(ctx, before)
+	if err != nil {
+		j.logger.Error("清理回收站失败", zap.Time("before", before), zap.Error(err))
+		return nil, err
+	}
+
+	j.logger.Info("回收站已清理",
+		zap.Time("before", before),
+		zap.Int64("users", result.Users),
+		zap.Int64("favorites", result.Favorites),
+		zap.Int64("study_records", result.StudyRecords),
+		zap.Int64("dictionaries", result.Dictionaries))
+	return result, nil
+}
+
+// loop 按固定间隔执行清理
+func (j *TrashPurgeJob) loop(ctx context.Context, interval time.Duration) {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 停止时等待本次清理完成，不中断正在执行的事务
+			_, _ = j.RunOnce(context.WithoutCancel(ctx))
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/pkg/resilience"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// namespace 所有指标的前缀
+const namespace = "flashcard"
+
+// Metrics Prometheus指标集合，使用独立的Registry，避免与第三方库的全局指标冲突
+type Metrics struct {
+	registry            *prometheus.Registry
+	rpcHandled          *prometheus.CounterVec
+	rpcDuration         *prometheus.HistogramVec
+	streamsInFlight     *prometheus.GaugeVec
+	translationRequests *prometheus.CounterVec
+	translationDuration *prometheus.HistogramVec
+	translationRejected *prometheus.CounterVec
+	translationFallback *prometheus.CounterVec
+	circuitState        *prometheus.GaugeVec
+	logger              *zap.Logger
+}
+
+// NewMetrics 创建指标集合并注册Go运行时和进程指标
+func NewMetrics(logger *zap.Logger) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handled_total",
+			Help:      "已完成的RPC总数，按方法和状态码统计",
+		}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handling_seconds",
+			Help:      "RPC处理耗时（秒）",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"}),
+		streamsInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_streams_in_flight",
+			Help:      "正在处理的流式RPC数量",
+		}, []string{"grpc_service", "grpc_method"}),
+		translationRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "translation_requests_total",
+			Help:      "翻译引擎请求总数，按结果统计",
+		}, []string{"engine", "result"}),
+		translationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "translation_request_seconds",
+			Help:      "翻译引擎请求耗时（秒）",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 3, 5, 10},
+		}, []string{"engine"}),
+		translationRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "translation_rejected_total",
+			Help:      "未发送给翻译引擎的请求数，reason为circuit_open或bulkhead_full",
+		}, []string{"engine", "reason"}),
+		translationFallback: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "translation_fallback_total",
+			Help:      "降级查询词典缓存的次数，按是否命中统计",
+		}, []string{"engine", "result"}),
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "circuit_breaker_state",
+			Help:      "熔断器状态：0闭合，1断开，2半开",
+		}, []string{"name"}),
+		logger: logger,
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcHandled,
+		m.rpcDuration,
+		m.streamsInFlight,
+		m.translationRequests,
+		m.translationDuration,
+		m.translationRejected,
+		m.translationFallback,
+		m.circuitState,
+	)
+	return m
+}
+
+// Handler 返回/metrics的HTTP处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		Registry:          m.registry,
+		EnableOpenMetrics: true,
+	})
+}
+
+// Register 注册自定义采集器
+func (m *Metrics) Register(collector prometheus.Collector) error {
+	return m.registry.Register(collector)
+}
+
+// RegisterGaugeFunc 注册在采集时读取当前值的指标，name不需要带前缀
+func (m *Metrics) RegisterGaugeFunc(name, help string, fn func() float64) {
+	m.register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// RegisterCounterFunc 注册在采集时读取当前值的计数器，name不需要带前缀
+func (m *Metrics) RegisterCounterFunc(name, help string, fn func() float64) {
+	m.register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// register 注册采集器，重复注册只记录日志
+func (m *Metrics) register(collector prometheus.Collector) {
+	if err := m.registry.Register(collector); err != nil {
+		m.logger.Warn("Failed to register metrics collector", zap.Error(err))
+	}
+}
+
+// ObserveTranslation 记录一次翻译引擎请求
+func (m *Metrics) ObserveTranslation(engine string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.translationRequests.WithLabelValues(engine, result).Inc()
+	m.translationDuration.WithLabelValues(engine).Observe(time.Since(start).Seconds())
+}
+
+// ObserveTranslationRejected 记录一次因熔断或并发已满未发送给翻译引擎的请求
+func (m *Metrics) ObserveTranslationRejected(engine, reason string) {
+	m.translationRejected.WithLabelValues(engine, reason).Inc()
+}
+
+// ObserveTranslationFallback 记录一次降级查询词典缓存的结果
+func (m *Metrics) ObserveTranslationFallback(engine string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.translationFallback.WithLabelValues(engine, result).Inc()
+}
+
+// SetCircuitState 更新熔断器状态
+func (m *Metrics) SetCircuitState(name string, state resilience.State) {
+	m.circuitState.WithLabelValues(name).Set(float64(state))
+}
+
+// UnaryInterceptor 记录一元RPC的耗时和状态码
+func (m *Metrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observeRPC("unary", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor 记录流式RPC的耗时、状态码和正在处理的数量
+func (m *Metrics) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method := splitMethodName(info.FullMethod)
+		inFlight := m.streamsInFlight.WithLabelValues(service, method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observeRPC(streamType(info), info.FullMethod, start, err)
+		return err
+	}
+}
+
+// observeRPC 记录RPC结果
+func (m *Metrics) observeRPC(rpcType, fullMethod string, start time.Time, err error) {
+	service, method := splitMethodName(fullMethod)
+	code := status.Code(err).String()
+	m.rpcHandled.WithLabelValues(rpcType, service, method, code).Inc()
+	m.rpcDuration.WithLabelValues(rpcType, service, method, code).Observe(time.Since(start).Seconds())
+}
+
+// splitMethodName 将/package.Service/Method拆分为服务名和方法名
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+// streamType 流式RPC的类型
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
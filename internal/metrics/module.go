@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// Module 指标模块
+var Module = fx.Options(
+	fx.Provide(NewMetrics),
+	fx.Invoke(func(m *Metrics, cfg *config.Config, db *gorm.DB, redisClient *redis.RedisClient) error {
+		if err := m.RegisterDatabase(db, cfg.Database.DBName); err != nil {
+			return err
+		}
+		return m.RegisterRedis(redisClient)
+	}),
+)
@@ -4,24 +4,28 @@ import (
 	"context"
 	"strings"
 
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/repository"
 	"github.com/cheel98/flashcard-backend/pkg/jwt"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
 )
 
-// AuthMiddleware JWT认证中间件
+// AuthMiddleware JWT认证中间件。认证后检查请求中的user_id是否为当前用户，管理员方法还要检查用户角色
 type AuthMiddleware struct {
 	jwtManager *jwt.JWTManager
+	userRepo   repository.UserRepository
 	logger     *zap.Logger
 }
 
 // NewAuthMiddleware 创建认证中间件
-func NewAuthMiddleware(jwtManager *jwt.JWTManager, logger *zap.Logger) *AuthMiddleware {
+func NewAuthMiddleware(jwtManager *jwt.JWTManager, userRepo repository.UserRepository, logger *zap.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtManager: jwtManager,
+		userRepo:   userRepo,
 		logger:     logger,
 	}
 }
@@ -37,13 +41,16 @@ func (a *AuthMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
 		// 验证token
 		claims, err := a.authorize(ctx)
 		if err != nil {
-			a.logger.Error("认证失败", zap.String("method", info.FullMethod), zap.Error(err))
+			logger.FromContext(ctx, a.logger).Error("认证失败", zap.String("method", info.FullMethod), zap.Error(err))
 			return nil, err
 		}
 
 		// 将用户信息添加到上下文
 		ctx = a.addUserToContext(ctx, claims)
 
+		if err := a.checkPermission(ctx, info.FullMethod, claims, req); err != nil {
+			return nil, err
+		}
 		return handler(ctx, req)
 	}
 }
@@ -59,7 +66,7 @@ func (a *AuthMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
 		// 验证token
 		claims, err := a.authorize(ss.Context())
 		if err != nil {
-			a.logger.Error("认证失败", zap.String("method", info.FullMethod), zap.Error(err))
+			logger.FromContext(ss.Context(), a.logger).Error("认证失败", zap.String("method", info.FullMethod), zap.Error(err))
 			return err
 		}
 
@@ -69,6 +76,10 @@ func (a *AuthMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
 			ctx:          a.addUserToContext(ss.Context(), claims),
 		}
 
+		// 流式请求的消息在处理时才接收，这里只检查管理员权限
+		if err := a.checkPermission(wrappedStream.ctx, info.FullMethod, claims, nil); err != nil {
+			return err
+		}
 		return handler(srv, wrappedStream)
 	}
 }
@@ -77,43 +88,87 @@ func (a *AuthMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
 func (a *AuthMiddleware) authorize(ctx context.Context) (*jwt.Claims, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "缺少metadata")
+		return nil, errorsvar.ErrTokenMissing
 	}
 
 	values := md["authorization"]
 	if len(values) == 0 {
-		return nil, status.Errorf(codes.Unauthenticated, "缺少authorization header")
+		return nil, errorsvar.ErrTokenMissing
 	}
 
 	accessToken := values[0]
 	if !strings.HasPrefix(accessToken, "Bearer ") {
-		return nil, status.Errorf(codes.Unauthenticated, "无效的authorization header格式")
+		return nil, errorsvar.ErrTokenInvalid.WithMessage("无效的authorization header格式")
 	}
 
 	accessToken = strings.TrimPrefix(accessToken, "Bearer ")
 	claims, err := a.jwtManager.VerifyToken(accessToken)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "无效的access token: %v", err)
+		return nil, errorsvar.ErrTokenInvalid.WithCause(err)
 	}
 
 	// 检查token类型
 	if claims.TokenType != jwt.AccessToken {
-		return nil, status.Errorf(codes.Unauthenticated, "无效的token类型")
+		return nil, errorsvar.ErrTokenInvalid.WithMessage("无效的token类型")
 	}
 
 	return claims, nil
 }
 
-// isPublicMethod 检查是否为公开方法（不需要认证）
-func (a *AuthMiddleware) isPublicMethod(method string) bool {
-	publicMethods := []string{
-		"/user.UserService/Register",
-		"/user.UserService/SendEmailCaptcha",
-		"/user.UserService/VerifyCaptcha",
-		"/user.UserService/Login",
-		"/user.UserService/RefreshToken",
+// checkPermission 检查请求中的user_id是否为当前用户，以及管理员方法的调用者是否为管理员
+func (a *AuthMiddleware) checkPermission(ctx context.Context, method string, claims *jwt.Claims, req interface{}) error {
+	if owned, ok := req.(interface{ GetUserId() string }); ok {
+		if userID := owned.GetUserId(); userID != "" && userID != claims.UserID {
+			logger.FromContext(ctx, a.logger).Warn("访问其他用户的数据",
+				zap.String("method", method),
+				zap.String("requestUserID", userID))
+			return errorsvar.ErrPermissionDenied
+		}
 	}
 
+	if !adminMethods[method] {
+		return nil
+	}
+	user, err := a.userRepo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Role != model.RoleAdmin {
+		logger.FromContext(ctx, a.logger).Warn("非管理员调用管理员方法", zap.String("method", method))
+		return errorsvar.ErrPermissionDenied
+	}
+	return nil
+}
+
+// adminMethods 只有管理员可以调用的方法，词典记录由所有用户共享
+var adminMethods = map[string]bool{
+	"/dictionary.DictionaryService/DeleteDictionary":        true,
+	"/dictionary.DictionaryService/RestoreDictionary":       true,
+	"/dictionary.DictionaryService/ListTrashedDictionaries": true,
+}
+
+// publicMethods 公开方法（不需要认证）
+var publicMethods = []string{
+	"/user.UserService/Register",
+	"/user.UserService/SendEmailCaptcha",
+	"/user.UserService/VerifyCaptcha",
+	"/user.UserService/Login",
+	"/user.UserService/RefreshToken",
+	// 负载均衡器和探针不携带令牌
+	"/grpc.health.v1.Health/Check",
+	"/grpc.health.v1.Health/Watch",
+	"/health.HealthService/Check",
+	"/health.HealthService/Watch",
+	"/health.HealthService/Heartbeat",
+}
+
+// PublicMethods 返回不需要认证的方法，OpenAPI文档据此标记不需要令牌的接口
+func PublicMethods() []string {
+	return append([]string(nil), publicMethods...)
+}
+
+// isPublicMethod 检查是否为公开方法（不需要认证）
+func (a *AuthMiddleware) isPublicMethod(method string) bool {
 	for _, publicMethod := range publicMethods {
 		if method == publicMethod {
 			return true
@@ -122,8 +177,9 @@ func (a *AuthMiddleware) isPublicMethod(method string) bool {
 	return false
 }
 
-// addUserToContext 将用户信息添加到上下文
+// addUserToContext 将用户信息添加到上下文，并为请求级日志追加用户ID
 func (a *AuthMiddleware) addUserToContext(ctx context.Context, claims *jwt.Claims) context.Context {
+	ctx = logger.AddFields(ctx, zap.String("user_id", claims.UserID))
 	return context.WithValue(ctx, "user_id", claims.UserID)
 }
 
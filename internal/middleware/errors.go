@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// internalErrorMessage 未知错误返回给调用方的描述，具体原因只记录到日志中
+const internalErrorMessage = "服务器内部错误"
+
+// ErrorMiddleware 错误转换中间件，将处理器返回的错误统一转换为带有错误详情和本地化描述的gRPC状态
+type ErrorMiddleware struct {
+	logger *zap.Logger
+}
+
+// NewErrorMiddleware 创建错误转换中间件
+func NewErrorMiddleware(logger *zap.Logger) *ErrorMiddleware {
+	return &ErrorMiddleware{
+		logger: logger,
+	}
+}
+
+// UnaryInterceptor 一元RPC拦截器
+func (e *ErrorMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, e.toStatus(ctx, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamInterceptor 流式RPC拦截器
+func (e *ErrorMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return e.toStatus(ss.Context(), err)
+		}
+		return nil
+	}
+}
+
+// toStatus 转换错误：来自预定义错误的描述按请求的语言替换后，领域错误转换为带详情的状态，已有的gRPC状态原样返回，
+// 其他错误记录日志后返回不含内部信息的Internal错误
+func (e *ErrorMiddleware) toStatus(ctx context.Context, err error) error {
+	reqLogger := logger.FromContext(ctx, e.logger)
+
+	domainErr, ok := errorsvar.As(err)
+	if !ok {
+		if st, ok := status.FromError(err); ok {
+			return st.Err()
+		}
+		switch {
+		case errors.Is(err, context.Canceled):
+			domainErr = errorsvar.ErrCanceled
+		case errors.Is(err, context.DeadlineExceeded):
+			domainErr = errorsvar.ErrDeadlineExceeded
+		default:
+			domainErr = errorsvar.Internal(internalErrorMessage, err)
+		}
+	}
+	if cause := domainErr.Cause(); cause != nil {
+		reqLogger.Error("请求处理出错", zap.String("reason", domainErr.Reason), zap.Error(cause))
+	}
+
+	message := i18n.Message(i18n.FromContext(ctx), domainErr.Reason, domainErr.Message)
+	return domainErr.WithMessage("%s", message).GRPCStatus().Err()
+}
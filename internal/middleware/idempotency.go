@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// IdempotencyKeyHeader 幂等键在metadata和HTTP头中的名称
+	IdempotencyKeyHeader = "idempotency-key"
+	// IdempotentReplayedHeader 响应是重放的已保存结果时，在响应头中返回该字段
+	IdempotentReplayedHeader = "idempotent-replayed"
+	// maxIdempotencyKeyLength 幂等键的最大长度
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotentMethods 支持幂等键的方法，只有会产生副作用且客户端可能重试的方法才需要加入
+var idempotentMethods = map[string]bool{
+	"/user.UserService/Register":                  true,
+	"/favorite.FavoriteService/AddFavorite":       true,
+	"/favorite.FavoriteService/BatchAddFavorites": true,
+	"/favorite.FavoriteService/AddStudyRecord":    true,
+	"/translation.Translation/SaveImageWords":     true,
+}
+
+// idempotencyRecord Redis中保存的幂等记录
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`        // 请求内容的摘要，用于识别复用幂等键的不同请求
+	Done        bool   `json:"done"`               // 请求是否已成功完成
+	Response    []byte `json:"response,omitempty"` // 序列化为Any的响应
+}
+
+// IdempotencyMiddleware 幂等键中间件。请求携带idempotency-key时，成功的响应在Redis中保存一段时间，
+// 相同幂等键和相同内容的重试直接返回保存的响应；内容不同的请求被拒绝。失败的请求不保存，重试时重新执行
+type IdempotencyMiddleware struct {
+	redisClient *redis.RedisClient
+	ttl         time.Duration
+	lockTimeout time.Duration
+	logger      *zap.Logger
+}
+
+// NewIdempotencyMiddleware 创建幂等键中间件
+func NewIdempotencyMiddleware(cfg *config.Config, redisClient *redis.RedisClient, logger *zap.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		redisClient: redisClient,
+		ttl:         time.Duration(cfg.Idempotency.TTL) * time.Hour,
+		lockTimeout: time.Duration(cfg.Idempotency.LockTimeout) * time.Second,
+		logger:      logger,
+	}
+}
+
+// UnaryInterceptor 一元RPC拦截器，需要位于认证和请求校验之后，幂等键按用户隔离
+func (i *IdempotencyMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !idempotentMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		key := idempotencyKeyFromMetadata(ctx)
+		msg, ok := req.(proto.Message)
+		if key == "" || !ok {
+			return handler(ctx, req)
+		}
+		if len(key) > maxIdempotencyKeyLength || !printableASCII(key) {
+			return nil, errorsvar.ErrIdempotencyKeyInvalid
+		}
+
+		fingerprint, err := requestFingerprint(msg)
+		if err != nil {
+			return nil, errorsvar.Internal("计算请求摘要失败", err)
+		}
+		redisKey := i.redisKey(ctx, info.FullMethod, key)
+		reqLogger := logger.FromContext(ctx, i.logger).With(zap.String("idempotency_key", key))
+
+		acquired, err := i.acquire(ctx, redisKey, fingerprint)
+		if err != nil {
+			// Redis不可用时不阻塞请求，退化为不保证幂等
+			reqLogger.Warn("幂等记录写入失败，跳过幂等检查", zap.Error(err))
+			return handler(ctx, req)
+		}
+		if !acquired {
+			return i.replay(ctx, redisKey, fingerprint, reqLogger)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			i.release(redisKey, reqLogger)
+			return nil, err
+		}
+		i.save(ctx, redisKey, fingerprint, resp, reqLogger)
+		return resp, nil
+	}
+}
+
+// acquire 写入处理中的记录，幂等键已存在时返回false
+func (i *IdempotencyMiddleware) acquire(ctx context.Context, redisKey, fingerprint string) (bool, error) {
+	data, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return false, err
+	}
+	return i.redisClient.SetNX(ctx, redisKey, data, i.lockTimeout)
+}
+
+// replay 返回已保存的响应；请求内容不同或原请求仍在处理中时返回错误
+func (i *IdempotencyMiddleware) replay(ctx context.Context, redisKey, fingerprint string, reqLogger *zap.Logger) (interface{}, error) {
+	value, err := i.redisClient.Get(ctx, redisKey)
+	if err != nil {
+		if errors.Is(err, redis.ErrKeyNotFound) {
+			// 记录恰好过期，让客户端重试
+			return nil, errorsvar.ErrIdempotencyInProgress
+		}
+		return nil, errorsvar.Internal("读取幂等记录失败", err)
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return nil, errorsvar.Internal("解析幂等记录失败", err)
+	}
+	if record.Fingerprint != fingerprint {
+		reqLogger.Warn("幂等键被用于不同的请求")
+		return nil, errorsvar.ErrIdempotencyKeyReused
+	}
+	if !record.Done {
+		return nil, errorsvar.ErrIdempotencyInProgress
+	}
+
+	var saved anypb.Any
+	if err := proto.Unmarshal(record.Response, &saved); err != nil {
+		return nil, errorsvar.Internal("解析幂等记录失败", err)
+	}
+	resp, err := saved.UnmarshalNew()
+	if err != nil {
+		return nil, errorsvar.Internal("解析幂等记录失败", err)
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedHeader, "true")); err != nil {
+		reqLogger.Debug("写入幂等重放响应头失败", zap.Error(err))
+	}
+	reqLogger.Info("重放幂等请求的响应")
+	return resp, nil
+}
+
+// save 保存成功的响应，保存失败只记录日志，不影响本次请求
+func (i *IdempotencyMiddleware) save(ctx context.Context, redisKey, fingerprint string, resp interface{}, reqLogger *zap.Logger) {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		i.release(redisKey, reqLogger)
+		return
+	}
+	saved, err := anypb.New(msg)
+	if err != nil {
+		reqLogger.Error("序列化幂等响应失败", zap.Error(err))
+		i.release(redisKey, reqLogger)
+		return
+	}
+	response, err := proto.Marshal(saved)
+	if err != nil {
+		reqLogger.Error("序列化幂等响应失败", zap.Error(err))
+		i.release(redisKey, reqLogger)
+		return
+	}
+	data, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Done: true, Response: response})
+	if err != nil {
+		reqLogger.Error("序列化幂等记录失败", zap.Error(err))
+		i.release(redisKey, reqLogger)
+		return
+	}
+	if err := i.redisClient.Set(context.WithoutCancel(ctx), redisKey, data, i.ttl); err != nil {
+		reqLogger.Error("保存幂等响应失败", zap.Error(err))
+	}
+}
+
+// release 删除处理中的记录，使失败的请求可以重试
+func (i *IdempotencyMiddleware) release(redisKey string, reqLogger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := i.redisClient.Delete(ctx, redisKey); err != nil {
+		reqLogger.Error("删除幂等记录失败", zap.Error(err))
+	}
+}
+
+// redisKey 幂等记录的键，包含用户ID和方法名，不同用户或不同方法使用相同幂等键互不影响
+func (i *IdempotencyMiddleware) redisKey(ctx context.Context, method, key string) string {
+	scope := "anonymous"
+	if userID, ok := GetUserIDFromContext(ctx); ok && userID != "" {
+		scope = userID
+	}
+	return fmt.Sprintf("idempotency:%s:%s:%s", scope, method, key)
+}
+
+// idempotencyKeyFromMetadata 从metadata读取幂等键
+func idempotencyKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(IdempotencyKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// requestFingerprint 计算请求内容的摘要，使用确定性序列化保证相同内容得到相同结果
+func requestFingerprint(msg proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// printableASCII 是否只包含可见ASCII字符
+func printableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,311 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cheel98/flashcard-backend/internal/config"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"github.com/cheel98/flashcard-backend/proto/generated/favorite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const addFavoriteMethod = "/favorite.FavoriteService/AddFavorite"
+
+// newTestRedis 启动miniredis并返回连接到它的客户端
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.RedisClient) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	cfg := config.DefaultConfig()
+	cfg.Redis.Host = server.Host()
+	port, err := strconv.Atoi(server.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Redis.Port = port
+	client, err := redis.NewRedisClient(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+// headerStream 记录拦截器写入的响应头
+type headerStream struct {
+	mu     sync.Mutex
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string {
+	return addFavoriteMethod
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *headerStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+func (s *headerStream) Header() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header.Copy()
+}
+
+// idempotentCall 以指定用户和幂等键调用拦截器，返回响应、写入的响应头和错误
+func idempotentCall(interceptor grpc.UnaryServerInterceptor, userID, key string, req *favorite.AddFavoriteRequest, handler grpc.UnaryHandler) (interface{}, metadata.MD, error) {
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(IdempotencyKeyHeader, key))
+	if userID != "" {
+		ctx = context.WithValue(ctx, "user_id", userID)
+	}
+	resp, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: addFavoriteMethod}, handler)
+	return resp, stream.Header(), err
+}
+
+// countingHandler 每次调用返回递增ID的收藏
+type countingHandler struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (h *countingHandler) handle(ctx context.Context, req interface{}) (interface{}, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &favorite.AddFavoriteResponse{Favorite: &favorite.Favorite{Id: strconv.Itoa(h.calls)}}, nil
+}
+
+func (h *countingHandler) Calls() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+func newTestIdempotencyMiddleware(t *testing.T) (*IdempotencyMiddleware, *miniredis.Miniredis) {
+	t.Helper()
+	server, client := newTestRedis(t)
+	cfg := config.DefaultConfig()
+	return NewIdempotencyMiddleware(cfg, client, zap.NewNop()), server
+}
+
+func TestIdempotencyReplaysSavedResponse(t *testing.T) {
+	m, _ := newTestIdempotencyMiddleware(t)
+	interceptor := m.UnaryInterceptor()
+	handler := &countingHandler{}
+	req := &favorite.AddFavoriteRequest{DictionaryId: 42}
+
+	first, header, err := idempotentCall(interceptor, "1", "key-1", req, handler.handle)
+	if err != nil {
+		t.Fatalf("first call error = %v", err)
+	}
+	if len(header.Get(IdempotentReplayedHeader)) != 0 {
+		t.Fatalf("first call must not set %s", IdempotentReplayedHeader)
+	}
+
+	second, header, err := idempotentCall(interceptor, "1", "key-1", &favorite.AddFavoriteRequest{DictionaryId: 42}, handler.handle)
+	if err != nil {
+		t.Fatalf("retry error = %v", err)
+	}
+	if calls := handler.Calls(); calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+	if got := header.Get(IdempotentReplayedHeader); len(got) != 1 || got[0] != "true" {
+		t.Fatalf("%s = %v, want [true]", IdempotentReplayedHeader, got)
+	}
+	replayed, ok := second.(*favorite.AddFavoriteResponse)
+	if !ok {
+		t.Fatalf("replayed response type = %T", second)
+	}
+	if replayed.GetFavorite().GetId() != first.(*favorite.AddFavoriteResponse).GetFavorite().GetId() {
+		t.Fatalf("replayed favorite id = %s, want %s", replayed.GetFavorite().GetId(), first.(*favorite.AddFavoriteResponse).GetFavorite().GetId())
+	}
+}
+
+func TestIdempotencyRejectsReusedKeyWithDifferentPayload(t *testing.T) {
+	m, _ := newTestIdempotencyMiddleware(t)
+	interceptor := m.UnaryInterceptor()
+	handler := &countingHandler{}
+
+	if _, _, err := idempotentCall(interceptor, "1", "key-1", &favorite.AddFavoriteRequest{DictionaryId: 42}, handler.handle); err != nil {
+		t.Fatalf("first call error = %v", err)
+	}
+	_, _, err := idempotentCall(interceptor, "1", "key-1", &favorite.AddFavoriteRequest{DictionaryId: 43}, handler.handle)
+	if !errors.Is(err, errorsvar.ErrIdempotencyKeyReused) {
+		t.Fatalf("error = %v, want ErrIdempotencyKeyReused", err)
+	}
+	if calls := handler.Calls(); calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	m, _ := newTestIdempotencyMiddleware(t)
+	interceptor := m.UnaryInterceptor()
+	req := &favorite.AddFavoriteRequest{DictionaryId: 42}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	slow := func(ctx context.Context, req interface{}) (interface{}, error) {
+		close(started)
+		<-finish
+		return &favorite.AddFavoriteResponse{}, nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := idempotentCall(interceptor, "1", "key-1", req, slow)
+		done <- err
+	}()
+	<-started
+
+	handler := &countingHandler{}
+	_, _, err := idempotentCall(interceptor, "1", "key-1", req, handler.handle)
+	if !errors.Is(err, errorsvar.ErrIdempotencyInProgress) {
+		t.Fatalf("concurrent call error = %v, want ErrIdempotencyInProgress", err)
+	}
+	if calls := handler.Calls(); calls != 0 {
+		t.Fatalf("handler calls = %d, want 0", calls)
+	}
+
+	close(finish)
+	if err := <-done; err != nil {
+		t.Fatalf("first call error = %v", err)
+	}
+	if _, _, err := idempotentCall(interceptor, "1", "key-1", req, handler.handle); err != nil {
+		t.Fatalf("call after completion error = %v", err)
+	}
+}
+
+func TestIdempotencyReleasesKeyAfterHandlerError(t *testing.T) {
+	m, _ := newTestIdempotencyMiddleware(t)
+	interceptor := m.UnaryInterceptor()
+	req := &favorite.AddFavoriteRequest{DictionaryId: 42}
+	handler := &countingHandler{err: errorsvar.ErrFavoriteExists}
+
+	if _, _, err := idempotentCall(interceptor, "1", "key-1", req, handler.handle); !errors.Is(err, errorsvar.ErrFavoriteExists) {
+		t.Fatalf("first call error = %v, want ErrFavoriteExists", err)
+	}
+
+	handler.err = nil
+	_, header, err := idempotentCall(interceptor, "1", "key-1", req, handler.handle)
+	if err != nil {
+		t.Fatalf("retry error = %v", err)
+	}
+	if calls := handler.Calls(); calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+	if len(header.Get(IdempotentReplayedHeader)) != 0 {
+		t.Fatal("retry after a failure must run the handler instead of replaying")
+	}
+}
+
+func TestIdempotencyKeyValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "max length", key: strings.Repeat("k", maxIdempotencyKeyLength)},
+		{name: "printable symbols", key: "a1-B2_c3.~!@#"},
+		{name: "too long", key: strings.Repeat("k", maxIdempotencyKeyLength+1), wantErr: errorsvar.ErrIdempotencyKeyInvalid},
+		{name: "space", key: "key 1", wantErr: errorsvar.ErrIdempotencyKeyInvalid},
+		{name: "control character", key: "key\t1", wantErr: errorsvar.ErrIdempotencyKeyInvalid},
+		{name: "non ascii", key: "幂等键", wantErr: errorsvar.ErrIdempotencyKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestIdempotencyMiddleware(t)
+			handler := &countingHandler{}
+			_, _, err := idempotentCall(m.UnaryInterceptor(), "1", tt.key, &favorite.AddFavoriteRequest{DictionaryId: 42}, handler.handle)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if calls := handler.Calls(); calls != 0 {
+				t.Fatalf("handler calls = %d, want 0", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeysAreScopedPerUser(t *testing.T) {
+	m, server := newTestIdempotencyMiddleware(t)
+	interceptor := m.UnaryInterceptor()
+	handler := &countingHandler{}
+
+	for _, userID := range []string{"1", "2", ""} {
+		// 不同用户使用相同的幂等键和不同的请求内容，互不影响
+		_, header, err := idempotentCall(interceptor, userID, "shared-key", &favorite.AddFavoriteRequest{DictionaryId: uint64(len(userID) + 40)}, handler.handle)
+		if err != nil {
+			t.Fatalf("user %q: error = %v", userID, err)
+		}
+		if len(header.Get(IdempotentReplayedHeader)) != 0 {
+			t.Fatalf("user %q: response replayed from another user", userID)
+		}
+	}
+	if calls := handler.Calls(); calls != 3 {
+		t.Fatalf("handler calls = %d, want 3", calls)
+	}
+	for _, scope := range []string{"1", "2", "anonymous"} {
+		key := "idempotency:" + scope + ":" + addFavoriteMethod + ":shared-key"
+		if !server.Exists(key) {
+			t.Fatalf("record %q not saved", key)
+		}
+		if ttl := server.TTL(key); ttl != time.Duration(config.DefaultConfig().Idempotency.TTL)*time.Hour {
+			t.Fatalf("record %q TTL = %v", key, ttl)
+		}
+	}
+}
+
+func TestIdempotencySkipsRequestsWithoutKeyOrUnlistedMethod(t *testing.T) {
+	m, server := newTestIdempotencyMiddleware(t)
+	interceptor := m.UnaryInterceptor()
+	handler := &countingHandler{}
+	req := &favorite.AddFavoriteRequest{DictionaryId: 42}
+
+	for i := 0; i < 2; i++ {
+		if _, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: addFavoriteMethod}, handler.handle); err != nil {
+			t.Fatal(err)
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, "key-1"))
+		if _, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/favorite.FavoriteService/ListFavorites"}, handler.handle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := handler.Calls(); calls != 4 {
+		t.Fatalf("handler calls = %d, want 4", calls)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Fatalf("redis keys = %v, want none", keys)
+	}
+}
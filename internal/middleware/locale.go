@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// 用户语言偏好缓存
+const (
+	preferenceCacheTTL  = 5 * time.Minute
+	preferenceCacheSize = 10000
+)
+
+// LocaleMiddleware 语言中间件，确定返回给调用方的消息使用的语言：
+// 优先使用已登录用户设置中的语言偏好，其次使用accept-language，最后使用默认语言
+type LocaleMiddleware struct {
+	userRepo repository.UserRepository
+	logger   *zap.Logger
+
+	// preferences 用户语言偏好缓存，避免每个请求都查询用户设置
+	mu          sync.Mutex
+	preferences map[string]cachedPreference
+}
+
+// cachedPreference 缓存的语言偏好，用户没有设置时为空
+type cachedPreference struct {
+	language  string
+	expiresAt time.Time
+}
+
+// NewLocaleMiddleware 创建语言中间件
+func NewLocaleMiddleware(userRepo repository.UserRepository, logger *zap.Logger) *LocaleMiddleware {
+	return &LocaleMiddleware{
+		userRepo:    userRepo,
+		logger:      logger,
+		preferences: make(map[string]cachedPreference),
+	}
+}
+
+// UnaryInterceptor 一元RPC拦截器，根据accept-language确定请求的语言，需要位于错误转换中间件之前
+func (l *LocaleMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(newLocaleContext(ctx), req)
+	}
+}
+
+// StreamInterceptor 流式RPC拦截器，根据accept-language确定请求的语言
+func (l *LocaleMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedServerStream{ServerStream: ss, ctx: newLocaleContext(ss.Context())})
+	}
+}
+
+// UserUnaryInterceptor 一元RPC拦截器，需要位于认证中间件之后；用户设置了语言偏好时覆盖accept-language
+func (l *LocaleMiddleware) UserUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		l.applyUserPreference(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// UserStreamInterceptor 流式RPC拦截器，需要位于认证中间件之后
+func (l *LocaleMiddleware) UserStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		l.applyUserPreference(ss.Context())
+		return handler(srv, ss)
+	}
+}
+
+// applyUserPreference 读取已登录用户的语言偏好，读取失败时保持当前语言
+func (l *LocaleMiddleware) applyUserPreference(ctx context.Context) {
+	userID, ok := GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return
+	}
+	preference, err := l.userPreference(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx, l.logger).Debug("读取用户语言偏好失败", zap.Error(err))
+		return
+	}
+	i18n.SetPreference(ctx, preference)
+}
+
+// userPreference 返回用户的语言偏好，缓存preferenceCacheTTL，修改语言偏好后最迟在缓存过期后生效。
+// 用户没有设置时同样缓存，查询出错时不缓存
+func (l *LocaleMiddleware) userPreference(ctx context.Context, userID string) (string, error) {
+	now := time.Now()
+	l.mu.Lock()
+	cached, ok := l.preferences[userID]
+	l.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.language, nil
+	}
+
+	var preference string
+	settings, err := l.userRepo.GetUserSettings(ctx, userID)
+	switch {
+	case err == nil:
+		preference = settings.LanguagePreference
+	case !errors.Is(err, errorsvar.ErrUserSettingsNotFound):
+		return "", err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.preferences) >= preferenceCacheSize {
+		for id, entry := range l.preferences {
+			if !now.Before(entry.expiresAt) {
+				delete(l.preferences, id)
+			}
+		}
+		// 仍然没有空间时清空缓存，下次请求重新查询
+		if len(l.preferences) >= preferenceCacheSize {
+			clear(l.preferences)
+		}
+	}
+	l.preferences[userID] = cachedPreference{language: preference, expiresAt: now.Add(preferenceCacheTTL)}
+	return preference, nil
+}
+
+// newLocaleContext 根据accept-language创建带语言的上下文
+func newLocaleContext(ctx context.Context) context.Context {
+	var acceptLanguage string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(i18n.AcceptLanguageHeader); len(values) > 0 {
+			acceptLanguage = values[0]
+		}
+	}
+	return i18n.NewContext(ctx, acceptLanguage)
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeSettingsRepository 按用户ID返回语言偏好，记录查询次数
+type fakeSettingsRepository struct {
+	repository.UserRepository
+
+	mu          sync.Mutex
+	preferences map[string]string
+	err         error
+	calls       int
+}
+
+func (r *fakeSettingsRepository) GetUserSettings(ctx context.Context, userID string) (*model.UserSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	preference, ok := r.preferences[userID]
+	if !ok {
+		return nil, errorsvar.ErrUserSettingsNotFound
+	}
+	return &model.UserSettings{UserID: userID, LanguagePreference: preference}, nil
+}
+
+// localeOf 依次经过语言拦截器和用户语言拦截器，返回处理请求时的语言
+func localeOf(t *testing.T, middleware *LocaleMiddleware, acceptLanguage, userID string) language.Tag {
+	t.Helper()
+	ctx := context.Background()
+	if acceptLanguage != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(i18n.AcceptLanguageHeader, acceptLanguage))
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: addFavoriteMethod}
+
+	var got language.Tag
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got = i18n.FromContext(ctx)
+		return nil, nil
+	}
+	userHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		// 认证中间件在两个拦截器之间写入用户ID
+		if userID != "" {
+			ctx = context.WithValue(ctx, "user_id", userID)
+		}
+		return middleware.UserUnaryInterceptor()(ctx, req, info, handler)
+	}
+	if _, err := middleware.UnaryInterceptor()(ctx, nil, info, userHandler); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestLocaleMiddleware(t *testing.T) {
+	userRepo := &fakeSettingsRepository{preferences: map[string]string{
+		"user-ja":          "ja",
+		"user-empty":       "",
+		"user-unsupported": "de",
+	}}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		userID         string
+		want           language.Tag
+	}{
+		{name: "default", want: i18n.Default},
+		{name: "accept-language", acceptLanguage: "en-US,en;q=0.9", want: i18n.English},
+		{name: "accept-language quality", acceptLanguage: "de;q=0.9,ja;q=0.8,en;q=0.1", want: i18n.Japanese},
+		{name: "unsupported accept-language", acceptLanguage: "fr-FR", want: i18n.Default},
+		{name: "user preference", userID: "user-ja", want: i18n.Japanese},
+		{name: "user preference overrides header", acceptLanguage: "en-US", userID: "user-ja", want: i18n.Japanese},
+		{name: "no user preference", acceptLanguage: "en", userID: "user-empty", want: i18n.English},
+		{name: "unsupported user preference", acceptLanguage: "en", userID: "user-unsupported", want: i18n.English},
+		{name: "no user settings", acceptLanguage: "ja", userID: "user-missing", want: i18n.Japanese},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := NewLocaleMiddleware(userRepo, zap.NewNop())
+			if got := localeOf(t, middleware, tt.acceptLanguage, tt.userID); got != tt.want {
+				t.Fatalf("locale = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocaleMiddlewareCachesPreference(t *testing.T) {
+	userRepo := &fakeSettingsRepository{preferences: map[string]string{"user-1": "ja"}}
+	middleware := NewLocaleMiddleware(userRepo, zap.NewNop())
+
+	for i := 0; i < 3; i++ {
+		if got := localeOf(t, middleware, "en", "user-1"); got != i18n.Japanese {
+			t.Fatalf("request %d: locale = %s, want %s", i, got, i18n.Japanese)
+		}
+	}
+	if userRepo.calls != 1 {
+		t.Errorf("GetUserSettings called %d times, want 1", userRepo.calls)
+	}
+}
+
+func TestLocaleMiddlewarePreferenceError(t *testing.T) {
+	userRepo := &fakeSettingsRepository{err: errors.New("connection refused")}
+	middleware := NewLocaleMiddleware(userRepo, zap.NewNop())
+
+	// 查询失败时使用请求头的语言，且不缓存失败结果
+	for i := 0; i < 2; i++ {
+		if got := localeOf(t, middleware, "en", "user-1"); got != i18n.English {
+			t.Fatalf("request %d: locale = %s, want %s", i, got, i18n.English)
+		}
+	}
+	if userRepo.calls != 2 {
+		t.Errorf("GetUserSettings called %d times, want 2", userRepo.calls)
+	}
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/tracing"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader 请求ID在metadata和HTTP头中的名称
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength 上游传入的请求ID最大长度，超出或包含非法字符时重新生成
+const maxRequestIDLength = 128
+
+// requestIDKey 上下文中保存请求ID的键
+type requestIDKey struct{}
+
+// LoggingMiddleware 请求日志中间件，为每个请求分配请求ID并创建请求级日志实例
+type LoggingMiddleware struct {
+	logger *zap.Logger
+}
+
+// NewLoggingMiddleware 创建请求日志中间件
+func NewLoggingMiddleware(logger *zap.Logger) *LoggingMiddleware {
+	return &LoggingMiddleware{
+		logger: logger,
+	}
+}
+
+// UnaryInterceptor 一元RPC拦截器
+func (l *LoggingMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = l.newRequestContext(ctx, info.FullMethod)
+		reqLogger := logger.FromContext(ctx, l.logger)
+		if reqLogger.Core().Enabled(zapcore.DebugLevel) {
+			reqLogger.Debug("收到请求", logger.Proto("request", req))
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		l.logCompletion(ctx, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor 流式RPC拦截器
+func (l *LoggingMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := l.newRequestContext(ss.Context(), info.FullMethod)
+		logger.FromContext(ctx, l.logger).Debug("收到流式请求")
+
+		start := time.Now()
+		err := handler(srv, &wrappedServerStream{ServerStream: ss, ctx: ctx})
+		l.logCompletion(ctx, start, err)
+		return err
+	}
+}
+
+// newRequestContext 确定请求ID，回写到响应头，并在上下文中保存请求级日志实例
+func (l *LoggingMiddleware) newRequestContext(ctx context.Context, method string) context.Context {
+	requestID := requestIDFromMetadata(ctx)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID)); err != nil {
+		l.logger.Debug("写入请求ID响应头失败", zap.Error(err))
+	}
+
+	fields := []zap.Field{
+		zap.String("request_id", requestID),
+		zap.String("method", method),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		// 经过HTTP网关的请求，peer为网关地址，真实客户端地址在x-forwarded-for中
+		if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+			fields = append(fields, zap.String("forwarded_for", forwarded[0]))
+		}
+	}
+	fields = append(fields, tracing.LogFields(ctx)...)
+
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return logger.WithContext(ctx, l.logger.With(fields...))
+}
+
+// logCompletion 记录请求结果
+func (l *LoggingMiddleware) logCompletion(ctx context.Context, start time.Time, err error) {
+	fields := []zap.Field{
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	}
+	reqLogger := logger.FromContext(ctx, l.logger)
+	if err != nil {
+		reqLogger.Info("请求失败", append(fields, zap.String("error", status.Convert(err).Message()))...)
+		return
+	}
+	reqLogger.Info("请求完成", fields...)
+}
+
+// requestIDFromMetadata 读取上游传入的请求ID，不合法时返回空
+func requestIDFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(RequestIDHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	requestID := values[0]
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return ""
+	}
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return ""
+		}
+	}
+	return requestID
+}
+
+// GetRequestIDFromContext 从上下文获取请求ID
+func GetRequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok
+}
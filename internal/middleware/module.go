@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"github.com/cheel98/flashcard-backend/internal/config"
 	"go.uber.org/fx"
 )
 
@@ -8,5 +9,12 @@ import (
 var Module = fx.Options(
 	fx.Provide(
 		NewAuthMiddleware,
+		NewLoggingMiddleware,
+		NewErrorMiddleware,
+		NewValidationMiddleware,
+		NewLocaleMiddleware,
+		NewIdempotencyMiddleware,
+		NewRateLimitMiddleware,
 	),
+	fx.Provide(config.AsSubscriber(func(m *RateLimitMiddleware) *RateLimitMiddleware { return m })),
 )
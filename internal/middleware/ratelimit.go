@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/pkg/logger"
+	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// 限流相关的响应头，HTTP网关转换为 RateLimit-Limit 等标准请求头
+const (
+	RateLimitLimitHeader     = "ratelimit-limit"
+	RateLimitRemainingHeader = "ratelimit-remaining"
+	RateLimitResetHeader     = "ratelimit-reset"
+	RetryAfterHeader         = "retry-after"
+)
+
+// forwardedForHeader HTTP网关转发请求时写入的客户端地址
+const forwardedForHeader = "x-forwarded-for"
+
+// inProcessNetwork 进程内HTTP网关连接的网络类型（grpc/test/bufconn）
+const inProcessNetwork = "bufconn"
+
+// rateLimitRules 生效中的限流规则
+type rateLimitRules struct {
+	enabled     bool
+	defaultRule config.RateLimitRule
+	methods     map[string][]config.RateLimitRule // 按会员等级从高到低排列
+}
+
+// newRateLimitRules 整理限流配置，便于按方法查找
+func newRateLimitRules(cfg config.RateLimitConfig) *rateLimitRules {
+	rules := &rateLimitRules{
+		enabled:     cfg.Enabled,
+		defaultRule: cfg.Default,
+		methods:     make(map[string][]config.RateLimitRule),
+	}
+	for _, rule := range cfg.Rules {
+		rules.methods[rule.Method] = append(rules.methods[rule.Method], rule)
+	}
+	for _, methodRules := range rules.methods {
+		sort.Slice(methodRules, func(i, j int) bool {
+			return methodRules[i].MembershipLevel > methodRules[j].MembershipLevel
+		})
+	}
+	return rules
+}
+
+// RateLimitMiddleware 限流中间件，已登录用户按用户ID计数，公开方法按客户端IP计数。
+// 限额按方法和会员等级配置，计数保存在Redis中，多个实例共享；Redis不可用时不限流
+type RateLimitMiddleware struct {
+	redisClient *redis.RedisClient
+	userRepo    repository.UserRepository
+	rules       atomic.Pointer[rateLimitRules]
+	logger      *zap.Logger
+}
+
+// NewRateLimitMiddleware 创建限流中间件
+func NewRateLimitMiddleware(cfg *config.Config, redisClient *redis.RedisClient, userRepo repository.UserRepository, logger *zap.Logger) *RateLimitMiddleware {
+	r := &RateLimitMiddleware{
+		redisClient: redisClient,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+	r.rules.Store(newRateLimitRules(cfg.RateLimit))
+	return r
+}
+
+// ConfigReloaded 实现config.Subscriber，限流规则修改后立即生效
+func (r *RateLimitMiddleware) ConfigReloaded(old, updated *config.Config) {
+	if reflect.DeepEqual(old.RateLimit, updated.RateLimit) {
+		return
+	}
+	r.rules.Store(newRateLimitRules(updated.RateLimit))
+	r.logger.Info("限流规则已更新", zap.Bool("enabled", updated.RateLimit.Enabled), zap.Int("rules", len(updated.RateLimit.Rules)))
+}
+
+// UnaryInterceptor 一元RPC拦截器，需要位于认证中间件之后
+func (r *RateLimitMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := r.limit(ctx, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor 流式RPC拦截器，每个流计为一次请求
+func (r *RateLimitMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := r.limit(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// limit 消耗一次请求并写入限流响应头，超过限额时返回错误
+func (r *RateLimitMiddleware) limit(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	rules := r.rules.Load()
+	if !rules.enabled {
+		return nil
+	}
+
+	reqLogger := logger.FromContext(ctx, r.logger)
+	userID, _ := GetUserIDFromContext(ctx)
+	rule := r.match(ctx, rules, method, userID)
+	if rule.Limit == 0 {
+		return nil
+	}
+
+	subject := "user:" + userID
+	if userID == "" {
+		ip := clientIP(ctx)
+		if ip == "" {
+			reqLogger.Debug("无法确定客户端地址，跳过限流")
+			return nil
+		}
+		subject = "ip:" + ip
+	}
+
+	result, err := r.redisClient.AllowRate(ctx, "ratelimit:"+method+":"+subject, redis.RateLimit{
+		Limit:  rule.Limit,
+		Period: time.Duration(rule.Period) * time.Second,
+		Burst:  rule.Burst,
+	})
+	if err != nil {
+		reqLogger.Warn("限流检查失败，跳过限流", zap.Error(err))
+		return nil
+	}
+
+	md := metadata.Pairs(
+		RateLimitLimitHeader, strconv.Itoa(result.Limit),
+		RateLimitRemainingHeader, strconv.Itoa(result.Remaining),
+		RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.ResetAfter)),
+	)
+	if result.Allowed {
+		if err := setHeader(md); err != nil {
+			reqLogger.Debug("写入限流响应头失败", zap.Error(err))
+		}
+		return nil
+	}
+
+	retryAfter := strconv.Itoa(ceilSeconds(result.RetryAfter))
+	md.Set(RetryAfterHeader, retryAfter)
+	if err := setHeader(md); err != nil {
+		reqLogger.Debug("写入限流响应头失败", zap.Error(err))
+	}
+	reqLogger.Warn("请求超过限额", zap.String("method", method), zap.String("subject", subject))
+	return errorsvar.ErrRateLimited.WithMetadata("retry_after", retryAfter)
+}
+
+// match 选择适用的规则：方法有单独配置时使用用户会员等级能达到的最高等级的规则，否则使用默认规则
+func (r *RateLimitMiddleware) match(ctx context.Context, rules *rateLimitRules, method, userID string) config.RateLimitRule {
+	methodRules := rules.methods[method]
+	if len(methodRules) == 0 {
+		return rules.defaultRule
+	}
+
+	level := 0
+	if userID != "" && methodRules[0].MembershipLevel > 0 {
+		level = r.membershipLevel(ctx, userID)
+	}
+	for _, rule := range methodRules {
+		if rule.MembershipLevel <= level {
+			return rule
+		}
+	}
+	return rules.defaultRule
+}
+
+// membershipLevel 用户当前有效的会员等级，会员过期或读取失败时为0
+func (r *RateLimitMiddleware) membershipLevel(ctx context.Context, userID string) int {
+	user, err := r.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx, r.logger).Debug("读取用户会员等级失败", zap.Error(err))
+		return 0
+	}
+	if user.MembershipExpire.Before(time.Now()) {
+		return 0
+	}
+	return int(user.MemberShipLevel)
+}
+
+// clientIP 客户端地址。请求来自本机或进程内的HTTP网关时使用网关记录的x-forwarded-for中最后一个地址，
+// 即网关看到的对端地址；直接访问gRPC时使用连接的对端地址，不信任客户端自己传入的x-forwarded-for
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	ip := net.ParseIP(host)
+	if p.Addr.Network() == inProcessNetwork || (ip != nil && ip.IsLoopback()) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(forwardedForHeader); len(values) > 0 {
+				forwarded := strings.Split(values[len(values)-1], ",")
+				if last := strings.TrimSpace(forwarded[len(forwarded)-1]); last != "" {
+					return last
+				}
+			}
+		}
+	}
+	return host
+}
+
+// ceilSeconds 向上取整到秒
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"github.com/cheel98/flashcard-backend/proto/generated/favorite"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const translateMethod = "/translation.Translation/Translation"
+
+// fakeUserRepository 只实现限流用到的GetUserByID
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[string]*model.User
+}
+
+func (r *fakeUserRepository) GetUserByID(ctx context.Context, userID string) (*model.User, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, errorsvar.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// fakeAddr 指定网络类型的对端地址
+type fakeAddr struct {
+	network string
+	address string
+}
+
+func (a fakeAddr) Network() string { return a.network }
+func (a fakeAddr) String() string  { return a.address }
+
+func testRateLimitConfig() config.RateLimitConfig {
+	return config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{Limit: 100, Period: 60},
+		Rules: []config.RateLimitRule{
+			{Method: translateMethod, Limit: 2, Period: 60},
+			{Method: translateMethod, MembershipLevel: 2, Limit: 20, Period: 60},
+			{Method: translateMethod, MembershipLevel: 1, Limit: 10, Period: 60},
+		},
+	}
+}
+
+func TestRateLimitMatch(t *testing.T) {
+	now := time.Now()
+	repo := &fakeUserRepository{users: map[string]*model.User{
+		"basic":   {MemberShipLevel: 1, MembershipExpire: now.Add(time.Hour)},
+		"premium": {MemberShipLevel: 2, MembershipExpire: now.Add(time.Hour)},
+		"top":     {MemberShipLevel: 5, MembershipExpire: now.Add(time.Hour)},
+		"expired": {MemberShipLevel: 2, MembershipExpire: now.Add(-time.Hour)},
+		"free":    {MemberShipLevel: 0},
+	}}
+	m := &RateLimitMiddleware{userRepo: repo, logger: zap.NewNop()}
+	rules := newRateLimitRules(testRateLimitConfig())
+
+	tests := []struct {
+		name      string
+		method    string
+		userID    string
+		wantLimit int
+	}{
+		{name: "anonymous uses level 0 rule", method: translateMethod, userID: "", wantLimit: 2},
+		{name: "free member uses level 0 rule", method: translateMethod, userID: "free", wantLimit: 2},
+		{name: "level 1 member", method: translateMethod, userID: "basic", wantLimit: 10},
+		{name: "level 2 member", method: translateMethod, userID: "premium", wantLimit: 20},
+		{name: "level above highest rule uses highest rule", method: translateMethod, userID: "top", wantLimit: 20},
+		{name: "expired membership falls back to level 0", method: translateMethod, userID: "expired", wantLimit: 2},
+		{name: "unknown user falls back to level 0", method: translateMethod, userID: "missing", wantLimit: 2},
+		{name: "method without rules uses default", method: "/favorite.FavoriteService/AddFavorite", userID: "premium", wantLimit: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rule := m.match(context.Background(), rules, tt.method, tt.userID); rule.Limit != tt.wantLimit {
+				t.Fatalf("match() limit = %d, want %d", rule.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestRateLimitMatchWithoutLevel0Rule(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimitRule{Limit: 100, Period: 60},
+		Rules:   []config.RateLimitRule{{Method: translateMethod, MembershipLevel: 1, Limit: 10, Period: 60}},
+	}
+	m := &RateLimitMiddleware{userRepo: &fakeUserRepository{}, logger: zap.NewNop()}
+	if rule := m.match(context.Background(), newRateLimitRules(cfg), translateMethod, ""); rule.Limit != 100 {
+		t.Fatalf("match() limit = %d, want default 100", rule.Limit)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      net.Addr
+		forwarded []string
+		want      string
+	}{
+		{name: "bufconn trusts forwarded for", addr: fakeAddr{network: "bufconn", address: "bufconn"}, forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "bufconn uses last forwarded hop", addr: fakeAddr{network: "bufconn", address: "bufconn"}, forwarded: []string{"10.0.0.1, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "bufconn uses last forwarded value", addr: fakeAddr{network: "bufconn", address: "bufconn"}, forwarded: []string{"10.0.0.1", "198.51.100.8"}, want: "198.51.100.8"},
+		{name: "loopback trusts forwarded for", addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 40000}, forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "ipv6 loopback trusts forwarded for", addr: &net.TCPAddr{IP: net.ParseIP("::1"), Port: 40000}, forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "loopback without forwarded for", addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 40000}, want: "127.0.0.1"},
+		{name: "direct peer ignores forwarded for", addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.5"), Port: 40000}, forwarded: []string{"198.51.100.7"}, want: "203.0.113.5"},
+		{name: "direct peer", addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.5"), Port: 40000}, want: "203.0.113.5"},
+		{name: "no peer", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.addr != nil {
+				ctx = peer.NewContext(ctx, &peer.Peer{Addr: tt.addr})
+			}
+			md := metadata.MD{}
+			for _, value := range tt.forwarded {
+				md.Append(forwardedForHeader, value)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+			if got := clientIP(ctx); got != tt.want {
+				t.Fatalf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeadersAndError(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.SetTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := config.DefaultConfig()
+	cfg.RateLimit = testRateLimitConfig()
+	m := NewRateLimitMiddleware(cfg, client, &fakeUserRepository{}, zap.NewNop())
+	interceptor := m.UnaryInterceptor()
+	handler := &countingHandler{}
+
+	call := func() (metadata.MD, error) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.5"), Port: 40000}})
+		_, err := interceptor(ctx, &favorite.AddFavoriteRequest{}, &grpc.UnaryServerInfo{FullMethod: translateMethod}, handler.handle)
+		return stream.Header(), err
+	}
+	expectHeader := func(header metadata.MD, key, want string) {
+		t.Helper()
+		if got := header.Get(key); len(got) != 1 || got[0] != want {
+			t.Fatalf("%s = %v, want [%s]", key, got, want)
+		}
+	}
+
+	header, err := call()
+	if err != nil {
+		t.Fatalf("first call error = %v", err)
+	}
+	expectHeader(header, RateLimitLimitHeader, "2")
+	expectHeader(header, RateLimitRemainingHeader, "1")
+	expectHeader(header, RateLimitResetHeader, "30")
+	if len(header.Get(RetryAfterHeader)) != 0 {
+		t.Fatalf("allowed request must not set %s", RetryAfterHeader)
+	}
+
+	if _, err := call(); err != nil {
+		t.Fatalf("second call error = %v", err)
+	}
+
+	header, err = call()
+	if !errors.Is(err, errorsvar.ErrRateLimited) {
+		t.Fatalf("third call error = %v, want ErrRateLimited", err)
+	}
+	domainErr, ok := errorsvar.As(err)
+	if !ok || domainErr.Metadata["retry_after"] != "30" {
+		t.Fatalf("error metadata = %v, want retry_after 30", domainErr.Metadata)
+	}
+	expectHeader(header, RateLimitLimitHeader, "2")
+	expectHeader(header, RateLimitRemainingHeader, "0")
+	expectHeader(header, RateLimitResetHeader, "60")
+	expectHeader(header, RetryAfterHeader, "30")
+	if calls := handler.Calls(); calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+}
+
+func TestRateLimitCountsUsersAndIPsSeparately(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.SetTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	cfg := config.DefaultConfig()
+	cfg.RateLimit = testRateLimitConfig()
+	m := NewRateLimitMiddleware(cfg, client, &fakeUserRepository{}, zap.NewNop())
+	interceptor := m.UnaryInterceptor()
+	handler := &countingHandler{}
+
+	call := func(userID, ip string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+		if userID != "" {
+			ctx = context.WithValue(ctx, "user_id", userID)
+		}
+		_, err := interceptor(ctx, &favorite.AddFavoriteRequest{}, &grpc.UnaryServerInfo{FullMethod: translateMethod}, handler.handle)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := call("", "203.0.113.5"); err != nil {
+			t.Fatalf("ip request %d error = %v", i, err)
+		}
+	}
+	if err := call("", "203.0.113.5"); !errors.Is(err, errorsvar.ErrRateLimited) {
+		t.Fatalf("ip over limit error = %v, want ErrRateLimited", err)
+	}
+	if err := call("", "203.0.113.6"); err != nil {
+		t.Fatalf("other ip error = %v", err)
+	}
+	// 已登录用户按用户ID计数，与同一IP的匿名请求互不影响
+	if err := call("1", "203.0.113.5"); err != nil {
+		t.Fatalf("user request error = %v", err)
+	}
+	for _, key := range []string{
+		"ratelimit:" + translateMethod + ":ip:203.0.113.5",
+		"ratelimit:" + translateMethod + ":ip:203.0.113.6",
+		"ratelimit:" + translateMethod + ":user:1",
+	} {
+		if !server.Exists(key) {
+			t.Fatalf("rate limit key %q not found, keys = %v", key, server.Keys())
+		}
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	_, client := newTestRedis(t)
+	cfg := config.DefaultConfig()
+	cfg.RateLimit = testRateLimitConfig()
+	cfg.RateLimit.Enabled = false
+	m := NewRateLimitMiddleware(cfg, client, &fakeUserRepository{}, zap.NewNop())
+	handler := &countingHandler{}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.5"), Port: 40000}})
+	for i := 0; i < 5; i++ {
+		if _, err := m.UnaryInterceptor()(ctx, &favorite.AddFavoriteRequest{}, &grpc.UnaryServerInfo{FullMethod: translateMethod}, handler.handle); err != nil {
+			t.Fatalf("request %d error = %v", i, err)
+		}
+	}
+}
@@ -1 +1,88 @@
 package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"buf.build/go/protovalidate"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// validationFailedMessage 请求参数校验失败时返回的描述，具体字段错误放在BadRequest详情中
+const validationFailedMessage = "请求参数错误"
+
+// ValidationMiddleware 请求校验中间件，根据proto文件中声明的protovalidate规则校验请求
+type ValidationMiddleware struct {
+	validator protovalidate.Validator
+}
+
+// NewValidationMiddleware 创建请求校验中间件
+func NewValidationMiddleware() (*ValidationMiddleware, error) {
+	validator, err := protovalidate.New()
+	if err != nil {
+		return nil, fmt.Errorf("创建请求校验器失败: %w", err)
+	}
+	return &ValidationMiddleware{
+		validator: validator,
+	}, nil
+}
+
+// UnaryInterceptor 一元RPC拦截器
+func (v *ValidationMiddleware) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := v.validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor 流式RPC拦截器，校验客户端发送的每一条消息
+func (v *ValidationMiddleware) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingServerStream{ServerStream: ss, middleware: v})
+	}
+}
+
+// validate 校验请求，校验失败时返回带字段错误的领域错误
+func (v *ValidationMiddleware) validate(req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	err := v.validator.Validate(msg)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *protovalidate.ValidationError
+	if !errors.As(err, &validationErr) {
+		return errorsvar.Internal("请求校验失败", err)
+	}
+	violations := make([]errorsvar.FieldViolation, 0, len(validationErr.Violations))
+	for _, violation := range validationErr.Violations {
+		violations = append(violations, errorsvar.FieldViolation{
+			Field:       protovalidate.FieldPathString(violation.Proto.GetField()),
+			Description: violation.Proto.GetMessage(),
+		})
+	}
+	return errorsvar.InvalidArgument(errorsvar.ReasonInvalidArgument, validationFailedMessage, violations...)
+}
+
+// validatingServerStream 接收消息后进行校验的ServerStream
+type validatingServerStream struct {
+	grpc.ServerStream
+	middleware *ValidationMiddleware
+}
+
+// RecvMsg 接收并校验消息
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.middleware.validate(m)
+}
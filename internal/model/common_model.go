@@ -1,9 +1,13 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Model struct {
-	CreatedAt time.Time `gorm:"<-:create;column:create_at;type:timestamptz;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:update_at;type:timestamptz;default:CURRENT_TIMESTAMP" json:"updated_at"`
-	DeletedAt time.Time `gorm:"column:deleted_at;type:timestamptz" json:"deleted_at"`
+	CreatedAt time.Time      `gorm:"<-:create;column:create_at;type:timestamptz;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"column:update_at;type:timestamptz;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;type:timestamptz;index" json:"deleted_at"` // 软删除时间，GORM默认过滤已删除的记录
 }
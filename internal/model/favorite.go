@@ -1,25 +1,106 @@
 package model
 
-// 收藏
+import "time"
+
+// 学习结果
+const (
+	StudyResultRemembered = "remembered"
+	StudyResultFuzzy      = "fuzzy"
+	StudyResultStrange    = "strange"
+)
+
+// reviewIntervals 按记忆深度划分的复习间隔
+var reviewIntervals = []time.Duration{
+	24 * time.Hour,
+	2 * 24 * time.Hour,
+	4 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	15 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+}
+
+// 收藏，同一用户对同一词典只能有一条未删除的收藏（与迁移000006的唯一索引一致，AutoMigrate时同样创建）
 type Favorite struct {
 	ID              string        `gorm:"column:id;type:varchar(255)" json:"id"`
-	UserID          string        `gorm:"column:user_id;type:varchar(255)" json:"user_id"`
-	DictionaryID    uint64        `gorm:"column:dictionary_id;type:bigint" json:"dictionary_id"`
+	UserID          string        `gorm:"column:user_id;type:varchar(255);index:idx_favorite_user_dictionary,unique,where:deleted_at IS NULL" json:"user_id"`
+	DictionaryID    uint64        `gorm:"column:dictionary_id;type:bigint;index:idx_favorite_user_dictionary,unique,where:deleted_at IS NULL" json:"dictionary_id"`
 	MemoryDepth     uint64        `gorm:"column:memory_depth;type:bigint" json:"memory_depth"`
-	FavoriteRecords []StudyRecord `gorm:"foreignKey:ID" json:"favorite_records"`
+	Note            string        `gorm:"column:note;type:text" json:"note"`                                 // 个人笔记
+	CustomExample   string        `gorm:"column:custom_example;type:text" json:"custom_example"`             // 自定义例句
+	LastResult      string        `gorm:"column:last_result;type:varchar(20);default:''" json:"last_result"` // 最近一次学习结果
+	DueAt           time.Time     `gorm:"column:due_at;type:timestamptz;default:CURRENT_TIMESTAMP" json:"due_at"`
+	FavoriteRecords []StudyRecord `gorm:"foreignKey:FavoriteID" json:"favorite_records"`
+	Model
+
+	// 关联表
+	Dictionary *Dictionary   `gorm:"foreignKey:DictionaryID" json:"dictionary,omitempty"`
+	Tags       []FavoriteTag `gorm:"foreignKey:FavoriteID;constraint:OnDelete:CASCADE" json:"tags"`
+}
+
+// FavoriteTag 用户自定义的收藏标签
+type FavoriteTag struct {
+	ID         uint64 `gorm:"primaryKey;autoIncrement;type:bigint" json:"id"`
+	FavoriteID string `gorm:"column:favorite_id;type:varchar(255);not null;index:idx_favorite_tag,unique" json:"favorite_id"`
+	UserID     string `gorm:"column:user_id;type:varchar(255);not null;index:idx_user_tag" json:"user_id"`
+	Name       string `gorm:"column:name;type:varchar(50);not null;index:idx_favorite_tag,unique;index:idx_user_tag" json:"name"`
 	Model
 }
 
 type StudyRecord struct {
-	ID     string `gorm:"column:id;primary_key;type:varchar(255)" json:"id"`
-	Result string `gorm:"column:result;type:varchar(20);check:result IN ('remembered','fuzzy','strange')" json:"result"` // 学习结果
-	Remark string `gorm:"column:remark;type:text" json:"remark"`
+	ID         string `gorm:"column:id;primary_key;type:varchar(255)" json:"id"`
+	FavoriteID string `gorm:"column:favorite_id;type:varchar(255);index" json:"favorite_id"`
+	Result     string `gorm:"column:result;type:varchar(20);check:result IN ('remembered','fuzzy','strange')" json:"result"` // 学习结果
+	Remark     string `gorm:"column:remark;type:text" json:"remark"`
 	Model
 }
 
+// IsValidStudyResult 检查学习结果是否有效
+func IsValidStudyResult(result string) bool {
+	return result == StudyResultRemembered || result == StudyResultFuzzy || result == StudyResultStrange
+}
+
+// NextDueAt 根据记忆深度和本次学习结果计算下次复习时间
+func NextDueAt(memoryDepth uint64, result string, now time.Time) time.Time {
+	switch result {
+	case StudyResultRemembered:
+		if memoryDepth >= uint64(len(reviewIntervals)) {
+			memoryDepth = uint64(len(reviewIntervals) - 1)
+		}
+		return now.Add(reviewIntervals[memoryDepth])
+	case StudyResultFuzzy:
+		return now.Add(reviewIntervals[0])
+	default:
+		// 陌生的单词需要立即复习
+		return now
+	}
+}
+
+// NextMemoryDepth 根据本次学习结果计算新的记忆深度：记住时加一，最多到最长的复习间隔；模糊或陌生时重置为0
+func NextMemoryDepth(memoryDepth uint64, result string) uint64 {
+	if result != StudyResultRemembered {
+		return 0
+	}
+	if memoryDepth >= uint64(len(reviewIntervals)-1) {
+		return uint64(len(reviewIntervals) - 1)
+	}
+	return memoryDepth + 1
+}
+
+// TagNames 返回收藏上的标签名称
+func (f *Favorite) TagNames() []string {
+	names := make([]string, 0, len(f.Tags))
+	for _, tag := range f.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
 func (Favorite) TableName() string {
 	return "favorite"
 }
+func (FavoriteTag) TableName() string {
+	return "favorite_tag"
+}
 func (StudyRecord) TableName() string {
 	return "study_record"
 }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStudyProgression(t *testing.T) {
+	const day = 24 * time.Hour
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 依次学习同一个收藏，每一步基于上一步的记忆深度
+	steps := []struct {
+		result       string
+		wantInterval time.Duration
+		wantDepth    uint64
+	}{
+		{StudyResultRemembered, day, 1},
+		{StudyResultRemembered, 2 * day, 2},
+		{StudyResultRemembered, 4 * day, 3},
+		{StudyResultRemembered, 7 * day, 4},
+		{StudyResultRemembered, 15 * day, 5},
+		{StudyResultRemembered, 30 * day, 5},
+		{StudyResultRemembered, 30 * day, 5},
+		{StudyResultFuzzy, day, 0},
+		{StudyResultRemembered, day, 1},
+		{StudyResultStrange, 0, 0},
+	}
+	var depth uint64
+	for i, step := range steps {
+		if got := NextDueAt(depth, step.result, now).Sub(now); got != step.wantInterval {
+			t.Errorf("step %d (%s at depth %d): interval = %v, want %v", i, step.result, depth, got, step.wantInterval)
+		}
+		depth = NextMemoryDepth(depth, step.result)
+		if depth != step.wantDepth {
+			t.Fatalf("step %d (%s): depth = %d, want %d", i, step.result, depth, step.wantDepth)
+		}
+	}
+}
@@ -6,11 +6,17 @@ import (
 	"time"
 )
 
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID               string    `gorm:"column:id;primary_key;type:varchar(255)" json:"id"`
-	Name             string    `gorm:"column:name;uniqueIndex;type:varchar(20)" json:"name"`
-	Email            string    `gorm:"column:email;uniqueIndex;type:varchar(255)" json:"email"`
-	Phone            string    `gorm:"column:phone;uniqueIndex;type:varchar(11)" json:"phone"`
+	Name             string    `gorm:"column:name;index:idx_user_name,unique,where:deleted_at IS NULL;type:varchar(20)" json:"name"`
+	Email            string    `gorm:"column:email;index:idx_user_email,unique,where:deleted_at IS NULL;type:varchar(255)" json:"email"`
+	Phone            string    `gorm:"column:phone;index:idx_user_phone,unique,where:deleted_at IS NULL;type:varchar(11)" json:"phone"`
 	PasswordHash     string    `gorm:"column:password_hash;type:varchar(255)" json:"-"`
 	RefreshToken     string    `gorm:"column:refresh_token;type:text" json:"-"` // 刷新令牌
 	Avatar           string    `gorm:"column:avatar;type:varchar(255)" json:"-"`
@@ -18,6 +24,8 @@ type User struct {
 	MemberShipLevel  uint64    `gorm:"column:member_ship_level;type:bigint" json:"-"`
 	MembershipExpire time.Time `gorm:"column:membership_expire;type:timestamptz" json:"-"` // 会员到期时间
 	Balance          uint64    `gorm:"column:balance;type:bigint" json:"-"`
+	Role             string    `gorm:"column:role;type:varchar(20);default:'user'" json:"role"` // 角色：user, admin
+	Disabled         bool      `gorm:"column:disabled;default:false" json:"disabled"`           // 被禁用的用户无法登录
 	Model
 
 	// 关联表
@@ -46,6 +54,11 @@ type UserLogs struct {
 	Model
 }
 
+// IsValidRole 检查角色是否有效
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 func (User) TableName() string {
 	return "user"
 }
@@ -0,0 +1,68 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// DefaultFakeRegions Fake引擎默认返回的识别结果
+var DefaultFakeRegions = []Region{
+	{Text: "hello", X: 10, Y: 10, Width: 120, Height: 32, Lines: 1},
+	{Text: "flash card", X: 10, Y: 60, Width: 240, Height: 32, Lines: 1},
+}
+
+// Fake 本地假引擎，不解析图片内容也不调用外部服务，用于开发和测试。
+// 总是返回Regions中的文本，没有设置译文的区域译文为"[目标语言] 原文"
+type Fake struct {
+	Regions  []Region // 识别结果，为空时使用DefaultFakeRegions
+	Language string   // 请求为auto时返回的源语言，为空时为en
+	Err      error    // 不为空时Translate返回该错误
+
+	calls atomic.Int64
+}
+
+// NewFake 创建返回默认识别结果的假引擎
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Name 引擎名称
+func (f *Fake) Name() string {
+	return "fake_ocr"
+}
+
+// Translate 返回固定的识别结果
+func (f *Fake) Translate(ctx context.Context, image []byte, from, to string) (*Result, error) {
+	f.calls.Add(1)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	if from == "auto" {
+		from = f.Language
+		if from == "" {
+			from = "en"
+		}
+	}
+	regions := f.Regions
+	if len(regions) == 0 {
+		regions = DefaultFakeRegions
+	}
+	result := &Result{From: from, To: to, Orientation: "UP", Regions: make([]Region, len(regions))}
+	for i, region := range regions {
+		if region.Translation == "" {
+			region.Translation = fmt.Sprintf("[%s] %s", to, region.Text)
+		}
+		result.Regions[i] = region
+	}
+	return result, nil
+}
+
+// Calls Translate被调用的次数
+func (f *Fake) Calls() int {
+	return int(f.calls.Load())
+}
@@ -0,0 +1,23 @@
+package ocr
+
+import (
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module 图片翻译模块
+var Module = fx.Options(
+	fx.Provide(NewFromConfig),
+)
+
+// NewFromConfig 按transfer_config.ocr.engine创建图片翻译引擎
+func NewFromConfig(cfg *config.Config, client *httpclient.Client, logger *zap.Logger) Engine {
+	ocrCfg := cfg.TransferConfig.OCR
+	if ocrCfg.Engine == config.FAKE {
+		logger.Warn("Using fake OCR engine, image translation returns fixed results")
+		return NewFake()
+	}
+	return NewYoudao(ocrCfg.URL, cfg.TransferConfig.AppKey, cfg.TransferConfig.AppSecret, client)
+}
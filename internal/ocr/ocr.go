@@ -0,0 +1,39 @@
+package ocr
+
+import "context"
+
+// Region 图片中识别出的一段文本及其译文
+type Region struct {
+	Text        string // 识别出的原文
+	Translation string // 译文
+	X           int    // 文本区域左上角的横坐标（像素）
+	Y           int    // 文本区域左上角的纵坐标（像素）
+	Width       int
+	Height      int
+	Lines       int // 区域包含的行数
+}
+
+// Result 图片翻译结果
+type Result struct {
+	From        string // 源语言，请求为auto时是引擎识别到的语言
+	To          string
+	Orientation string // 图片方向，例如UP
+	Regions     []Region
+}
+
+// EngineError 引擎处理了请求但返回了错误码，例如图片中没有文字或语言不支持
+type EngineError struct {
+	Code string
+}
+
+func (e *EngineError) Error() string {
+	return "ocr: engine returned error code " + e.Code
+}
+
+// Engine 图片翻译引擎
+type Engine interface {
+	// Name 引擎名称，用于指标标签
+	Name() string
+	// Translate 识别图片中的文字并翻译，from为auto时由引擎识别源语言
+	Translate(ctx context.Context, image []byte, from, to string) (*Result, error)
+}
@@ -0,0 +1,99 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cheel98/flashcard-backend/internal/utils/authv3"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
+)
+
+// youdaoResponse 有道图片翻译接口的响应
+type youdaoResponse struct {
+	ErrorCode   string         `json:"errorCode"`
+	LanFrom     string         `json:"lanFrom"`
+	LanTo       string         `json:"lanTo"`
+	Orientation string         `json:"orientation"`
+	ResRegions  []youdaoRegion `json:"resRegions"`
+}
+
+// youdaoRegion 有道返回的文本区域，boundingBox格式为"x,y,width,height"
+type youdaoRegion struct {
+	BoundingBox string `json:"boundingBox"`
+	LinesCount  int    `json:"linesCount"`
+	Context     string `json:"context"`
+	TranContent string `json:"tranContent"`
+}
+
+// Youdao 有道图片翻译引擎
+type Youdao struct {
+	url       string
+	appKey    string
+	appSecret string
+	client    *httpclient.Client
+}
+
+// NewYoudao 创建有道图片翻译引擎
+func NewYoudao(url, appKey, appSecret string, client *httpclient.Client) *Youdao {
+	return &Youdao{
+		url:       url,
+		appKey:    appKey,
+		appSecret: appSecret,
+		client:    client,
+	}
+}
+
+// Name 引擎名称
+func (y *Youdao) Name() string {
+	return "youdao_ocr"
+}
+
+// Translate 以Base64上传图片，签名按v3规则对图片内容计算。请求在熔断器和隔舱内执行且按次计费，不在客户端重试
+func (y *Youdao) Translate(ctx context.Context, image []byte, from, to string) (*Result, error) {
+	params := map[string][]string{
+		"type":    {"1"}, // 1表示Base64编码的图片
+		"q":       {base64.StdEncoding.EncodeToString(image)},
+		"from":    {from},
+		"to":      {to},
+		"render":  {"0"}, // 不需要渲染后的图片
+		"docType": {"json"},
+	}
+	authv3.AddAuthParams(y.appKey, y.appSecret, params)
+
+	response, err := y.client.PostForm(ctx, y.url, url.Values(params), nil, false)
+	if err != nil {
+		return nil, err
+	}
+	var body youdaoResponse
+	if err := response.DecodeJSON(&body); err != nil {
+		return nil, err
+	}
+	if body.ErrorCode != "0" {
+		return nil, &EngineError{Code: body.ErrorCode}
+	}
+
+	result := &Result{
+		From:        body.LanFrom,
+		To:          body.LanTo,
+		Orientation: body.Orientation,
+		Regions:     make([]Region, 0, len(body.ResRegions)),
+	}
+	for _, item := range body.ResRegions {
+		region := Region{Text: item.Context, Translation: item.TranContent, Lines: item.LinesCount}
+		region.X, region.Y, region.Width, region.Height = parseBoundingBox(item.BoundingBox)
+		result.Regions = append(result.Regions, region)
+	}
+	return result, nil
+}
+
+// parseBoundingBox 解析"x,y,width,height"格式的区域，格式错误的部分为0
+func parseBoundingBox(box string) (x, y, width, height int) {
+	values := make([]int, 4)
+	for i, part := range strings.SplitN(box, ",", 4) {
+		values[i], _ = strconv.Atoi(strings.TrimSpace(part))
+	}
+	return values[0], values[1], values[2], values[3]
+}
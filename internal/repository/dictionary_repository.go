@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
 	"github.com/cheel98/flashcard-backend/internal/model"
 	"gorm.io/gorm"
 )
@@ -9,15 +11,23 @@ import (
 // DictionaryRepository 词典仓储接口
 type DictionaryRepository interface {
 	// CreateDictionary 新增词典记录
-	CreateDictionary(dictionary *model.Dictionary) error
+	CreateDictionary(ctx context.Context, dictionary *model.Dictionary) error
 	// GetDictionaryByUniqueTranslation 根据idx_unique_translation信息查询dictionary
-	GetDictionaryByUniqueTranslation(sourceLang, targetLang, sourceText string) (*model.Dictionary, error)
+	GetDictionaryByUniqueTranslation(ctx context.Context, sourceLang, targetLang, sourceText string) (*model.Dictionary, error)
+	// ListDictionariesBySourceTexts 批量查询指定语言下原文在sourceTexts中的词典记录
+	ListDictionariesBySourceTexts(ctx context.Context, sourceLang, targetLang string, sourceTexts []string) ([]model.Dictionary, error)
 	// CreateDictionaryAudio 创建词典音频记录
-	CreateDictionaryAudio(audio *model.DictionaryAudio) error
+	CreateDictionaryAudio(ctx context.Context, audio *model.DictionaryAudio) error
 	// CreateDictionaryMetadata 创建词典元数据记录
-	CreateDictionaryMetadata(metadata *model.DictionaryMetadata) error
+	CreateDictionaryMetadata(ctx context.Context, metadata *model.DictionaryMetadata) error
 	// GetDictionaryWithDetails 获取词典详细信息（包含音频和元数据）
-	GetDictionaryWithDetails(dictionaryID uint64) (*model.Dictionary, error)
+	GetDictionaryWithDetails(ctx context.Context, dictionaryID uint64) (*model.Dictionary, error)
+	// DeleteDictionary 删除词典记录（移入回收站）
+	DeleteDictionary(ctx context.Context, dictionaryID uint64) error
+	// RestoreDictionary 从回收站恢复词典记录
+	RestoreDictionary(ctx context.Context, dictionaryID uint64) (*model.Dictionary, error)
+	// ListTrashedDictionaries 查询回收站中的词典记录
+	ListTrashedDictionaries(ctx context.Context, limit, offset int) ([]*model.Dictionary, error)
 }
 
 // dictionaryRepository 词典仓储实现
@@ -33,45 +43,71 @@ func NewDictionaryRepository(db *gorm.DB) DictionaryRepository {
 }
 
 // CreateDictionary 新增词典记录
-func (r *dictionaryRepository) CreateDictionary(dictionary *model.Dictionary) error {
+func (r *dictionaryRepository) CreateDictionary(ctx context.Context, dictionary *model.Dictionary) error {
 	// 检查是否已存在相同的翻译记录（根据唯一索引）
 	var existingDict model.Dictionary
-	err := r.db.Where("source_lang = ? AND target_lang = ? AND source_text = ?",
+	err := r.db.WithContext(ctx).Where("source_lang = ? AND target_lang = ? AND source_text = ?",
 		dictionary.SourceLang, dictionary.TargetLang, dictionary.SourceText).First(&existingDict).Error
 
 	if err == nil {
-		return errors.New("该翻译记录已存在")
+		return errorsvar.ErrDictionaryExists
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 
-	// 创建新的词典记录
-	err = r.db.Create(dictionary).Error
+	// 回收站中存在相同的翻译记录时，恢复并更新为新的内容
+	err = r.db.WithContext(ctx).Unscoped().Where("source_lang = ? AND target_lang = ? AND source_text = ? AND deleted_at IS NOT NULL",
+		dictionary.SourceLang, dictionary.TargetLang, dictionary.SourceText).First(&existingDict).Error
+	if err == nil {
+		dictionary.ID = existingDict.ID
+		dictionary.CreatedAt = existingDict.CreatedAt
+		return r.db.WithContext(ctx).Unscoped().Model(&existingDict).Select("*").Omit("id", "create_at").Updates(dictionary).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 创建新的词典记录，并发请求已经创建时由唯一索引拒绝
+	err = r.db.WithContext(ctx).Create(dictionary).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errorsvar.ErrDictionaryExists
+		}
 		return err
 	}
 	return nil
 }
 
 // GetDictionaryByUniqueTranslation 根据idx_unique_translation信息查询dictionary
-func (r *dictionaryRepository) GetDictionaryByUniqueTranslation(sourceLang, targetLang, sourceText string) (*model.Dictionary, error) {
+func (r *dictionaryRepository) GetDictionaryByUniqueTranslation(ctx context.Context, sourceLang, targetLang, sourceText string) (*model.Dictionary, error) {
 	var dictionary model.Dictionary
-	err := r.db.Where("source_lang = ? AND target_lang = ? AND source_text = ?",
+	err := r.db.WithContext(ctx).Where("source_lang = ? AND target_lang = ? AND source_text = ?",
 		sourceLang, targetLang, sourceText).First(&dictionary).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("词典记录不存在")
+			return nil, errorsvar.ErrDictionaryNotFound
 		}
 		return nil, err
 	}
 	return &dictionary, nil
 }
 
+// ListDictionariesBySourceTexts 批量查询指定语言下原文在sourceTexts中的词典记录
+func (r *dictionaryRepository) ListDictionariesBySourceTexts(ctx context.Context, sourceLang, targetLang string, sourceTexts []string) ([]model.Dictionary, error) {
+	var dictionaries []model.Dictionary
+	if len(sourceTexts) == 0 {
+		return dictionaries, nil
+	}
+	err := r.db.WithContext(ctx).Where("source_lang = ? AND target_lang = ? AND source_text IN ?",
+		sourceLang, targetLang, sourceTexts).Find(&dictionaries).Error
+	return dictionaries, err
+}
+
 // CreateDictionaryAudio 创建词典音频记录
-func (r *dictionaryRepository) CreateDictionaryAudio(audio *model.DictionaryAudio) error {
-	err := r.db.Create(audio).Error
+func (r *dictionaryRepository) CreateDictionaryAudio(ctx context.Context, audio *model.DictionaryAudio) error {
+	err := r.db.WithContext(ctx).Create(audio).Error
 	if err != nil {
 		return err
 	}
@@ -79,8 +115,8 @@ func (r *dictionaryRepository) CreateDictionaryAudio(audio *model.DictionaryAudi
 }
 
 // CreateDictionaryMetadata 创建词典元数据记录
-func (r *dictionaryRepository) CreateDictionaryMetadata(metadata *model.DictionaryMetadata) error {
-	err := r.db.Create(metadata).Error
+func (r *dictionaryRepository) CreateDictionaryMetadata(ctx context.Context, metadata *model.DictionaryMetadata) error {
+	err := r.db.WithContext(ctx).Create(metadata).Error
 	if err != nil {
 		return err
 	}
@@ -88,15 +124,56 @@ func (r *dictionaryRepository) CreateDictionaryMetadata(metadata *model.Dictiona
 }
 
 // GetDictionaryWithDetails 获取词典详细信息（包含音频和元数据）
-func (r *dictionaryRepository) GetDictionaryWithDetails(dictionaryID uint64) (*model.Dictionary, error) {
+func (r *dictionaryRepository) GetDictionaryWithDetails(ctx context.Context, dictionaryID uint64) (*model.Dictionary, error) {
 	var dictionary model.Dictionary
-	err := r.db.Preload("Audios").Preload("Metadata").Where("id = ?", dictionaryID).First(&dictionary).Error
+	err := r.db.WithContext(ctx).Preload("Audios").Preload("Metadata").Where("id = ?", dictionaryID).First(&dictionary).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("词典记录不存在")
+			return nil, errorsvar.ErrDictionaryNotFound
 		}
 		return nil, err
 	}
 	return &dictionary, nil
 }
+
+// DeleteDictionary 删除词典记录（移入回收站）
+func (r *dictionaryRepository) DeleteDictionary(ctx context.Context, dictionaryID uint64) error {
+	result := r.db.WithContext(ctx).Where("id = ?", dictionaryID).Delete(&model.Dictionary{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errorsvar.ErrDictionaryNotFound
+	}
+	return nil
+}
+
+// RestoreDictionary 从回收站恢复词典记录
+func (r *dictionaryRepository) RestoreDictionary(ctx context.Context, dictionaryID uint64) (*model.Dictionary, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.Dictionary{}).
+		Where("id = ? AND deleted_at IS NOT NULL", dictionaryID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errorsvar.ErrDictionaryNotInTrash
+	}
+	return r.GetDictionaryWithDetails(ctx, dictionaryID)
+}
+
+// ListTrashedDictionaries 查询回收站中的词典记录，按删除时间倒序
+func (r *dictionaryRepository) ListTrashedDictionaries(ctx context.Context, limit, offset int) ([]*model.Dictionary, error) {
+	var dictionaries []*model.Dictionary
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&dictionaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return dictionaries, nil
+}
@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultFavoritePageSize 默认分页大小
+	defaultFavoritePageSize = 20
+	// maxFavoritePageSize 最大分页大小
+	maxFavoritePageSize = 100
+)
+
+// ErrInvalidCursor 分页游标无效
+var ErrInvalidCursor = errorsvar.ErrInvalidCursor
+
+// FavoriteSortField 收藏排序字段
+type FavoriteSortField string
+
+const (
+	FavoriteSortCreatedAt   FavoriteSortField = "created_at"
+	FavoriteSortUpdatedAt   FavoriteSortField = "updated_at"
+	FavoriteSortMemoryDepth FavoriteSortField = "memory_depth"
+	FavoriteSortDueAt       FavoriteSortField = "due_at"
+	FavoriteSortSourceText  FavoriteSortField = "source_text"
+)
+
+// favoriteSortColumns 排序字段对应的数据库列
+var favoriteSortColumns = map[FavoriteSortField]string{
+	FavoriteSortCreatedAt:   "favorite.create_at",
+	FavoriteSortUpdatedAt:   "favorite.update_at",
+	FavoriteSortMemoryDepth: "favorite.memory_depth",
+	FavoriteSortDueAt:       "favorite.due_at",
+	// 词典记录被删除时关联列为NULL，按空字符串排序，与游标中记录的值一致
+	FavoriteSortSourceText: `COALESCE("Dictionary".source_text, '')`,
+}
+
+// FavoriteSort 收藏排序条件
+type FavoriteSort struct {
+	Field FavoriteSortField
+	Desc  bool
+}
+
+// FavoriteQuery 收藏查询条件，所有过滤条件之间为AND关系
+type FavoriteQuery struct {
+	UserID         string
+	MinMemoryDepth *uint64
+	MaxMemoryDepth *uint64
+	LastResult     string
+	Tag            string
+	SourceLang     string
+	TargetLang     string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	DueAfter       *time.Time
+	DueBefore      *time.Time
+	Search         string // 在关联词典的源文本和译文中搜索
+
+	Sort         []FavoriteSort // 为空时按创建时间倒序
+	Cursor       string         // 游标分页，优先于Offset
+	Limit        int
+	Offset       int // 仅供旧的limit/offset接口使用
+	IncludeTotal bool
+}
+
+// FavoritePage 收藏分页结果
+type FavoritePage struct {
+	Favorites  []*model.Favorite
+	NextCursor string // 为空表示没有更多数据
+	Total      int64  // 仅在IncludeTotal为true时有效
+}
+
+// favoriteCursor 游标内容，记录上一页最后一条记录的排序值，以及签发游标时的排序和过滤条件
+type favoriteCursor struct {
+	Sort   string   `json:"s"`
+	Filter string   `json:"f"`
+	Values []string `json:"v"`
+	ID     string   `json:"id"`
+}
+
+// ListFavorites 统一查询收藏
+func (r *favoriteRepository) ListFavorites(ctx context.Context, query *FavoriteQuery) (*FavoritePage, error) {
+	sorts := normalizeFavoriteSorts(query.Sort)
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultFavoritePageSize
+	}
+	if limit > maxFavoritePageSize {
+		limit = maxFavoritePageSize
+	}
+
+	page := &FavoritePage{}
+	if query.IncludeTotal {
+		if err := r.filterFavorites(ctx, r.db.WithContext(ctx).Model(&model.Favorite{}), query).Count(&page.Total).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	db := r.filterFavorites(ctx, r.db.WithContext(ctx).Model(&model.Favorite{}), query).
+		Preload("Tags")
+	if query.Cursor != "" {
+		cursor, err := decodeFavoriteCursor(query.Cursor, sorts, filterSignature(query))
+		if err != nil {
+			return nil, err
+		}
+		condition, args, err := keysetCondition(sorts, cursor)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(condition, args...)
+	} else if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+	for _, sort := range sorts {
+		db = db.Order(orderClause(favoriteSortColumns[sort.Field], sort.Desc))
+	}
+	db = db.Order("favorite.id ASC")
+
+	// 多查询一条用于判断是否还有下一页
+	var favorites []*model.Favorite
+	if err := db.Limit(limit + 1).Find(&favorites).Error; err != nil {
+		return nil, err
+	}
+	if len(favorites) > limit {
+		favorites = favorites[:limit]
+		page.NextCursor = encodeFavoriteCursor(sorts, filterSignature(query), favorites[len(favorites)-1])
+	}
+	page.Favorites = favorites
+	return page, nil
+}
+
+// filterFavorites 添加过滤条件，始终关联dictionary表
+func (r *favoriteRepository) filterFavorites(ctx context.Context, db *gorm.DB, query *FavoriteQuery) *gorm.DB {
+	db = db.Joins("Dictionary").Where("favorite.user_id = ?", query.UserID)
+
+	if query.MinMemoryDepth != nil {
+		db = db.Where("favorite.memory_depth >= ?", *query.MinMemoryDepth)
+	}
+	if query.MaxMemoryDepth != nil {
+		db = db.Where("favorite.memory_depth <= ?", *query.MaxMemoryDepth)
+	}
+	if query.LastResult != "" {
+		db = db.Where("favorite.last_result = ?", query.LastResult)
+	}
+	if tag := strings.TrimSpace(query.Tag); tag != "" {
+		db = db.Where("favorite.id IN (?)", r.db.WithContext(ctx).Model(&model.FavoriteTag{}).
+			Select("favorite_id").
+			Where("user_id = ? AND name = ?", query.UserID, tag))
+	}
+	if query.SourceLang != "" {
+		db = db.Where(`"Dictionary".source_lang = ?`, query.SourceLang)
+	}
+	if query.TargetLang != "" {
+		db = db.Where(`"Dictionary".target_lang = ?`, query.TargetLang)
+	}
+	if query.CreatedAfter != nil {
+		db = db.Where("favorite.create_at >= ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		db = db.Where("favorite.create_at < ?", *query.CreatedBefore)
+	}
+	if query.DueAfter != nil {
+		db = db.Where("favorite.due_at >= ?", *query.DueAfter)
+	}
+	if query.DueBefore != nil {
+		db = db.Where("favorite.due_at < ?", *query.DueBefore)
+	}
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		db = db.Where(`("Dictionary".source_text ILIKE ? OR "Dictionary".translated_text ILIKE ?)`, pattern, pattern)
+	}
+	return db
+}
+
+// normalizeFavoriteSorts 去除无效和重复的排序字段，为空时按创建时间倒序
+func normalizeFavoriteSorts(sorts []FavoriteSort) []FavoriteSort {
+	seen := make(map[FavoriteSortField]bool, len(sorts))
+	normalized := make([]FavoriteSort, 0, len(sorts))
+	for _, sort := range sorts {
+		if _, ok := favoriteSortColumns[sort.Field]; !ok || seen[sort.Field] {
+			continue
+		}
+		seen[sort.Field] = true
+		normalized = append(normalized, sort)
+	}
+	if len(normalized) == 0 {
+		normalized = append(normalized, FavoriteSort{Field: FavoriteSortCreatedAt, Desc: true})
+	}
+	return normalized
+}
+
+// sortSignature 排序条件的签名，用于校验游标与查询是否匹配
+func sortSignature(sorts []FavoriteSort) string {
+	parts := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		direction := "asc"
+		if sort.Desc {
+			direction = "desc"
+		}
+		parts = append(parts, string(sort.Field)+":"+direction)
+	}
+	return strings.Join(parts, ",")
+}
+
+// filterSignature 过滤条件的签名，游标只能用于签发时的过滤条件，否则翻页会跳过或重复记录
+func filterSignature(query *FavoriteQuery) string {
+	filter := *query
+	filter.Tag = strings.TrimSpace(filter.Tag)
+	filter.Search = strings.TrimSpace(filter.Search)
+	filter.Sort, filter.Cursor, filter.Limit, filter.Offset, filter.IncludeTotal = nil, "", 0, 0, false
+	data, _ := json.Marshal(filter)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// keysetCondition 构造游标条件：(k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... OR (k1 = v1 AND ... AND id > vid)
+func keysetCondition(sorts []FavoriteSort, cursor *favoriteCursor) (string, []interface{}, error) {
+	values := make([]interface{}, 0, len(sorts))
+	for i, sort := range sorts {
+		value, err := parseSortValue(sort.Field, cursor.Values[i])
+		if err != nil {
+			return "", nil, err
+		}
+		values = append(values, value)
+	}
+
+	var clauses []string
+	var args []interface{}
+	for i := 0; i <= len(sorts); i++ {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, favoriteSortColumns[sorts[j].Field]+" = ?")
+			args = append(args, values[j])
+		}
+		if i < len(sorts) {
+			operator := ">"
+			if sorts[i].Desc {
+				operator = "<"
+			}
+			parts = append(parts, favoriteSortColumns[sorts[i].Field]+" "+operator+" ?")
+			args = append(args, values[i])
+		} else {
+			parts = append(parts, "favorite.id > ?")
+			args = append(args, cursor.ID)
+		}
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args, nil
+}
+
+// encodeFavoriteCursor 根据最后一条记录生成游标
+func encodeFavoriteCursor(sorts []FavoriteSort, filter string, favorite *model.Favorite) string {
+	cursor := favoriteCursor{
+		Sort:   sortSignature(sorts),
+		Filter: filter,
+		Values: make([]string, 0, len(sorts)),
+		ID:     favorite.ID,
+	}
+	for _, sort := range sorts {
+		cursor.Values = append(cursor.Values, sortValue(sort.Field, favorite))
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeFavoriteCursor 解析游标并校验排序和过滤条件
+func decodeFavoriteCursor(token string, sorts []FavoriteSort, filter string) (*favoriteCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor favoriteCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sortSignature(sorts) || cursor.Filter != filter || len(cursor.Values) != len(sorts) || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// sortValue 获取记录在排序字段上的值
+func sortValue(field FavoriteSortField, favorite *model.Favorite) string {
+	switch field {
+	case FavoriteSortCreatedAt:
+		return favorite.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case FavoriteSortUpdatedAt:
+		return favorite.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case FavoriteSortDueAt:
+		return favorite.DueAt.UTC().Format(time.RFC3339Nano)
+	case FavoriteSortMemoryDepth:
+		return fmt.Sprintf("%d", favorite.MemoryDepth)
+	case FavoriteSortSourceText:
+		if favorite.Dictionary != nil {
+			return favorite.Dictionary.SourceText
+		}
+	}
+	return ""
+}
+
+// parseSortValue 将游标中的值还原为排序字段的类型
+func parseSortValue(field FavoriteSortField, value string) (interface{}, error) {
+	switch field {
+	case FavoriteSortCreatedAt, FavoriteSortUpdatedAt, FavoriteSortDueAt:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	case FavoriteSortMemoryDepth:
+		var depth uint64
+		if _, err := fmt.Sscanf(value, "%d", &depth); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return depth, nil
+	default:
+		return value, nil
+	}
+}
+
+// orderClause 生成排序子句
+func orderClause(column string, desc bool) string {
+	if desc {
+		return column + " DESC"
+	}
+	return column + " ASC"
+}
+
+// escapeLike 转义LIKE模式中的特殊字符
+func escapeLike(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/model"
+)
+
+// favoriteIDs 收藏ID列表
+func favoriteIDs(favorites []*model.Favorite) []string {
+	ids := make([]string, 0, len(favorites))
+	for _, favorite := range favorites {
+		ids = append(ids, favorite.ID)
+	}
+	return ids
+}
+
+// listAllPages 按limit逐页查询，返回所有页的收藏ID
+func listAllPages(t *testing.T, repo FavoriteRepository, query FavoriteQuery, limit int) []string {
+	t.Helper()
+	var ids []string
+	query.Limit = limit
+	for page := 0; ; page++ {
+		if page > 100 {
+			t.Fatal("分页没有结束")
+		}
+		result, err := repo.ListFavorites(context.Background(), &query)
+		if err != nil {
+			t.Fatalf("ListFavorites() page %d error = %v", page, err)
+		}
+		if len(result.Favorites) > limit {
+			t.Fatalf("page %d has %d favorites, limit %d", page, len(result.Favorites), limit)
+		}
+		ids = append(ids, favoriteIDs(result.Favorites)...)
+		if result.NextCursor == "" {
+			return ids
+		}
+		query.Cursor = result.NextCursor
+	}
+}
+
+// seedSortFavorites 创建排序测试数据，记忆深度和创建时间都有重复值
+func seedSortFavorites(t *testing.T, repo FavoriteRepository) {
+	t.Helper()
+	db := repo.(*favoriteRepository).db
+	seeds := []struct {
+		id          string
+		memoryDepth uint64
+		created     time.Duration
+	}{
+		{id: "f1", memoryDepth: 2, created: 0},
+		{id: "f2", memoryDepth: 1, created: time.Hour},
+		{id: "f3", memoryDepth: 2, created: time.Hour},
+		{id: "f4", memoryDepth: 1, created: time.Hour},
+		{id: "f5", memoryDepth: 2, created: time.Hour},
+		{id: "f6", memoryDepth: 0, created: 2 * time.Hour},
+	}
+	for _, seed := range seeds {
+		dictionary := createDictionary(t, db, "word-"+seed.id)
+		createFavorite(t, db, &model.Favorite{
+			ID:           seed.id,
+			UserID:       "u1",
+			DictionaryID: dictionary.ID,
+			MemoryDepth:  seed.memoryDepth,
+			Model:        model.Model{CreatedAt: testTime.Add(seed.created)},
+		})
+	}
+	// 其他用户的收藏不应出现在结果中
+	dictionary := createDictionary(t, db, "word-other")
+	createFavorite(t, db, &model.Favorite{ID: "f0", UserID: "u2", DictionaryID: dictionary.ID, MemoryDepth: 2})
+}
+
+func TestListFavoritesSort(t *testing.T) {
+	repo := NewFavoriteRepository(newTestDB(t))
+	seedSortFavorites(t, repo)
+
+	tests := []struct {
+		name string
+		sort []FavoriteSort
+		want []string
+	}{
+		{
+			name: "default is created_at desc with ties by id",
+			want: []string{"f6", "f2", "f3", "f4", "f5", "f1"},
+		},
+		{
+			name: "multi key",
+			sort: []FavoriteSort{{Field: FavoriteSortMemoryDepth, Desc: true}, {Field: FavoriteSortCreatedAt}},
+			want: []string{"f1", "f3", "f5", "f2", "f4", "f6"},
+		},
+		{
+			name: "multi key mixed direction",
+			sort: []FavoriteSort{{Field: FavoriteSortCreatedAt, Desc: true}, {Field: FavoriteSortMemoryDepth}},
+			want: []string{"f6", "f2", "f4", "f3", "f5", "f1"},
+		},
+		{
+			name: "duplicate and unknown fields are ignored",
+			sort: []FavoriteSort{{Field: FavoriteSortMemoryDepth}, {Field: "unknown"}, {Field: FavoriteSortMemoryDepth, Desc: true}},
+			want: []string{"f6", "f2", "f4", "f1", "f3", "f5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.ListFavorites(context.Background(), &FavoriteQuery{UserID: "u1", Sort: tt.sort})
+			if err != nil {
+				t.Fatalf("ListFavorites() error = %v", err)
+			}
+			if got := favoriteIDs(page.Favorites); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ListFavorites() = %v, want %v", got, tt.want)
+			}
+			if page.NextCursor != "" {
+				t.Fatalf("NextCursor = %q, want empty", page.NextCursor)
+			}
+
+			// 任意分页大小逐页查询的结果与一次查询相同，没有重复或遗漏
+			for limit := 1; limit <= len(tt.want); limit++ {
+				if got := listAllPages(t, repo, FavoriteQuery{UserID: "u1", Sort: tt.sort}, limit); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("limit %d: pages = %v, want %v", limit, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListFavoritesSortsMissingDictionaryAsEmptyText(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFavoriteRepository(db)
+	banana := createDictionary(t, db, "banana")
+	apple := createDictionary(t, db, "apple")
+	createFavorite(t, db, &model.Favorite{ID: "f1", UserID: "u1", DictionaryID: banana.ID})
+	createFavorite(t, db, &model.Favorite{ID: "f2", UserID: "u1", DictionaryID: 999999}) // 词典记录不存在
+	createFavorite(t, db, &model.Favorite{ID: "f3", UserID: "u1", DictionaryID: apple.ID})
+	createFavorite(t, db, &model.Favorite{ID: "f4", UserID: "u1", DictionaryID: 999998})
+
+	tests := []struct {
+		name string
+		desc bool
+		want []string
+	}{
+		{name: "asc", want: []string{"f2", "f4", "f3", "f1"}},
+		{name: "desc", desc: true, want: []string{"f1", "f3", "f2", "f4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := FavoriteQuery{UserID: "u1", Sort: []FavoriteSort{{Field: FavoriteSortSourceText, Desc: tt.desc}}}
+			for limit := 1; limit <= len(tt.want); limit++ {
+				if got := listAllPages(t, repo, query, limit); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("limit %d: pages = %v, want %v", limit, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListFavoritesRejectsMismatchedCursor(t *testing.T) {
+	repo := NewFavoriteRepository(newTestDB(t))
+	seedSortFavorites(t, repo)
+	minDepth := uint64(1)
+
+	base := FavoriteQuery{UserID: "u1", Sort: []FavoriteSort{{Field: FavoriteSortMemoryDepth, Desc: true}}, Limit: 2}
+	first, err := repo.ListFavorites(context.Background(), &base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("NextCursor is empty")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(query *FavoriteQuery)
+	}{
+		{name: "different sort field", modify: func(q *FavoriteQuery) { q.Sort = []FavoriteSort{{Field: FavoriteSortCreatedAt, Desc: true}} }},
+		{name: "different sort direction", modify: func(q *FavoriteQuery) { q.Sort = []FavoriteSort{{Field: FavoriteSortMemoryDepth}} }},
+		{name: "additional sort field", modify: func(q *FavoriteQuery) {
+			q.Sort = append(q.Sort, FavoriteSort{Field: FavoriteSortCreatedAt})
+		}},
+		{name: "different filter", modify: func(q *FavoriteQuery) { q.MinMemoryDepth = &minDepth }},
+		{name: "different search", modify: func(q *FavoriteQuery) { q.Search = "word" }},
+		{name: "different user", modify: func(q *FavoriteQuery) { q.UserID = "u2" }},
+		{name: "malformed cursor", modify: func(q *FavoriteQuery) { q.Cursor = "not-a-cursor" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := base
+			query.Sort = append([]FavoriteSort(nil), base.Sort...)
+			query.Cursor = first.NextCursor
+			tt.modify(&query)
+			if _, err := repo.ListFavorites(context.Background(), &query); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("ListFavorites() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	// 分页大小和是否返回总数不属于过滤条件，修改后游标仍然有效
+	query := base
+	query.Cursor = first.NextCursor
+	query.Limit = 3
+	query.IncludeTotal = true
+	if _, err := repo.ListFavorites(context.Background(), &query); err != nil {
+		t.Fatalf("ListFavorites() with different limit error = %v", err)
+	}
+}
+
+func TestListFavoritesLimit(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFavoriteRepository(db)
+	favorites := make([]*model.Favorite, 0, maxFavoritePageSize+5)
+	for i := 0; i < maxFavoritePageSize+5; i++ {
+		favorites = append(favorites, &model.Favorite{
+			ID:           fmt.Sprintf("f%03d", i),
+			UserID:       "u1",
+			DictionaryID: uint64(i + 1),
+			DueAt:        testTime,
+			Model:        model.Model{CreatedAt: testTime.Add(time.Duration(i) * time.Second)},
+		})
+	}
+	if err := db.CreateInBatches(favorites, 50).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		wantSize int
+	}{
+		{name: "default", limit: 0, wantSize: defaultFavoritePageSize},
+		{name: "negative", limit: -1, wantSize: defaultFavoritePageSize},
+		{name: "within cap", limit: 30, wantSize: 30},
+		{name: "capped", limit: maxFavoritePageSize * 10, wantSize: maxFavoritePageSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.ListFavorites(context.Background(), &FavoriteQuery{UserID: "u1", Limit: tt.limit, IncludeTotal: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Favorites) != tt.wantSize {
+				t.Fatalf("len(Favorites) = %d, want %d", len(page.Favorites), tt.wantSize)
+			}
+			if page.NextCursor == "" {
+				t.Fatal("NextCursor is empty, want more pages")
+			}
+			if page.Total != int64(len(favorites)) {
+				t.Fatalf("Total = %d, want %d", page.Total, len(favorites))
+			}
+		})
+	}
+
+	if got := listAllPages(t, repo, FavoriteQuery{UserID: "u1"}, maxFavoritePageSize*10); len(got) != len(favorites) {
+		t.Fatalf("all pages returned %d favorites, want %d", len(got), len(favorites))
+	}
+}
@@ -1,25 +1,70 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
 	"github.com/cheel98/flashcard-backend/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // FavoriteRepository 收藏仓储接口
 type FavoriteRepository interface {
 	// AddFavorite 用户收藏单词
-	AddFavorite(favorite *model.Favorite) error
+	AddFavorite(ctx context.Context, favorite *model.Favorite) error
+	// ListFavorites 按过滤条件查询收藏，支持多字段排序和游标分页
+	ListFavorites(ctx context.Context, query *FavoriteQuery) (*FavoritePage, error)
 	// GetFavoritesByMemoryAsc 按memory升序查询favorite
-	GetFavoritesByMemoryAsc(userID string, limit, offset int) ([]*model.Favorite, error)
-	// GetFavoritesByStudyRecord 按收藏日志查询Favorites
-	GetFavoritesByStudyRecord(userID string, result string, limit, offset int) ([]*model.Favorite, error)
+	GetFavoritesByMemoryAsc(ctx context.Context, userID string, limit, offset int) ([]*model.Favorite, error)
+	// GetFavoritesByStudyRecord 按最近一次学习结果查询Favorites
+	GetFavoritesByStudyRecord(ctx context.Context, userID string, result string, limit, offset int) ([]*model.Favorite, error)
 	// GetFavoritesByMemoryDepth 按记忆深度查询Favorites
-	GetFavoritesByMemoryDepth(userID string, memoryDepth uint64, limit, offset int) ([]*model.Favorite, error)
+	GetFavoritesByMemoryDepth(ctx context.Context, userID string, memoryDepth uint64, limit, offset int) ([]*model.Favorite, error)
 	// AddStudyRecord 添加学习记录
-	AddStudyRecord(record *model.StudyRecord) error
+	AddStudyRecord(ctx context.Context, record *model.StudyRecord) error
+	// GetFavorite 获取收藏详情（包含词典记录和标签）
+	GetFavorite(ctx context.Context, userID, favoriteID string) (*model.Favorite, error)
+	// RemoveFavorite 取消收藏（移入回收站）
+	RemoveFavorite(ctx context.Context, userID, favoriteID string) error
+	// BatchAddFavorites 批量收藏，返回新建的收藏和被跳过的词典ID
+	BatchAddFavorites(ctx context.Context, favorites []*model.Favorite, tags []string) ([]*model.Favorite, []uint64, error)
+	// BatchRemoveFavorites 批量取消收藏（移入回收站），返回删除的数量
+	BatchRemoveFavorites(ctx context.Context, userID string, favoriteIDs []string) (int64, error)
+	// UpdateFavoriteNote 修改收藏的个人笔记和自定义例句
+	UpdateFavoriteNote(ctx context.Context, userID, favoriteID, note, customExample string) (*model.Favorite, error)
+	// AddFavoriteTags 为收藏添加标签，返回收藏上的全部标签
+	AddFavoriteTags(ctx context.Context, userID, favoriteID string, tags []string) ([]string, error)
+	// RemoveFavoriteTags 移除收藏上的标签，返回收藏上的全部标签
+	RemoveFavoriteTags(ctx context.Context, userID, favoriteID string, tags []string) ([]string, error)
+	// MoveFavorites 将收藏从一个标签移动到另一个标签，返回移动的数量
+	MoveFavorites(ctx context.Context, userID string, favoriteIDs []string, fromTag, toTag string) (int64, error)
+	// ListFavoriteTags 查询用户的全部标签及其收藏数量
+	ListFavoriteTags(ctx context.Context, userID string) ([]*TagCount, error)
+	// ListFavoritesByTag 按标签查询收藏
+	ListFavoritesByTag(ctx context.Context, userID, tag string, limit, offset int) ([]*model.Favorite, error)
+	// ListTrashedFavorites 查询回收站中的收藏
+	ListTrashedFavorites(ctx context.Context, userID string, limit, offset int) ([]*model.Favorite, error)
+	// ListTrashedStudyRecords 查询回收站中的学习记录
+	ListTrashedStudyRecords(ctx context.Context, userID string, limit, offset int) ([]*model.StudyRecord, error)
+	// RestoreFavorites 从回收站恢复收藏，favoriteIDs为空时恢复全部，返回恢复的数量
+	RestoreFavorites(ctx context.Context, userID string, favoriteIDs []string) (int64, error)
+	// EmptyTrash 彻底删除回收站中的收藏，返回删除的数量
+	EmptyTrash(ctx context.Context, userID string) (int64, error)
+}
+
+// TagCount 标签及其收藏数量
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
 }
 
+// ErrFavoriteNotFound 收藏记录不存在
+var ErrFavoriteNotFound = errorsvar.ErrFavoriteNotFound
+
 // favoriteRepository 收藏仓储实现
 type favoriteRepository struct {
 	db *gorm.DB
@@ -33,79 +78,553 @@ func NewFavoriteRepository(db *gorm.DB) FavoriteRepository {
 }
 
 // AddFavorite 用户收藏单词
-func (r *favoriteRepository) AddFavorite(favorite *model.Favorite) error {
+func (r *favoriteRepository) AddFavorite(ctx context.Context, favorite *model.Favorite) error {
 	// 检查是否已经收藏
 	var existingFavorite model.Favorite
-	err := r.db.Where("user_id = ? AND dictionary_id = ?", favorite.UserID, favorite.DictionaryID).First(&existingFavorite).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND dictionary_id = ?", favorite.UserID, favorite.DictionaryID).First(&existingFavorite).Error
+	if err == nil {
+		return errorsvar.ErrFavoriteExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 回收站中存在相同的收藏时直接恢复
+	err = r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND dictionary_id = ? AND deleted_at IS NOT NULL", favorite.UserID, favorite.DictionaryID).
+		First(&existingFavorite).Error
 	if err == nil {
-		return errors.New("该单词已经收藏")
+		if _, err := r.RestoreFavorites(ctx, favorite.UserID, []string{existingFavorite.ID}); err != nil {
+			return err
+		}
+		restored, err := r.GetFavorite(ctx, favorite.UserID, existingFavorite.ID)
+		if err != nil {
+			return err
+		}
+		*favorite = *restored
+		return nil
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 
-	// 创建新的收藏记录
-	err = r.db.Create(favorite).Error
+	// 创建新的收藏记录，并发请求已经创建时由唯一索引拒绝
+	err = r.db.WithContext(ctx).Create(favorite).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errorsvar.ErrFavoriteExists
+		}
 		return err
 	}
 	return nil
 }
 
-// GetFavoritesByMemoryAsc 按memory升序查询favorite（关联查询dictionary表）
-func (r *favoriteRepository) GetFavoritesByMemoryAsc(userID string, limit, offset int) ([]*model.Favorite, error) {
-	var favorites []*model.Favorite
-	err := r.db.Preload("DictionaryEnglishUS"). // 关联查询dictionary表
-							Where("user_id = ?", userID).
-							Order("memory_depth ASC").
-							Limit(limit).
-							Offset(offset).
-							Find(&favorites).Error
+// GetFavoritesByMemoryAsc 按memory升序查询favorite（关联查询dictionary表），limit为0时取20，最大100
+func (r *favoriteRepository) GetFavoritesByMemoryAsc(ctx context.Context, userID string, limit, offset int) ([]*model.Favorite, error) {
+	page, err := r.ListFavorites(ctx, &FavoriteQuery{
+		UserID: userID,
+		Sort:   []FavoriteSort{{Field: FavoriteSortMemoryDepth}},
+		Limit:  limit,
+		Offset: offset,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return favorites, nil
+	return page.Favorites, nil
 }
 
-// GetFavoritesByStudyRecord 按收藏日志查询Favorites（关联查询dictionary表）
-func (r *favoriteRepository) GetFavoritesByStudyRecord(userID string, result string, limit, offset int) ([]*model.Favorite, error) {
-	var favorites []*model.Favorite
+// GetFavoritesByStudyRecord 按最近一次学习结果查询Favorites（关联查询dictionary表），limit为0时取20，最大100
+func (r *favoriteRepository) GetFavoritesByStudyRecord(ctx context.Context, userID string, result string, limit, offset int) ([]*model.Favorite, error) {
+	page, err := r.ListFavorites(ctx, &FavoriteQuery{
+		UserID:     userID,
+		LastResult: result,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Favorites, nil
+}
+
+// GetFavoritesByMemoryDepth 按记忆深度查询Favorites（关联查询dictionary表），limit为0时取20，最大100
+func (r *favoriteRepository) GetFavoritesByMemoryDepth(ctx context.Context, userID string, memoryDepth uint64, limit, offset int) ([]*model.Favorite, error) {
+	page, err := r.ListFavorites(ctx, &FavoriteQuery{
+		UserID:         userID,
+		MinMemoryDepth: &memoryDepth,
+		MaxMemoryDepth: &memoryDepth,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Favorites, nil
+}
+
+// AddStudyRecord 添加学习记录，关联收藏时同步更新最近学习结果、记忆深度和下次复习时间
+func (r *favoriteRepository) AddStudyRecord(ctx context.Context, record *model.StudyRecord) error {
+	if record.FavoriteID == "" {
+		return r.db.WithContext(ctx).Create(record).Error
+	}
 
-	// 先查询符合条件的StudyRecord，然后关联查询Favorite和Dictionary
-	subQuery := r.db.Model(&model.StudyRecord{}).Select("id").Where("result = ?", result)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var favorite model.Favorite
+		err := tx.Where("id = ?", record.FavoriteID).First(&favorite).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFavoriteNotFound
+			}
+			return err
+		}
 
-	err := r.db.Preload("DictionaryEnglishUS"). // 关联查询dictionary表
-							Preload("FavoriteRecords", "result = ?", result). // 预加载符合条件的学习记录
-							Where("user_id = ? AND id IN (?)", userID, subQuery).
-							Limit(limit).
-							Offset(offset).
-							Find(&favorites).Error
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&favorite).Updates(map[string]interface{}{
+			"last_result":  record.Result,
+			"due_at":       model.NextDueAt(favorite.MemoryDepth, record.Result, record.CreatedAt),
+			"memory_depth": model.NextMemoryDepth(favorite.MemoryDepth, record.Result),
+		}).Error
+	})
+}
+
+// GetFavorite 获取收藏详情（包含词典记录和标签）
+func (r *favoriteRepository) GetFavorite(ctx context.Context, userID, favoriteID string) (*model.Favorite, error) {
+	var favorite model.Favorite
+	err := r.db.WithContext(ctx).Preload("Dictionary").
+		Preload("Tags").
+		Where("user_id = ? AND id = ?", userID, favoriteID).
+		First(&favorite).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFavoriteNotFound
+		}
 		return nil, err
 	}
-	return favorites, nil
+	return &favorite, nil
+}
+
+// RemoveFavorite 取消收藏（移入回收站）
+func (r *favoriteRepository) RemoveFavorite(ctx context.Context, userID, favoriteID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		removed, err := softDeleteFavorites(tx, userID, []string{favoriteID})
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return ErrFavoriteNotFound
+		}
+		return nil
+	})
+}
+
+// BatchAddFavorites 批量收藏，已收藏的词典记录会被跳过
+func (r *favoriteRepository) BatchAddFavorites(ctx context.Context, favorites []*model.Favorite, tags []string) ([]*model.Favorite, []uint64, error) {
+	if len(favorites) == 0 {
+		return nil, nil, nil
+	}
+	userID := favorites[0].UserID
+	tags = normalizeTags(tags)
+
+	var created []*model.Favorite
+	var skipped []uint64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dictionaryIDs := make([]uint64, 0, len(favorites))
+		for _, favorite := range favorites {
+			dictionaryIDs = append(dictionaryIDs, favorite.DictionaryID)
+		}
+
+		// 先恢复回收站中相同的收藏，恢复后按已收藏处理
+		trashed := tx.Unscoped().Model(&model.Favorite{}).
+			Select("id").
+			Where("user_id = ? AND dictionary_id IN ? AND deleted_at IS NOT NULL", userID, dictionaryIDs)
+		if _, err := restoreFavorites(tx, trashed); err != nil {
+			return err
+		}
+
+		// 查询已经收藏的词典记录
+		var existingIDs []uint64
+		err := tx.Model(&model.Favorite{}).
+			Where("user_id = ? AND dictionary_id IN ?", userID, dictionaryIDs).
+			Pluck("dictionary_id", &existingIDs).Error
+		if err != nil {
+			return err
+		}
+		existing := make(map[uint64]bool, len(existingIDs))
+		for _, id := range existingIDs {
+			existing[id] = true
+		}
+
+		for _, favorite := range favorites {
+			if existing[favorite.DictionaryID] {
+				skipped = append(skipped, favorite.DictionaryID)
+				continue
+			}
+			existing[favorite.DictionaryID] = true
+			created = append(created, favorite)
+		}
+		if len(created) == 0 {
+			return nil
+		}
+		if err := tx.Create(&created).Error; err != nil {
+			return err
+		}
+
+		if len(tags) == 0 {
+			return nil
+		}
+		var favoriteTags []*model.FavoriteTag
+		for _, favorite := range created {
+			for _, tag := range tags {
+				favoriteTag := &model.FavoriteTag{FavoriteID: favorite.ID, UserID: userID, Name: tag}
+				favoriteTags = append(favoriteTags, favoriteTag)
+				favorite.Tags = append(favorite.Tags, *favoriteTag)
+			}
+		}
+		return tx.Create(&favoriteTags).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, skipped, nil
+}
+
+// BatchRemoveFavorites 批量取消收藏
+func (r *favoriteRepository) BatchRemoveFavorites(ctx context.Context, userID string, favoriteIDs []string) (int64, error) {
+	if len(favoriteIDs) == 0 {
+		return 0, nil
+	}
+	var removed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		removed, err = softDeleteFavorites(tx, userID, favoriteIDs)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// UpdateFavoriteNote 修改收藏的个人笔记和自定义例句
+func (r *favoriteRepository) UpdateFavoriteNote(ctx context.Context, userID, favoriteID, note, customExample string) (*model.Favorite, error) {
+	result := r.db.WithContext(ctx).Model(&model.Favorite{}).
+		Where("user_id = ? AND id = ?", userID, favoriteID).
+		Updates(map[string]interface{}{
+			"note":           note,
+			"custom_example": customExample,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrFavoriteNotFound
+	}
+	return r.GetFavorite(ctx, userID, favoriteID)
+}
+
+// AddFavoriteTags 为收藏添加标签
+func (r *favoriteRepository) AddFavoriteTags(ctx context.Context, userID, favoriteID string, tags []string) ([]string, error) {
+	if err := r.ensureFavoriteOwner(ctx, userID, favoriteID); err != nil {
+		return nil, err
+	}
+
+	tags = normalizeTags(tags)
+	if len(tags) > 0 {
+		favoriteTags := make([]*model.FavoriteTag, 0, len(tags))
+		for _, tag := range tags {
+			favoriteTags = append(favoriteTags, &model.FavoriteTag{FavoriteID: favoriteID, UserID: userID, Name: tag})
+		}
+		// 已存在的标签直接忽略
+		err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&favoriteTags).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r.getFavoriteTagNames(ctx, favoriteID)
+}
+
+// RemoveFavoriteTags 移除收藏上的标签
+func (r *favoriteRepository) RemoveFavoriteTags(ctx context.Context, userID, favoriteID string, tags []string) ([]string, error) {
+	if err := r.ensureFavoriteOwner(ctx, userID, favoriteID); err != nil {
+		return nil, err
+	}
+
+	tags = normalizeTags(tags)
+	if len(tags) > 0 {
+		err := r.db.WithContext(ctx).Unscoped().Where("favorite_id = ? AND name IN ?", favoriteID, tags).Delete(&model.FavoriteTag{}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r.getFavoriteTagNames(ctx, favoriteID)
+}
+
+// MoveFavorites 将收藏从一个标签移动到另一个标签，fromTag为空时只添加新标签
+func (r *favoriteRepository) MoveFavorites(ctx context.Context, userID string, favoriteIDs []string, fromTag, toTag string) (int64, error) {
+	fromTag = strings.TrimSpace(fromTag)
+	toTag = strings.TrimSpace(toTag)
+	if len(favoriteIDs) == 0 || fromTag == toTag {
+		return 0, nil
+	}
+
+	var moved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 只移动属于该用户的收藏
+		var ownedIDs []string
+		query := tx.Model(&model.Favorite{}).Where("user_id = ? AND id IN ?", userID, favoriteIDs)
+		if fromTag != "" {
+			query = query.Where("id IN (?)", tx.Model(&model.FavoriteTag{}).
+				Select("favorite_id").
+				Where("user_id = ? AND name = ?", userID, fromTag))
+		}
+		if err := query.Pluck("id", &ownedIDs).Error; err != nil {
+			return err
+		}
+		if len(ownedIDs) == 0 {
+			return nil
+		}
+
+		if fromTag != "" {
+			err := tx.Unscoped().Where("favorite_id IN ? AND name = ?", ownedIDs, fromTag).Delete(&model.FavoriteTag{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		favoriteTags := make([]*model.FavoriteTag, 0, len(ownedIDs))
+		for _, id := range ownedIDs {
+			favoriteTags = append(favoriteTags, &model.FavoriteTag{FavoriteID: id, UserID: userID, Name: toTag})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&favoriteTags).Error; err != nil {
+			return err
+		}
+		moved = int64(len(ownedIDs))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// ListFavoriteTags 查询用户的全部标签及其收藏数量
+func (r *favoriteRepository) ListFavoriteTags(ctx context.Context, userID string) ([]*TagCount, error) {
+	var tags []*TagCount
+	err := r.db.WithContext(ctx).Model(&model.FavoriteTag{}).
+		Select("name, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Where("favorite_id IN (?)", r.db.WithContext(ctx).Model(&model.Favorite{}).Select("id").Where("user_id = ?", userID)).
+		Group("name").
+		Order("name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListFavoritesByTag 按标签查询收藏（关联查询dictionary表）
+func (r *favoriteRepository) ListFavoritesByTag(ctx context.Context, userID, tag string, limit, offset int) ([]*model.Favorite, error) {
+	page, err := r.ListFavorites(ctx, &FavoriteQuery{
+		UserID: userID,
+		Tag:    tag,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Favorites, nil
 }
 
-// GetFavoritesByMemoryDepth 按记忆深度查询Favorites（关联查询dictionary表）
-func (r *favoriteRepository) GetFavoritesByMemoryDepth(userID string, memoryDepth uint64, limit, offset int) ([]*model.Favorite, error) {
+// ListTrashedFavorites 查询回收站中的收藏，按删除时间倒序
+func (r *favoriteRepository) ListTrashedFavorites(ctx context.Context, userID string, limit, offset int) ([]*model.Favorite, error) {
 	var favorites []*model.Favorite
-	err := r.db.Preload("DictionaryEnglishUS"). // 关联查询dictionary表
-							Where("user_id = ? AND memory_depth = ?", userID, memoryDepth).
-							Order("created_at DESC").
-							Limit(limit).
-							Offset(offset).
-							Find(&favorites).Error
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Dictionary").
+		Preload("Tags").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&favorites).Error
 	if err != nil {
 		return nil, err
 	}
 	return favorites, nil
 }
 
-// AddStudyRecord 添加学习记录
-func (r *favoriteRepository) AddStudyRecord(record *model.StudyRecord) error {
-	err := r.db.Create(record).Error
+// ListTrashedStudyRecords 查询用户回收站中的学习记录，按删除时间倒序。
+// 收藏被删除时学习记录随之移入回收站，因此关联收藏时不过滤收藏的删除状态
+func (r *favoriteRepository) ListTrashedStudyRecords(ctx context.Context, userID string, limit, offset int) ([]*model.StudyRecord, error) {
+	var records []*model.StudyRecord
+	err := r.db.WithContext(ctx).Unscoped().
+		Select("study_record.*").
+		Joins("JOIN favorite ON favorite.id = study_record.favorite_id").
+		Where("favorite.user_id = ? AND study_record.deleted_at IS NOT NULL", userID).
+		Order("study_record.deleted_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&records).Error
 	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// RestoreFavorites 从回收站恢复收藏，已有相同词典的有效收藏时跳过
+func (r *favoriteRepository) RestoreFavorites(ctx context.Context, userID string, favoriteIDs []string) (int64, error) {
+	var restored int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		trashed := tx.Unscoped().Model(&model.Favorite{}).
+			Select("id").
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+		if len(favoriteIDs) > 0 {
+			trashed = trashed.Where("id IN ?", favoriteIDs)
+		}
+
+		var err error
+		restored, err = restoreFavorites(tx, trashed)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return restored, nil
+}
+
+// EmptyTrash 彻底删除回收站中的收藏及其学习记录和标签
+func (r *favoriteRepository) EmptyTrash(ctx context.Context, userID string) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		trashed := tx.Unscoped().Model(&model.Favorite{}).
+			Select("id").
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+
+		var err error
+		purged, err = purgeFavorites(tx, trashed)
 		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// softDeleteFavorites 将收藏及其学习记录以相同的删除时间移入回收站，标签保留以便恢复
+func softDeleteFavorites(tx *gorm.DB, userID string, favoriteIDs []string) (int64, error) {
+	now := time.Now()
+	owned := tx.Model(&model.Favorite{}).Select("id").Where("user_id = ? AND id IN ?", userID, favoriteIDs)
+	err := tx.Model(&model.StudyRecord{}).
+		Where("favorite_id IN (?)", owned).
+		Update("deleted_at", now).Error
+	if err != nil {
+		return 0, err
+	}
+
+	result := tx.Model(&model.Favorite{}).
+		Where("user_id = ? AND id IN ?", userID, favoriteIDs).
+		Update("deleted_at", now)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// restoreFavorites 恢复trashed子查询选中的收藏，以及与收藏同时删除的学习记录
+func restoreFavorites(tx *gorm.DB, trashed *gorm.DB) (int64, error) {
+	// 已有相同词典的有效收藏时不恢复，回收站中有多条相同词典的收藏时只恢复最后删除的一条，
+	// 避免违反idx_favorite_user_dictionary唯一索引
+	restorable := trashed.Where(`NOT EXISTS (SELECT 1 FROM favorite AS active
+		WHERE active.user_id = favorite.user_id
+		AND active.dictionary_id = favorite.dictionary_id
+		AND active.deleted_at IS NULL)`).
+		Where(`NOT EXISTS (SELECT 1 FROM favorite AS newer
+		WHERE newer.user_id = favorite.user_id
+		AND newer.dictionary_id = favorite.dictionary_id
+		AND newer.deleted_at IS NOT NULL
+		AND (newer.deleted_at, newer.id) > (favorite.deleted_at, favorite.id))`)
+
+	err := tx.Exec(`UPDATE study_record SET deleted_at = NULL
+		FROM favorite
+		WHERE study_record.favorite_id = favorite.id
+		AND study_record.deleted_at = favorite.deleted_at
+		AND favorite.id IN (?)`, restorable).Error
+	if err != nil {
+		return 0, err
+	}
+
+	result := tx.Unscoped().Model(&model.Favorite{}).
+		Where("id IN (?)", restorable).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// purgeFavorites 彻底删除selected子查询选中的收藏及其学习记录和标签
+func purgeFavorites(tx *gorm.DB, selected *gorm.DB) (int64, error) {
+	var ids []string
+	if err := selected.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := tx.Unscoped().Where("favorite_id IN ?", ids).Delete(&model.FavoriteTag{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Unscoped().Where("favorite_id IN ?", ids).Delete(&model.StudyRecord{}).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Favorite{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// ensureFavoriteOwner 检查收藏是否属于该用户
+func (r *favoriteRepository) ensureFavoriteOwner(ctx context.Context, userID, favoriteID string) error {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Favorite{}).Where("user_id = ? AND id = ?", userID, favoriteID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrFavoriteNotFound
 	}
 	return nil
 }
+
+// getFavoriteTagNames 查询收藏上的全部标签名称
+func (r *favoriteRepository) getFavoriteTagNames(ctx context.Context, favoriteID string) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&model.FavoriteTag{}).
+		Where("favorite_id = ?", favoriteID).
+		Order("name ASC").
+		Pluck("name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// normalizeTags 去除标签两端空白、空标签和重复标签
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// deletedAt 返回指定时间的软删除标记
+func deletedAt(at time.Time) gorm.DeletedAt {
+	return gorm.DeletedAt{Time: at, Valid: true}
+}
+
+// createStudyRecord 直接写入学习记录
+func createStudyRecord(t *testing.T, db *gorm.DB, record *model.StudyRecord) {
+	t.Helper()
+	if record.Result == "" {
+		record.Result = model.StudyResultRemembered
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = testTime
+	}
+	if err := db.Create(record).Error; err != nil {
+		t.Fatalf("创建学习记录失败: %v", err)
+	}
+}
+
+// assertTrashed 检查记录是否处于软删除状态，记录必须存在
+func assertTrashed(t *testing.T, db *gorm.DB, value interface{}, id string, want bool) {
+	t.Helper()
+	var deleted sql.NullTime
+	err := db.Unscoped().Model(value).Select("deleted_at").Where("id = ?", id).Row().Scan(&deleted)
+	if err != nil {
+		t.Fatalf("查询%s失败: %v", id, err)
+	}
+	if deleted.Valid != want {
+		t.Fatalf("%s trashed = %v, want %v", id, deleted.Valid, want)
+	}
+}
+
+func TestRestoreFavoritesSkipsWordWithActiveFavorite(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFavoriteRepository(db)
+
+	duplicated := createDictionary(t, db, "apple")
+	other := createDictionary(t, db, "banana")
+	createFavorite(t, db, &model.Favorite{ID: "trashed", UserID: "u1", DictionaryID: duplicated.ID, Model: model.Model{DeletedAt: deletedAt(testTime)}})
+	createFavorite(t, db, &model.Favorite{ID: "active", UserID: "u1", DictionaryID: duplicated.ID})
+	createFavorite(t, db, &model.Favorite{ID: "other", UserID: "u1", DictionaryID: other.ID, Model: model.Model{DeletedAt: deletedAt(testTime)}})
+
+	tests := []struct {
+		name        string
+		favoriteIDs []string
+		want        int64
+	}{
+		{name: "explicit id", favoriteIDs: []string{"trashed"}, want: 0},
+		{name: "whole trash", favoriteIDs: nil, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restored, err := repo.RestoreFavorites(context.Background(), "u1", tt.favoriteIDs)
+			if err != nil {
+				t.Fatalf("RestoreFavorites() error = %v", err)
+			}
+			if restored != tt.want {
+				t.Fatalf("RestoreFavorites() = %d, want %d", restored, tt.want)
+			}
+		})
+	}
+
+	assertTrashed(t, db, &model.Favorite{}, "trashed", true)
+	assertTrashed(t, db, &model.Favorite{}, "active", false)
+	assertTrashed(t, db, &model.Favorite{}, "other", false)
+}
+
+func TestRestoreFavoritesRestoresNewestPerWord(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFavoriteRepository(db)
+
+	dictionary := createDictionary(t, db, "apple")
+	older := testTime.Add(time.Hour)
+	newer := testTime.Add(2 * time.Hour)
+	createFavorite(t, db, &model.Favorite{ID: "older", UserID: "u1", DictionaryID: dictionary.ID, Model: model.Model{DeletedAt: deletedAt(older)}})
+	createFavorite(t, db, &model.Favorite{ID: "newer", UserID: "u1", DictionaryID: dictionary.ID, Model: model.Model{DeletedAt: deletedAt(newer)}})
+	// 与收藏同时删除的学习记录随收藏恢复，之前单独删除的保留在回收站
+	createStudyRecord(t, db, &model.StudyRecord{ID: "with-favorite", FavoriteID: "newer", Model: model.Model{DeletedAt: deletedAt(newer)}})
+	createStudyRecord(t, db, &model.StudyRecord{ID: "alone", FavoriteID: "newer", Model: model.Model{DeletedAt: deletedAt(testTime)}})
+	createStudyRecord(t, db, &model.StudyRecord{ID: "older-record", FavoriteID: "older", Model: model.Model{DeletedAt: deletedAt(older)}})
+
+	restored, err := repo.RestoreFavorites(context.Background(), "u1", []string{"older", "newer"})
+	if err != nil {
+		t.Fatalf("RestoreFavorites() error = %v", err)
+	}
+	if restored != 1 {
+		t.Fatalf("RestoreFavorites() = %d, want 1", restored)
+	}
+	assertTrashed(t, db, &model.Favorite{}, "newer", false)
+	assertTrashed(t, db, &model.Favorite{}, "older", true)
+	assertTrashed(t, db, &model.StudyRecord{}, "with-favorite", false)
+	assertTrashed(t, db, &model.StudyRecord{}, "alone", true)
+	assertTrashed(t, db, &model.StudyRecord{}, "older-record", true)
+
+	// 再次恢复时已有有效收藏，不能违反idx_favorite_user_dictionary唯一索引
+	restored, err = repo.RestoreFavorites(context.Background(), "u1", nil)
+	if err != nil {
+		t.Fatalf("RestoreFavorites() error = %v", err)
+	}
+	if restored != 0 {
+		t.Fatalf("RestoreFavorites() = %d, want 0", restored)
+	}
+	assertTrashed(t, db, &model.Favorite{}, "older", true)
+}
+
+func TestAddStudyRecordUpdatesMemoryDepth(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewFavoriteRepository(db)
+	dictionary := createDictionary(t, db, "apple")
+	createFavorite(t, db, &model.Favorite{ID: "f1", UserID: "u1", DictionaryID: dictionary.ID})
+
+	const day = 24 * time.Hour
+	steps := []struct {
+		result    string
+		wantDepth uint64
+		wantDue   time.Duration
+	}{
+		{result: model.StudyResultRemembered, wantDepth: 1, wantDue: day},
+		{result: model.StudyResultRemembered, wantDepth: 2, wantDue: 2 * day},
+		{result: model.StudyResultRemembered, wantDepth: 3, wantDue: 4 * day},
+		{result: model.StudyResultFuzzy, wantDepth: 0, wantDue: day},
+		{result: model.StudyResultRemembered, wantDepth: 1, wantDue: day},
+		{result: model.StudyResultStrange, wantDepth: 0, wantDue: 0},
+	}
+	for i, step := range steps {
+		studiedAt := testTime.Add(time.Duration(i) * time.Hour)
+		record := &model.StudyRecord{ID: fmt.Sprintf("r%d", i), FavoriteID: "f1", Result: step.result, Model: model.Model{CreatedAt: studiedAt}}
+		if err := repo.AddStudyRecord(context.Background(), record); err != nil {
+			t.Fatalf("step %d: AddStudyRecord() error = %v", i, err)
+		}
+
+		var favorite model.Favorite
+		if err := db.Where("id = ?", "f1").First(&favorite).Error; err != nil {
+			t.Fatal(err)
+		}
+		if favorite.MemoryDepth != step.wantDepth || favorite.LastResult != step.result {
+			t.Fatalf("step %d: memory depth = %d, last result = %q, want %d, %q", i, favorite.MemoryDepth, favorite.LastResult, step.wantDepth, step.result)
+		}
+		if due := favorite.DueAt.Sub(studiedAt); due != step.wantDue {
+			t.Fatalf("step %d: due in %v, want %v", i, due, step.wantDue)
+		}
+	}
+}
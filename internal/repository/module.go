@@ -9,4 +9,5 @@ var Module = fx.Options(
 	fx.Provide(NewDictionaryRepository),
 	fx.Provide(NewUserRepository),
 	fx.Provide(NewFavoriteRepository),
+	fx.Provide(NewTrashRepository),
 )
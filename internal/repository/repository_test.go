@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/database"
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDatabaseDSNEnv 仓储测试使用的PostgreSQL连接串，例如
+// host=localhost user=postgres password=postgres dbname=flashcard_test port=5432 sslmode=disable
+const testDatabaseDSNEnv = "TEST_DATABASE_DSN"
+
+// newTestDB 在独立的schema中执行全部迁移并返回连接，测试结束后删除该schema。
+// 未设置TEST_DATABASE_DSN时跳过测试
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s未设置，跳过数据库测试", testDatabaseDSNEnv)
+	}
+	gormConfig := &gorm.Config{TranslateError: true, Logger: logger.Default.LogMode(logger.Silent)}
+
+	admin, err := gorm.Open(postgres.Open(dsn), gormConfig)
+	if err != nil {
+		t.Fatalf("连接测试数据库失败: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := admin.Exec(fmt.Sprintf(`CREATE SCHEMA %q`, schema)).Error; err != nil {
+		t.Fatalf("创建测试schema失败: %v", err)
+	}
+
+	// public保留在search_path中，pg_trgm等扩展已安装在public时仍然可用
+	db, err := gorm.Open(postgres.Open(dsn+fmt.Sprintf(" search_path=%s,public", schema)), gormConfig)
+	if err != nil {
+		t.Fatalf("连接测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec(fmt.Sprintf(`DROP SCHEMA %q CASCADE`, schema))
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := database.NewMigrator(db, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return db
+}
+
+// testTime 测试数据使用的基准时间，精度与数据库的timestamptz一致
+var testTime = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// createDictionary 创建词典记录
+func createDictionary(t *testing.T, db *gorm.DB, sourceText string) *model.Dictionary {
+	t.Helper()
+	dictionary := &model.Dictionary{SourceLang: "en", TargetLang: "zh", SourceText: sourceText, TranslatedText: sourceText + "-zh"}
+	if err := db.Create(dictionary).Error; err != nil {
+		t.Fatalf("创建词典记录失败: %v", err)
+	}
+	return dictionary
+}
+
+// createFavorite 直接写入收藏记录，不经过仓储的重复检查
+func createFavorite(t *testing.T, db *gorm.DB, favorite *model.Favorite) *model.Favorite {
+	t.Helper()
+	if favorite.CreatedAt.IsZero() {
+		favorite.CreatedAt = testTime
+	}
+	if favorite.DueAt.IsZero() {
+		favorite.DueAt = favorite.CreatedAt
+	}
+	if err := db.Create(favorite).Error; err != nil {
+		t.Fatalf("创建收藏失败: %v", err)
+	}
+	return favorite
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// TrashRepository 回收站仓储接口，负责彻底删除过期的软删除记录
+type TrashRepository interface {
+	// PurgeDeletedBefore 彻底删除在before之前被软删除的记录
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (*PurgeResult, error)
+}
+
+// PurgeResult 清理结果
+type PurgeResult struct {
+	Users        int64 `json:"users"`
+	Favorites    int64 `json:"favorites"`
+	StudyRecords int64 `json:"study_records"`
+	Dictionaries int64 `json:"dictionaries"`
+}
+
+// zeroDeletedAt 软删除之前的记录deleted_at为零值0001-01-01，它们没有被删除，清理时必须排除
+const zeroDeletedAt = "0001-01-02"
+
+// trashRepository 回收站仓储实现
+type trashRepository struct {
+	db *gorm.DB
+}
+
+// NewTrashRepository 创建回收站仓储实例
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{
+		db: db,
+	}
+}
+
+// PurgeDeletedBefore 彻底删除过期的软删除记录，每类记录使用独立的事务
+func (r *trashRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (*PurgeResult, error) {
+	result := &PurgeResult{}
+
+	// 用户：连同其全部收藏、设置和日志一起删除，有充值记录的用户保留
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var userIDs []string
+		err := tx.Unscoped().Model(&model.User{}).
+			Where("deleted_at > ? AND deleted_at < ?", zeroDeletedAt, before).
+			Where("NOT EXISTS (SELECT 1 FROM payment_records WHERE payment_records.user_id = \"user\".id)").
+			Pluck("id", &userIDs).Error
+		if err != nil || len(userIDs) == 0 {
+			return err
+		}
+
+		favorites := tx.Unscoped().Model(&model.Favorite{}).Select("id").Where("user_id IN ?", userIDs)
+		if _, err := purgeFavorites(tx, favorites); err != nil {
+			return err
+		}
+		for _, related := range []interface{}{&model.UserSettings{}, &model.UserPreferences{}, &model.UserLogs{}} {
+			if err := tx.Unscoped().Where("user_id IN ?", userIDs).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		deleted := tx.Unscoped().Where("id IN ?", userIDs).Delete(&model.User{})
+		result.Users = deleted.RowsAffected
+		return deleted.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 收藏：连同其学习记录和标签一起删除
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		favorites := tx.Unscoped().Model(&model.Favorite{}).Select("id").Where("deleted_at > ? AND deleted_at < ?", zeroDeletedAt, before)
+		purged, err := purgeFavorites(tx, favorites)
+		result.Favorites = purged
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 单独删除的学习记录
+	deleted := r.db.WithContext(ctx).Unscoped().Where("deleted_at > ? AND deleted_at < ?", zeroDeletedAt, before).Delete(&model.StudyRecord{})
+	if deleted.Error != nil {
+		return nil, deleted.Error
+	}
+	result.StudyRecords = deleted.RowsAffected
+
+	// 词典：仍被收藏引用的记录保留
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var dictionaryIDs []uint64
+		err := tx.Unscoped().Model(&model.Dictionary{}).
+			Where("deleted_at > ? AND deleted_at < ?", zeroDeletedAt, before).
+			Where("NOT EXISTS (SELECT 1 FROM favorite WHERE favorite.dictionary_id = dictionary.id)").
+			Pluck("id", &dictionaryIDs).Error
+		if err != nil || len(dictionaryIDs) == 0 {
+			return err
+		}
+
+		for _, related := range []interface{}{&model.DictionaryAudio{}, &model.DictionaryMetadata{}} {
+			if err := tx.Unscoped().Where("dictionary_id IN ?", dictionaryIDs).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		deleted := tx.Unscoped().Where("id IN ?", dictionaryIDs).Delete(&model.Dictionary{})
+		result.Dictionaries = deleted.RowsAffected
+		return deleted.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"gorm.io/gorm"
+)
+
+// countUnscoped 统计包括软删除记录在内的行数
+func countUnscoped(t *testing.T, db *gorm.DB, value interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Unscoped().Model(value).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatalf("统计记录失败: %v", err)
+	}
+	return count
+}
+
+func TestPurgeDeletedBeforeSkipsZeroDeletedAt(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewTrashRepository(db)
+
+	// 000004之前写入的未删除记录deleted_at是零值，迁移之后仍可能由旧版本写入
+	statements := []string{
+		`INSERT INTO "user" (id, name, email, phone, deleted_at) VALUES ('legacy-user', 'legacy', 'legacy@example.com', '13800000000', '0001-01-01 00:00:00+00')`,
+		`INSERT INTO dictionary (source_lang, target_lang, source_text, translated_text, deleted_at) VALUES ('en', 'zh', 'legacy', '遗留', '0001-01-01 00:00:00+00')`,
+		`INSERT INTO favorite (id, user_id, dictionary_id, deleted_at) VALUES ('legacy-favorite', 'legacy-user', 0, '0001-01-01 00:00:00+00')`,
+		`INSERT INTO study_record (id, favorite_id, result, deleted_at) VALUES ('legacy-record', 'legacy-favorite', 'remembered', '0001-01-01 00:00:00+00')`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("写入零值记录失败: %v", err)
+		}
+	}
+
+	result, err := repo.PurgeDeletedBefore(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("PurgeDeletedBefore() error = %v", err)
+	}
+	if want := (&PurgeResult{}); !reflect.DeepEqual(result, want) {
+		t.Fatalf("PurgeDeletedBefore() = %+v, want %+v", result, want)
+	}
+
+	for _, value := range []interface{}{&model.User{}, &model.Dictionary{}, &model.Favorite{}, &model.StudyRecord{}} {
+		if count := countUnscoped(t, db, value, "deleted_at IS NOT NULL"); count != 1 {
+			t.Fatalf("%T count = %d, want 1", value, count)
+		}
+	}
+}
+
+func TestPurgeDeletedBeforeRetention(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewTrashRepository(db)
+
+	now := testTime
+	expired := deletedAt(now.Add(-10 * 24 * time.Hour))
+	retained := deletedAt(now.Add(-2 * 24 * time.Hour))
+	cutoff := now.Add(-7 * 24 * time.Hour)
+
+	users := []*model.User{
+		{ID: "expired-user", Name: "expired", Email: "expired@example.com", Phone: "13800000001", Model: model.Model{DeletedAt: expired}},
+		{ID: "paid-user", Name: "paid", Email: "paid@example.com", Phone: "13800000002", Model: model.Model{DeletedAt: expired}},
+		{ID: "retained-user", Name: "retained", Email: "retained@example.com", Phone: "13800000003", Model: model.Model{DeletedAt: retained}},
+	}
+	for _, user := range users {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	// 有充值记录的用户即使过期也保留
+	if err := db.Create(&model.PaymentRecord{ID: "payment", UserID: "paid-user", Amount: 10}).Error; err != nil {
+		t.Fatalf("创建充值记录失败: %v", err)
+	}
+
+	createFavorite(t, db, &model.Favorite{ID: "expired-user-favorite", UserID: "expired-user", DictionaryID: createDictionary(t, db, "a").ID})
+	createFavorite(t, db, &model.Favorite{ID: "expired", UserID: "u1", DictionaryID: createDictionary(t, db, "b").ID, Model: model.Model{DeletedAt: expired}})
+	createFavorite(t, db, &model.Favorite{ID: "retained", UserID: "u1", DictionaryID: createDictionary(t, db, "c").ID, Model: model.Model{DeletedAt: retained}})
+	createFavorite(t, db, &model.Favorite{ID: "active", UserID: "u1", DictionaryID: createDictionary(t, db, "d").ID})
+	createStudyRecord(t, db, &model.StudyRecord{ID: "expired-favorite-record", FavoriteID: "expired", Model: model.Model{DeletedAt: expired}})
+	createStudyRecord(t, db, &model.StudyRecord{ID: "expired-record", FavoriteID: "active", Model: model.Model{DeletedAt: expired}})
+	createStudyRecord(t, db, &model.StudyRecord{ID: "retained-record", FavoriteID: "active", Model: model.Model{DeletedAt: retained}})
+	if err := db.Create(&model.FavoriteTag{FavoriteID: "expired", UserID: "u1", Name: "tag"}).Error; err != nil {
+		t.Fatalf("创建标签失败: %v", err)
+	}
+
+	// 仍被收藏引用的词典即使过期也保留
+	referenced := createDictionary(t, db, "referenced")
+	createFavorite(t, db, &model.Favorite{ID: "referencing", UserID: "u1", DictionaryID: referenced.ID})
+	expiredDictionary := createDictionary(t, db, "expired")
+	retainedDictionary := createDictionary(t, db, "retained")
+	for id, deleted := range map[uint64]gorm.DeletedAt{referenced.ID: expired, expiredDictionary.ID: expired, retainedDictionary.ID: retained} {
+		if err := db.Model(&model.Dictionary{}).Where("id = ?", id).Update("deleted_at", deleted).Error; err != nil {
+			t.Fatalf("删除词典失败: %v", err)
+		}
+	}
+
+	result, err := repo.PurgeDeletedBefore(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("PurgeDeletedBefore() error = %v", err)
+	}
+	want := &PurgeResult{Users: 1, Favorites: 1, StudyRecords: 1, Dictionaries: 1}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("PurgeDeletedBefore() = %+v, want %+v", result, want)
+	}
+
+	tests := []struct {
+		value interface{}
+		id    interface{}
+		want  int64
+	}{
+		{value: &model.User{}, id: "expired-user", want: 0},
+		{value: &model.User{}, id: "paid-user", want: 1},
+		{value: &model.User{}, id: "retained-user", want: 1},
+		{value: &model.Favorite{}, id: "expired-user-favorite", want: 0},
+		{value: &model.Favorite{}, id: "expired", want: 0},
+		{value: &model.Favorite{}, id: "retained", want: 1},
+		{value: &model.Favorite{}, id: "active", want: 1},
+		{value: &model.StudyRecord{}, id: "expired-favorite-record", want: 0},
+		{value: &model.StudyRecord{}, id: "expired-record", want: 0},
+		{value: &model.StudyRecord{}, id: "retained-record", want: 1},
+		{value: &model.Dictionary{}, id: referenced.ID, want: 1},
+		{value: &model.Dictionary{}, id: expiredDictionary.ID, want: 0},
+		{value: &model.Dictionary{}, id: retainedDictionary.ID, want: 1},
+	}
+	for _, tt := range tests {
+		if count := countUnscoped(t, db, tt.value, "id = ?", tt.id); count != tt.want {
+			t.Errorf("%T %v count = %d, want %d", tt.value, tt.id, count, tt.want)
+		}
+	}
+	if count := countUnscoped(t, db, &model.FavoriteTag{}, "favorite_id = ?", "expired"); count != 0 {
+		t.Errorf("expired favorite tags = %d, want 0", count)
+	}
+}
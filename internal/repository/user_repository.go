@@ -1,30 +1,46 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"time"
+
+	errorsvar "github.com/cheel98/flashcard-backend/internal/errors"
 	"github.com/cheel98/flashcard-backend/internal/model"
 	"gorm.io/gorm"
 )
 
 // UserRepository 用户仓储接口
 type UserRepository interface {
-	Create(*model.User) (*model.User, error)
+	Create(ctx context.Context, user *model.User) (*model.User, error)
 	// Login 用户登录验证
-	Login(email, passwordHash string) (*model.User, error)
+	Login(ctx context.Context, email, passwordHash string) (*model.User, error)
 	// GetUserByEmail 根据ID获取用户基本信息
-	GetUserByEmail(email string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	// GetUserByID 根据ID获取用户基本信息
+	GetUserByID(ctx context.Context, userID string) (*model.User, error)
 	// GetUserSettings 获取用户设置
-	GetUserSettings(userID string) (*model.UserSettings, error)
+	GetUserSettings(ctx context.Context, userID string) (*model.UserSettings, error)
 	// GetUserPreferences 获取用户个人喜好
-	GetUserPreferences(userID string) (*model.UserPreferences, error)
+	GetUserPreferences(ctx context.Context, userID string) (*model.UserPreferences, error)
 	// GetUserLogs 获取用户操作日志
-	GetUserLogs(userID string, limit, offset int) ([]*model.UserLogs, error)
+	GetUserLogs(ctx context.Context, userID string, limit, offset int) ([]*model.UserLogs, error)
 	// SaveRefreshToken 保存刷新令牌
-	SaveRefreshToken(userID, refreshToken string) error
+	SaveRefreshToken(ctx context.Context, userID, refreshToken string) error
 	// GetUserByRefreshToken 根据刷新令牌获取用户
-	GetUserByRefreshToken(userId, refreshToken string) (*model.User, error)
+	GetUserByRefreshToken(ctx context.Context, userId, refreshToken string) (*model.User, error)
 	// ClearRefreshToken 清除刷新令牌
-	ClearRefreshToken(userID string) error
+	ClearRefreshToken(ctx context.Context, userID string) error
+	// DeleteUser 注销用户（移入回收站）
+	DeleteUser(ctx context.Context, userID string) error
+	// RestoreUser 从回收站恢复用户
+	RestoreUser(ctx context.Context, userID string) error
+	// SetUserRole 设置用户角色
+	SetUserRole(ctx context.Context, userID, role string) error
+	// SetUserDisabled 禁用或启用用户
+	SetUserDisabled(ctx context.Context, userID string, disabled bool) error
+	// UpdatePasswordHash 重置用户密码
+	UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error
 }
 
 // userRepository 用户仓储实现
@@ -38,21 +54,40 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 		db: db,
 	}
 }
-func (r *userRepository) Create(user *model.User) (*model.User, error) {
-	err := r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	err := r.db.WithContext(ctx).Create(user).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errorsvar.ErrUserExists
+		}
 		return nil, err
 	}
 	return user, nil
 }
 
 // Login 用户登录验证
-func (r *userRepository) Login(email, passwordHash string) (*model.User, error) {
+func (r *userRepository) Login(ctx context.Context, email, passwordHash string) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Where("email = ? AND password_hash = ?", email, passwordHash).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorsvar.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errorsvar.ErrAccountDisabled
+	}
+	return &user, nil
+}
+
+// GetUserByID 根据ID获取用户基本信息（不使用关联查询）
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("email = ? AND password_hash = ?", email, passwordHash).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户名或密码错误")
+			return nil, errorsvar.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -60,12 +95,12 @@ func (r *userRepository) Login(email, passwordHash string) (*model.User, error)
 }
 
 // GetUserByID 根据ID获取用户基本信息（不使用关联查询）
-func (r *userRepository) GetUserByEmail(email string) (*model.User, error) {
+func (r *userRepository) GetUserByID(ctx context.Context, userID string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, errorsvar.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -73,12 +108,12 @@ func (r *userRepository) GetUserByEmail(email string) (*model.User, error) {
 }
 
 // GetUserSettings 获取用户设置（不使用关联查询）
-func (r *userRepository) GetUserSettings(userID string) (*model.UserSettings, error) {
+func (r *userRepository) GetUserSettings(ctx context.Context, userID string) (*model.UserSettings, error) {
 	var settings model.UserSettings
-	err := r.db.Where("user_id = ?", userID).First(&settings).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户设置不存在")
+			return nil, errorsvar.ErrUserSettingsNotFound
 		}
 		return nil, err
 	}
@@ -86,12 +121,12 @@ func (r *userRepository) GetUserSettings(userID string) (*model.UserSettings, er
 }
 
 // GetUserPreferences 获取用户个人喜好（不使用关联查询）
-func (r *userRepository) GetUserPreferences(userID string) (*model.UserPreferences, error) {
+func (r *userRepository) GetUserPreferences(ctx context.Context, userID string) (*model.UserPreferences, error) {
 	var preferences model.UserPreferences
-	err := r.db.Where("user_id = ?", userID).First(&preferences).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&preferences).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户喜好设置不存在")
+			return nil, errorsvar.ErrUserPreferencesNotFound
 		}
 		return nil, err
 	}
@@ -99,9 +134,9 @@ func (r *userRepository) GetUserPreferences(userID string) (*model.UserPreferenc
 }
 
 // GetUserLogs 获取用户操作日志（不使用关联查询）
-func (r *userRepository) GetUserLogs(userID string, limit, offset int) ([]*model.UserLogs, error) {
+func (r *userRepository) GetUserLogs(ctx context.Context, userID string, limit, offset int) ([]*model.UserLogs, error) {
 	var logs []*model.UserLogs
-	err := r.db.Where("user_id = ?", userID).
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -113,8 +148,8 @@ func (r *userRepository) GetUserLogs(userID string, limit, offset int) ([]*model
 }
 
 // SaveRefreshToken 保存刷新令牌
-func (r *userRepository) SaveRefreshToken(userID, refreshToken string) error {
-	err := r.db.Model(&model.User{}).Where("id = ?", userID).Update("refresh_token", refreshToken).Error
+func (r *userRepository) SaveRefreshToken(ctx context.Context, userID, refreshToken string) error {
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("refresh_token", refreshToken).Error
 	if err != nil {
 		return err
 	}
@@ -122,24 +157,93 @@ func (r *userRepository) SaveRefreshToken(userID, refreshToken string) error {
 }
 
 // GetUserByRefreshToken 根据刷新令牌获取用户
-func (r *userRepository) GetUserByRefreshToken(userId, refreshToken string) (*model.User, error) {
+func (r *userRepository) GetUserByRefreshToken(ctx context.Context, userId, refreshToken string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("user_id = ? AND refresh_token = ? AND refresh_token != ''", userId, refreshToken).First(&user).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND refresh_token = ? AND refresh_token != ''", userId, refreshToken).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("无效的刷新令牌")
+			return nil, errorsvar.ErrInvalidRefreshToken
 		}
-		_ = r.ClearRefreshToken(userId)
+		_ = r.ClearRefreshToken(ctx, userId)
 		return nil, err
 	}
 	return &user, nil
 }
 
 // ClearRefreshToken 清除刷新令牌
-func (r *userRepository) ClearRefreshToken(userID string) error {
-	err := r.db.Model(&model.User{}).Where("id = ?", userID).Update("refresh_token", "").Error
+func (r *userRepository) ClearRefreshToken(ctx context.Context, userID string) error {
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("refresh_token", "").Error
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// DeleteUser 注销用户（移入回收站），同时使刷新令牌失效
+func (r *userRepository) DeleteUser(ctx context.Context, userID string) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"refresh_token": "",
+			"deleted_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errorsvar.ErrUserNotFound
+	}
+	return nil
+}
+
+// RestoreUser 从回收站恢复用户
+func (r *userRepository) RestoreUser(ctx context.Context, userID string) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", userID).
+		Update("deleted_at", nil)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		// 注销后用户名、邮箱或手机号已被重新注册
+		return errorsvar.ErrUserExists
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errorsvar.ErrUserNotInTrash
+	}
+	return nil
+}
+
+// SetUserRole 设置用户角色
+func (r *userRepository) SetUserRole(ctx context.Context, userID, role string) error {
+	return r.updateUser(ctx, userID, map[string]interface{}{"role": role})
+}
+
+// SetUserDisabled 禁用或启用用户，禁用时同时使刷新令牌失效
+func (r *userRepository) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	updates := map[string]interface{}{"disabled": disabled}
+	if disabled {
+		updates["refresh_token"] = ""
+	}
+	return r.updateUser(ctx, userID, updates)
+}
+
+// UpdatePasswordHash 重置用户密码，同时使刷新令牌失效
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error {
+	return r.updateUser(ctx, userID, map[string]interface{}{
+		"password_hash": passwordHash,
+		"refresh_token": "",
+	})
+}
+
+// updateUser 更新用户字段，用户不存在时返回错误
+func (r *userRepository) updateUser(ctx context.Context, userID string, updates map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errorsvar.ErrUserNotFound
+	}
+	return nil
+}
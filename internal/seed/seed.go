@@ -0,0 +1,110 @@
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/model"
+	"github.com/cheel98/flashcard-backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+//go:embed data/dictionary.json
+var dictionaryData []byte
+
+const (
+	// DemoEmail 演示用户邮箱
+	DemoEmail = "demo@flashcard.local"
+	// DemoName 演示用户名
+	DemoName = "demo"
+	// DemoPasswordHash 演示用户密码"demo123456"的SHA-256值，与客户端提交的密码哈希格式一致
+	DemoPasswordHash = "d2b000ce0875cad8615dd8cf34f788635c959a0ce2b8a977e22caab745380b06"
+)
+
+// Result 导入结果
+type Result struct {
+	DictionariesCreated int
+	DictionariesSkipped int
+	DemoUserID          string
+	DemoUserCreated     bool
+}
+
+// Seeder 示例数据导入器，重复执行不会产生重复数据
+type Seeder struct {
+	dictionaryRepo repository.DictionaryRepository
+	userRepo       repository.UserRepository
+	logger         *zap.Logger
+}
+
+// NewSeeder 创建示例数据导入器
+func NewSeeder(dictionaryRepo repository.DictionaryRepository, userRepo repository.UserRepository, logger *zap.Logger) *Seeder {
+	return &Seeder{
+		dictionaryRepo: dictionaryRepo,
+		userRepo:       userRepo,
+		logger:         logger,
+	}
+}
+
+// Run 导入示例词典和演示用户
+func (s *Seeder) Run(ctx context.Context) (*Result, error) {
+	result := &Result{}
+	if err := s.seedDictionaries(ctx, result); err != nil {
+		return result, err
+	}
+	if err := s.seedDemoUser(ctx, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// seedDictionaries 导入示例词典，已存在的记录跳过
+func (s *Seeder) seedDictionaries(ctx context.Context, result *Result) error {
+	var dictionaries []*model.Dictionary
+	if err := json.Unmarshal(dictionaryData, &dictionaries); err != nil {
+		return fmt.Errorf("解析示例词典失败: %w", err)
+	}
+
+	for _, dict := range dictionaries {
+		if _, err := s.dictionaryRepo.GetDictionaryByUniqueTranslation(ctx, dict.SourceLang, dict.TargetLang, dict.SourceText); err == nil {
+			result.DictionariesSkipped++
+			continue
+		}
+		dict.CreatedAt = time.Now()
+		dict.UpdatedAt = time.Now()
+		if err := s.dictionaryRepo.CreateDictionary(ctx, dict); err != nil {
+			return fmt.Errorf("导入词典%s失败: %w", dict.SourceText, err)
+		}
+		result.DictionariesCreated++
+	}
+
+	s.logger.Info("示例词典导入完成",
+		zap.Int("created", result.DictionariesCreated),
+		zap.Int("skipped", result.DictionariesSkipped))
+	return nil
+}
+
+// seedDemoUser 创建演示用户，已存在时跳过
+func (s *Seeder) seedDemoUser(ctx context.Context, result *Result) error {
+	if existing, err := s.userRepo.GetUserByEmail(ctx, DemoEmail); err == nil {
+		result.DemoUserID = existing.ID
+		return nil
+	}
+
+	user, err := s.userRepo.Create(ctx, &model.User{
+		Name:         DemoName,
+		Email:        DemoEmail,
+		PasswordHash: DemoPasswordHash,
+		Role:         model.RoleUser,
+	})
+	if err != nil {
+		return fmt.Errorf("创建演示用户失败: %w", err)
+	}
+	result.DemoUserID = user.ID
+	result.DemoUserCreated = true
+
+	s.logger.Info("演示用户创建成功", zap.String("userID", user.ID))
+	return nil
+}
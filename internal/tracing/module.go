@@ -0,0 +1,26 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+// Module 链路追踪模块
+var Module = fx.Options(
+	fx.Provide(NewProvider),
+	fx.Invoke(func(lc fx.Lifecycle, provider *Provider, db *gorm.DB, redisClient *redis.RedisClient) error {
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return provider.Shutdown(ctx)
+			},
+		})
+		if err := db.Use(NewGormPlugin()); err != nil {
+			return err
+		}
+		return redisotel.InstrumentTracing(redisClient.Client(), redisotel.WithTracerProvider(provider))
+	}),
+)
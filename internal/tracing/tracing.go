@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+// instrumentationName 本服务创建span时使用的tracer名称
+const instrumentationName = "github.com/cheel98/flashcard-backend"
+
+// 支持的导出方式
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Provider 链路追踪提供者
+type Provider struct {
+	trace.TracerProvider
+	sdk    *sdktrace.TracerProvider
+	logger *zap.Logger
+}
+
+// NewProvider 按配置创建TracerProvider并设置为全局提供者。
+// 导出方式为none时不记录span，但仍然设置传播器，保证上游的追踪上下文可以透传给下游。
+func NewProvider(cfg *config.Config, logger *zap.Logger) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporterName := strings.ToLower(cfg.Tracing.Exporter)
+	if exporterName == "" || exporterName == ExporterNone {
+		logger.Info("Tracing disabled")
+		provider := noop.NewTracerProvider()
+		otel.SetTracerProvider(provider)
+		return &Provider{TracerProvider: provider, logger: logger}, nil
+	}
+
+	exporter, err := newExporter(exporterName, cfg.Tracing)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.Tracing.ServiceName),
+		attribute.String("deployment.environment", cfg.Server.Env),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	sdk := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(sdk)
+
+	logger.Info("Tracing enabled",
+		zap.String("exporter", exporterName),
+		zap.String("service", cfg.Tracing.ServiceName),
+		zap.Float64("sample_ratio", cfg.Tracing.SampleRatio))
+	return &Provider{TracerProvider: sdk, sdk: sdk, logger: logger}, nil
+}
+
+// newExporter 创建span导出器
+func newExporter(name string, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch name {
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		// 连接在后台建立，接收端暂时不可用时不会阻塞启动
+		return otlptracegrpc.New(context.Background(), opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter: %s", name)
+	}
+}
+
+// Shutdown 导出剩余的span并关闭提供者
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.sdk == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := p.sdk.Shutdown(ctx); err != nil {
+		p.logger.Error("Failed to shutdown tracer provider", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// tracer 获取本服务的tracer，使用全局提供者
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
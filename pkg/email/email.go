@@ -6,7 +6,9 @@ import (
 	"math/big"
 
 	"github.com/cheel98/flashcard-backend/internal/config"
+	"github.com/cheel98/flashcard-backend/internal/i18n"
 	"go.uber.org/zap"
+	"golang.org/x/text/language"
 	"gopkg.in/gomail.v2"
 )
 
@@ -24,25 +26,14 @@ func NewEmailService(cfg *config.Config, logger *zap.Logger) *EmailService {
 	}
 }
 
-// SendCaptcha 发送验证码邮件
-func (e *EmailService) SendCaptcha(toEmail, captcha string) error {
+// SendCaptcha 使用指定语言发送验证码邮件
+func (e *EmailService) SendCaptcha(toEmail, captcha string, lang language.Tag) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", e.config.FromEmail)
 	m.SetHeader("To", toEmail)
-	m.SetHeader("Subject", "验证码 - Flashcard App")
+	m.SetHeader("Subject", i18n.Text(lang, i18n.KeyCaptchaEmailSubject, ""))
 
-	body := fmt.Sprintf(`
-		<html>
-		<body>
-			<h2>验证码</h2>
-			<p>您的验证码是：<strong>%s</strong></p>
-			<p>验证码有效期为5分钟，请及时使用。</p>
-			<p>如果您没有请求此验证码，请忽略此邮件。</p>
-			<br>
-			<p>此邮件由系统自动发送，请勿回复。</p>
-		</body>
-		</html>
-	`, captcha)
+	body := i18n.Sprintf(lang, i18n.KeyCaptchaEmailBody, captcha)
 
 	m.SetBody("text/html", body)
 
@@ -0,0 +1,294 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+)
+
+// Options 客户端选项
+type Options struct {
+	Timeout             time.Duration            // 单次请求的超时时间，重试时每次单独计时
+	HostTimeouts        map[string]time.Duration // 按主机名覆盖Timeout
+	MaxRetries          int                      // 幂等请求失败后的最大重试次数
+	RetryBackoff        time.Duration            // 首次重试的退避时间，之后按指数增长
+	MaxRetryBackoff     time.Duration            // 退避时间上限，同时限制服务端Retry-After的等待时间
+	MaxResponseSize     int64                    // 响应体大小上限（字节），超过时返回ErrResponseTooLarge
+	MaxIdleConnsPerHost int                      // 每个主机保留的空闲连接数
+	MaxConnsPerHost     int                      // 每个主机的最大连接数，0表示不限制
+	// Transport 底层传输，为nil时使用带连接池的http.Transport。测试中可以替换为桩实现
+	Transport http.RoundTripper
+}
+
+// Client 调用外部HTTP服务的客户端。所有请求都使用调用方的ctx，并为链路追踪创建客户端span；
+// 幂等请求在网络错误、429和5xx网关错误时按带抖动的指数退避重试
+type Client struct {
+	client *http.Client
+	opts   Options
+	logger *zap.Logger
+}
+
+// New 创建客户端
+func New(opts Options, logger *zap.Logger) *Client {
+	transport := opts.Transport
+	if transport == nil {
+		base := http.DefaultTransport.(*http.Transport).Clone()
+		base.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		base.MaxConnsPerHost = opts.MaxConnsPerHost
+		transport = base
+	}
+	return &Client{
+		// 超时由每次请求的ctx控制，http.Client不再设置整体超时
+		client: &http.Client{Transport: otelhttp.NewTransport(transport)},
+		opts:   opts,
+		logger: logger,
+	}
+}
+
+// Request 一次HTTP请求。Idempotent为true时即使方法不是幂等方法也允许重试，
+// 适用于语义上没有副作用的POST查询接口
+type Request struct {
+	Method     string
+	URL        string
+	Query      url.Values
+	Header     http.Header
+	Body       []byte
+	Idempotent bool
+}
+
+// Response 已完整读取的响应
+type Response struct {
+	URL        string // 去掉了查询参数的请求地址
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// DecodeJSON 校验Content-Type后将响应体解析到v
+func (r *Response) DecodeJSON(v interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !strings.HasSuffix(mediaType, "json") {
+		return &ContentTypeError{URL: r.URL, Expected: "application/json", ContentType: r.Header.Get("Content-Type"), Body: r.Body}
+	}
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("httpclient: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Get 发送GET请求
+func (c *Client) Get(ctx context.Context, rawURL string, query url.Values, header http.Header) (*Response, error) {
+	return c.Do(ctx, &Request{Method: http.MethodGet, URL: rawURL, Query: query, Header: header})
+}
+
+// PostForm 发送表单POST请求，idempotent表示请求可以安全地重试
+func (c *Client) PostForm(ctx context.Context, rawURL string, form url.Values, header http.Header, idempotent bool) (*Response, error) {
+	header = cloneHeader(header)
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.Do(ctx, &Request{
+		Method:     http.MethodPost,
+		URL:        rawURL,
+		Header:     header,
+		Body:       []byte(form.Encode()),
+		Idempotent: idempotent,
+	})
+}
+
+// PostJSON 将body编码为JSON后发送POST请求，idempotent表示请求可以安全地重试
+func (c *Client) PostJSON(ctx context.Context, rawURL string, body interface{}, header http.Header, idempotent bool) (*Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: failed to encode request: %w", err)
+	}
+	header = cloneHeader(header)
+	header.Set("Content-Type", "application/json")
+	return c.Do(ctx, &Request{
+		Method:     http.MethodPost,
+		URL:        rawURL,
+		Header:     header,
+		Body:       payload,
+		Idempotent: idempotent,
+	})
+}
+
+// Do 发送请求，非2xx响应返回*StatusError
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: invalid url %q: %w", req.URL, err)
+	}
+	if len(req.Query) > 0 {
+		query := target.Query()
+		for key, values := range req.Query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	retryable := req.Idempotent || isIdempotentMethod(req.Method)
+	for attempt := 0; ; attempt++ {
+		res, err := c.attempt(ctx, req, target)
+		if err == nil {
+			return res, nil
+		}
+		if !retryable || attempt >= c.opts.MaxRetries || !shouldRetry(ctx, err) {
+			return nil, err
+		}
+
+		wait := c.backoff(attempt, err)
+		c.logger.Warn("HTTP请求失败，准备重试",
+			zap.String("method", req.Method),
+			zap.String("host", target.Host),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", wait),
+			zap.Error(err),
+		)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// attempt 发送一次请求并读取响应体
+func (c *Client) attempt(ctx context.Context, req *Request, target *url.URL) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout(target.Hostname()))
+	defer cancel()
+
+	var body io.Reader
+	if req.Body != nil {
+		body = bytes.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: failed to create request: %w", err)
+	}
+	for key, values := range req.Header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	httpRes, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	content, err := c.readBody(httpRes.Body)
+	if err != nil {
+		return nil, err
+	}
+	res := &Response{URL: redactURL(target), StatusCode: httpRes.StatusCode, Header: httpRes.Header, Body: content}
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		return nil, &StatusError{
+			Method:     req.Method,
+			URL:        res.URL,
+			StatusCode: httpRes.StatusCode,
+			Header:     httpRes.Header,
+			Body:       content,
+		}
+	}
+	return res, nil
+}
+
+// readBody 读取响应体，超过大小上限时返回ErrResponseTooLarge
+func (c *Client) readBody(body io.Reader) ([]byte, error) {
+	if c.opts.MaxResponseSize <= 0 {
+		return io.ReadAll(body)
+	}
+	content, err := io.ReadAll(io.LimitReader(body, c.opts.MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > c.opts.MaxResponseSize {
+		return nil, fmt.Errorf("%w: limit %d bytes", ErrResponseTooLarge, c.opts.MaxResponseSize)
+	}
+	return content, nil
+}
+
+// timeout 主机对应的单次请求超时时间
+func (c *Client) timeout(host string) time.Duration {
+	if timeout, ok := c.opts.HostTimeouts[host]; ok {
+		return timeout
+	}
+	return c.opts.Timeout
+}
+
+// backoff 第attempt次重试前的等待时间：在指数退避时间内随机取值（full jitter），
+// 服务端返回Retry-After时以其为准，均不超过MaxRetryBackoff
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if seconds, parseErr := strconv.Atoi(statusErr.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, c.opts.MaxRetryBackoff)
+		}
+	}
+	ceiling := min(c.opts.RetryBackoff<<attempt, c.opts.MaxRetryBackoff)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// shouldRetry 网络错误、单次请求超时、429和网关类5xx可以重试；调用方的ctx结束后不再重试
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, ErrResponseTooLarge) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isIdempotentMethod 按HTTP语义可以安全重试的方法
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// redactURL 去掉查询参数，避免签名等敏感信息出现在错误和日志中
+func redactURL(target *url.URL) string {
+	redacted := *target
+	redacted.RawQuery = ""
+	redacted.User = nil
+	return redacted.String()
+}
+
+// cloneHeader 复制请求头，避免修改调用方的map
+func cloneHeader(header http.Header) http.Header {
+	if header == nil {
+		return http.Header{}
+	}
+	return header.Clone()
+}
@@ -0,0 +1,288 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// stubResponse 桩传输返回的一次响应
+type stubResponse struct {
+	status int
+	header http.Header
+	body   string
+}
+
+// stubTransport 按顺序返回预设响应并记录请求次数，响应用完后重复最后一个
+type stubTransport struct {
+	mu        sync.Mutex
+	responses []stubResponse
+	calls     int
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	response := s.responses[min(s.calls, len(s.responses)-1)]
+	s.calls++
+	header := response.header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: response.status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(response.body)),
+		Request:    req,
+	}, nil
+}
+
+func (s *stubTransport) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func newTestClient(transport http.RoundTripper, opts Options) *Client {
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Second
+	}
+	opts.Transport = transport
+	return New(opts, zap.NewNop())
+}
+
+func TestDoRetriesRetryableStatus(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			transport := &stubTransport{responses: []stubResponse{
+				{status: status},
+				{status: status},
+				{status: http.StatusOK, body: "ok"},
+			}}
+			client := newTestClient(transport, Options{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: 5 * time.Millisecond})
+
+			res, err := client.Get(context.Background(), "http://engine.test/api", nil, nil)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if string(res.Body) != "ok" {
+				t.Fatalf("Body = %q, want ok", res.Body)
+			}
+			if calls := transport.Calls(); calls != 3 {
+				t.Fatalf("calls = %d, want 3", calls)
+			}
+		})
+	}
+}
+
+func TestDoStopsAfterMaxRetries(t *testing.T) {
+	transport := &stubTransport{responses: []stubResponse{{status: http.StatusServiceUnavailable}}}
+	client := newTestClient(transport, Options{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: time.Millisecond})
+
+	_, err := client.Get(context.Background(), "http://engine.test/api", nil, nil)
+	if !IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("Get() error = %v, want 503 StatusError", err)
+	}
+	if calls := transport.Calls(); calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoDoesNotRetryOtherStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			transport := &stubTransport{responses: []stubResponse{{status: status}}}
+			client := newTestClient(transport, Options{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: time.Millisecond})
+
+			if _, err := client.Get(context.Background(), "http://engine.test/api", nil, nil); !IsStatus(err, status) {
+				t.Fatalf("Get() error = %v, want %d StatusError", err, status)
+			}
+			if calls := transport.Calls(); calls != 1 {
+				t.Fatalf("calls = %d, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestDoFollowsRetryAfterCappedByMaxRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		maxBackoff time.Duration
+		minWait    time.Duration
+		maxWait    time.Duration
+	}{
+		{name: "zero retry after", retryAfter: "0", maxBackoff: time.Second, maxWait: 200 * time.Millisecond},
+		{name: "retry after capped", retryAfter: "30", maxBackoff: 100 * time.Millisecond, minWait: 100 * time.Millisecond, maxWait: time.Second},
+		{name: "retry after within cap", retryAfter: "1", maxBackoff: 5 * time.Second, minWait: time.Second, maxWait: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &stubTransport{responses: []stubResponse{
+				{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {tt.retryAfter}}},
+				{status: http.StatusOK},
+			}}
+			// RetryBackoff远大于期望的等待时间，确保等待时间来自Retry-After
+			client := newTestClient(transport, Options{MaxRetries: 1, RetryBackoff: tt.maxBackoff, MaxRetryBackoff: tt.maxBackoff})
+
+			start := time.Now()
+			if _, err := client.Get(context.Background(), "http://engine.test/api", nil, nil); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minWait || elapsed > tt.maxWait {
+				t.Fatalf("Get() took %v, want between %v and %v", elapsed, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+}
+
+func TestBackoffWithinExponentialCeiling(t *testing.T) {
+	client := newTestClient(&stubTransport{}, Options{RetryBackoff: 100 * time.Millisecond, MaxRetryBackoff: 300 * time.Millisecond})
+	err := errors.New("connection reset")
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if wait := client.backoff(attempt, err); wait <= 0 || wait > ceiling {
+				t.Fatalf("backoff(%d) = %v, want in (0, %v]", attempt, wait, ceiling)
+			}
+		}
+	}
+}
+
+func TestPostRetryDependsOnIdempotent(t *testing.T) {
+	tests := []struct {
+		name       string
+		idempotent bool
+		wantCalls  int
+	}{
+		{name: "non idempotent post is not retried", idempotent: false, wantCalls: 1},
+		{name: "idempotent post is retried", idempotent: true, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &stubTransport{responses: []stubResponse{{status: http.StatusServiceUnavailable}}}
+			client := newTestClient(transport, Options{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: time.Millisecond})
+
+			_, err := client.PostForm(context.Background(), "http://engine.test/api", url.Values{"q": {"hello"}}, nil, tt.idempotent)
+			if !IsStatus(err, http.StatusServiceUnavailable) {
+				t.Fatalf("PostForm() error = %v, want 503 StatusError", err)
+			}
+			if calls := transport.Calls(); calls != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoDoesNotRetryResponseTooLarge(t *testing.T) {
+	transport := &stubTransport{responses: []stubResponse{{status: http.StatusOK, body: strings.Repeat("x", 11)}}}
+	client := newTestClient(transport, Options{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: time.Millisecond, MaxResponseSize: 10})
+
+	_, err := client.Get(context.Background(), "http://engine.test/api", nil, nil)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Get() error = %v, want ErrResponseTooLarge", err)
+	}
+	if calls := transport.Calls(); calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoAcceptsResponseAtSizeLimit(t *testing.T) {
+	transport := &stubTransport{responses: []stubResponse{{status: http.StatusOK, body: strings.Repeat("x", 10)}}}
+	client := newTestClient(transport, Options{MaxResponseSize: 10})
+
+	res, err := client.Get(context.Background(), "http://engine.test/api", nil, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(res.Body) != 10 {
+		t.Fatalf("len(Body) = %d, want 10", len(res.Body))
+	}
+}
+
+func TestStatusErrorCarriesStatusAndBody(t *testing.T) {
+	transport := &stubTransport{responses: []stubResponse{{
+		status: http.StatusBadRequest,
+		header: http.Header{"Content-Type": {"application/json"}},
+		body:   `{"errorCode":"101"}`,
+	}}}
+	client := newTestClient(transport, Options{})
+
+	_, err := client.Get(context.Background(), "http://engine.test/api?sign=secret", url.Values{"appKey": {"key"}}, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Get() error = %v, want *StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusBadRequest || statusErr.Method != http.MethodGet {
+		t.Fatalf("StatusError = %s %d, want GET 400", statusErr.Method, statusErr.StatusCode)
+	}
+	if string(statusErr.Body) != `{"errorCode":"101"}` {
+		t.Fatalf("StatusError.Body = %q", statusErr.Body)
+	}
+	if statusErr.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("StatusError.Header = %v", statusErr.Header)
+	}
+	if statusErr.URL != "http://engine.test/api" || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("StatusError must not expose query parameters: URL = %q, Error() = %q", statusErr.URL, err.Error())
+	}
+}
+
+func TestDoReturnsPromptlyWhenCancelledDuringBackoff(t *testing.T) {
+	transport := &stubTransport{responses: []stubResponse{
+		{status: http.StatusServiceUnavailable, header: http.Header{"Retry-After": {"60"}}},
+	}}
+	client := newTestClient(transport, Options{MaxRetries: 3, RetryBackoff: time.Minute, MaxRetryBackoff: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.Get(ctx, "http://engine.test/api", nil, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Get() returned after %v, want prompt return on cancel", elapsed)
+	}
+	if !IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("Get() error = %v, want last 503 StatusError", err)
+	}
+	if calls := transport.Calls(); calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoDoesNotRetryAfterCallerCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := &cancellingTransport{cancel: cancel}
+	client := newTestClient(transport, Options{MaxRetries: 3, RetryBackoff: time.Millisecond, MaxRetryBackoff: time.Millisecond})
+
+	if _, err := client.Get(ctx, "http://engine.test/api", nil, nil); err == nil {
+		t.Fatal("Get() error = nil, want error")
+	}
+	if transport.calls != 1 {
+		t.Fatalf("calls = %d, want 1", transport.calls)
+	}
+}
+
+// cancellingTransport 第一次请求时取消调用方的ctx并返回网关错误
+type cancellingTransport struct {
+	cancel context.CancelFunc
+	calls  int
+}
+
+func (c *cancellingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.calls++
+	c.cancel()
+	return &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
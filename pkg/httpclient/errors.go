@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxErrorBodySize 错误信息中保留的响应体长度
+const maxErrorBodySize = 512
+
+// ErrResponseTooLarge 响应体超过 Options.MaxResponseSize
+var ErrResponseTooLarge = errors.New("httpclient: response body too large")
+
+// StatusError 服务端返回了非2xx状态码，Body为响应体（不超过大小上限）
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	return fmt.Sprintf("httpclient: %s %s returned %d: %s", e.Method, e.URL, e.StatusCode, body)
+}
+
+// ContentTypeError 响应的Content-Type与期望的不一致，常见于网关返回HTML错误页
+type ContentTypeError struct {
+	URL         string
+	Expected    string
+	ContentType string
+	Body        []byte
+}
+
+func (e *ContentTypeError) Error() string {
+	body := e.Body
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	return fmt.Sprintf("httpclient: %s returned content type %q, expected %q: %s", e.URL, e.ContentType, e.Expected, body)
+}
+
+// IsStatus 判断err是否为指定状态码的StatusError
+func IsStatus(err error, code int) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == code
+}
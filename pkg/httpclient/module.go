@@ -0,0 +1,33 @@
+package httpclient
+
+import (
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module 外部HTTP客户端模块
+var Module = fx.Options(
+	fx.Provide(NewFromConfig),
+)
+
+// NewFromConfig 从配置创建客户端
+func NewFromConfig(cfg *config.Config, logger *zap.Logger) *Client {
+	httpCfg := cfg.HTTPClient
+	hostTimeouts := make(map[string]time.Duration, len(httpCfg.Hosts))
+	for _, host := range httpCfg.Hosts {
+		hostTimeouts[host.Host] = time.Duration(host.Timeout) * time.Second
+	}
+	return New(Options{
+		Timeout:             time.Duration(httpCfg.Timeout) * time.Second,
+		HostTimeouts:        hostTimeouts,
+		MaxRetries:          httpCfg.MaxRetries,
+		RetryBackoff:        time.Duration(httpCfg.RetryBackoff) * time.Millisecond,
+		MaxRetryBackoff:     time.Duration(httpCfg.MaxRetryBackoff) * time.Millisecond,
+		MaxResponseSize:     int64(httpCfg.MaxResponseSize) * 1024,
+		MaxIdleConnsPerHost: httpCfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     httpCfg.MaxConnsPerHost,
+	}, logger)
+}
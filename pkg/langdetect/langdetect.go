@@ -0,0 +1,55 @@
+package langdetect
+
+import (
+	"github.com/abadojack/whatlanggo"
+)
+
+// byISO6391 ISO 639-1代码到whatlanggo语言的映射
+var byISO6391 = func() map[string]whatlanggo.Lang {
+	languages := make(map[string]whatlanggo.Lang)
+	for lang := whatlanggo.Lang(0); lang.String() != ""; lang++ {
+		if code := lang.Iso6391(); code != "" {
+			languages[code] = lang
+		}
+	}
+	return languages
+}()
+
+// Options 检测选项
+type Options struct {
+	// Languages 候选语言的ISO 639-1代码，为空时在所有支持的语言中检测。限定候选语言可以减少相近语言之间的误判
+	Languages []string
+	// MinConfidence 置信度下限，0~1。按字符集判断的语言（例如中文、日文、韩文）置信度为1，
+	// 拉丁字母等共用字符集的语言按三元组频率计算，短文本的置信度通常较低
+	MinConfidence float64
+}
+
+// Detector 离线语言检测器，基于字符集和三元组频率，不调用外部服务
+type Detector struct {
+	options       whatlanggo.Options
+	minConfidence float64
+}
+
+// New 创建检测器
+func New(opts Options) *Detector {
+	detector := &Detector{minConfidence: opts.MinConfidence}
+	if languages := opts.Languages; len(languages) > 0 {
+		detector.options.Whitelist = make(map[whatlanggo.Lang]bool, len(languages))
+		for _, code := range languages {
+			if lang, ok := byISO6391[code]; ok {
+				detector.options.Whitelist[lang] = true
+			}
+		}
+	}
+	return detector
+}
+
+// Detect 检测文本的语言，返回ISO 639-1代码。无法识别或置信度低于下限时ok为false
+func (d *Detector) Detect(text string) (language string, ok bool) {
+	info := whatlanggo.DetectWithOptions(text, d.options)
+	if info.Lang == -1 || info.Confidence < d.minConfidence {
+		return "", false
+	}
+	language = info.Lang.Iso6391()
+	return language, language != ""
+}
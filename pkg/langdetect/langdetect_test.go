@@ -0,0 +1,33 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	// 翻译服务使用的候选语言
+	supported := []string{"en", "zh", "ja", "ko", "fr", "de", "es", "pt", "it", "ru", "vi", "th", "id", "ar", "nl"}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{name: "script", opts: Options{MinConfidence: 0.5}, text: "今天天气很好", want: "zh", wantOK: true},
+		{name: "trigram", opts: Options{Languages: supported, MinConfidence: 0.5}, text: "Where is the nearest train station, please?", want: "en", wantOK: true},
+		{name: "all languages", text: "Привіт, як справи? Сьогодні гарна погода", want: "uk", wantOK: true},
+		{name: "whitelist", opts: Options{Languages: []string{"en", "ru"}}, text: "Привіт, як справи? Сьогодні гарна погода", want: "ru", wantOK: true},
+		{name: "unknown whitelist code ignored", opts: Options{Languages: []string{"ru", "xx"}}, text: "Привіт, як справи? Сьогодні гарна погода", want: "ru", wantOK: true},
+		{name: "short input", opts: Options{Languages: supported, MinConfidence: 0.5}, text: "ok"},
+		{name: "unsupported language", opts: Options{Languages: supported, MinConfidence: 0.5}, text: "Dzień dobry, jak się masz? Dzisiaj jest piękna pogoda"},
+		{name: "no letters", opts: Options{Languages: supported}, text: "12345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := New(tt.opts).Detect(tt.text)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("Detect(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// contextKey 上下文中保存日志实例的键
+type contextKey struct{}
+
+// WithContext 将日志实例保存到上下文中
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext 获取上下文中的请求级日志实例，不存在时返回fallback
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// AddFields 为上下文中的日志实例追加字段，上下文中没有日志实例时原样返回
+func AddFields(ctx context.Context, fields ...zap.Field) context.Context {
+	logger, ok := ctx.Value(contextKey{}).(*zap.Logger)
+	if !ok {
+		return ctx
+	}
+	return WithContext(ctx, logger.With(fields...))
+}
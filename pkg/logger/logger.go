@@ -3,10 +3,43 @@ package logger
 import (
 	"github.com/cheel98/flashcard-backend/internal/config"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// NewAtomicLevel 根据配置创建可在运行时调整的日志级别
+func NewAtomicLevel(cfg *config.Config) zap.AtomicLevel {
+	return zap.NewAtomicLevelAt(parseLevel(cfg.Logger.Level))
+}
+
+// NewLevelSubscriber 配置热加载时同步日志级别
+func NewLevelSubscriber(level zap.AtomicLevel, logger *zap.Logger) config.Subscriber {
+	return config.SubscriberFunc(func(old, updated *config.Config) {
+		if old.Logger.Level == updated.Logger.Level {
+			return
+		}
+		level.SetLevel(parseLevel(updated.Logger.Level))
+		logger.Info("Log level changed", zap.String("level", updated.Logger.Level))
+	})
+}
+
+// parseLevel 解析日志级别，无法识别时使用info
+func parseLevel(level string) zapcore.Level {
+	switch level {
+	case "debug":
+		return zap.DebugLevel
+	case "info":
+		return zap.InfoLevel
+	case "warn":
+		return zap.WarnLevel
+	case "error":
+		return zap.ErrorLevel
+	default:
+		return zap.InfoLevel
+	}
+}
+
 // NewLogger 创建新的日志实例
-func NewLogger(cfg *config.Config) (*zap.Logger, error) {
+func NewLogger(cfg *config.Config, level zap.AtomicLevel) (*zap.Logger, error) {
 	var zapConfig zap.Config
 
 	// 根据环境配置选择日志配置
@@ -16,19 +49,8 @@ func NewLogger(cfg *config.Config) (*zap.Logger, error) {
 		zapConfig = zap.NewDevelopmentConfig()
 	}
 
-	// 设置日志级别
-	switch cfg.Logger.Level {
-	case "debug":
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	case "warn":
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "error":
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		zapConfig.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	}
+	// 设置日志级别，修改level会立即对所有日志实例生效
+	zapConfig.Level = level
 
 	// 设置日志格式
 	if cfg.Logger.Format == "console" {
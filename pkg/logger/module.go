@@ -1,10 +1,14 @@
 package logger
 
 import (
+	"github.com/cheel98/flashcard-backend/internal/config"
 	"go.uber.org/fx"
 )
 
 // Module 日志模块
 var Module = fx.Options(
+	fx.Provide(NewAtomicLevel),
 	fx.Provide(NewLogger),
+	// 配置热加载时同步日志级别
+	fx.Provide(config.AsSubscriber(NewLevelSubscriber)),
 )
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// redactedValue 脱敏后的占位值
+const redactedValue = "[REDACTED]"
+
+// sensitiveFieldKeywords 字段名包含这些关键字时，日志中的值会被替换
+var sensitiveFieldKeywords = []string{
+	"password",
+	"captcha",
+	"token",
+	"secret",
+	"authorization",
+	"credential",
+}
+
+// IsSensitiveField 判断字段名是否属于敏感字段
+func IsSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, keyword := range sensitiveFieldKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Proto 将protobuf消息脱敏后以JSON形式记录到日志字段中
+func Proto(key string, msg interface{}) zap.Field {
+	message, ok := msg.(proto.Message)
+	if !ok || message == nil || !message.ProtoReflect().IsValid() {
+		return zap.Skip()
+	}
+
+	redacted := proto.Clone(message)
+	redactMessage(redacted.ProtoReflect())
+	data, err := protojson.Marshal(redacted)
+	if err != nil {
+		return zap.String(key, "<"+err.Error()+">")
+	}
+	return zap.Reflect(key, json.RawMessage(data))
+}
+
+// redactMessage 递归替换消息中的敏感字段
+func redactMessage(message protoreflect.Message) {
+	// 遍历过程中不能修改其他字段，先收集再替换
+	var sensitive []protoreflect.FieldDescriptor
+	message.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if IsSensitiveField(string(fd.Name())) {
+			sensitive = append(sensitive, fd)
+			return true
+		}
+
+		switch {
+		case fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Kind() == protoreflect.MessageKind:
+			value.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				redactMessage(v.Message())
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Kind() == protoreflect.MessageKind:
+			redactMessage(value.Message())
+		}
+		return true
+	})
+	for _, fd := range sensitive {
+		redactField(message, fd)
+	}
+}
+
+// redactField 替换单个敏感字段，字符串类型写入占位值，其他类型直接清空
+func redactField(message protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+		message.Set(fd, protoreflect.ValueOfString(redactedValue))
+		return
+	}
+	message.Clear(fd)
+}
@@ -2,16 +2,20 @@ package pkg
 
 import (
 	"github.com/cheel98/flashcard-backend/pkg/email"
+	"github.com/cheel98/flashcard-backend/pkg/httpclient"
 	"github.com/cheel98/flashcard-backend/pkg/jwt"
 	"github.com/cheel98/flashcard-backend/pkg/logger"
 	"github.com/cheel98/flashcard-backend/pkg/redis"
+	"github.com/cheel98/flashcard-backend/pkg/tlsutil"
 	"go.uber.org/fx"
 )
 
 // Module Redis模块
 var Module = fx.Options(
 	email.Module,
+	httpclient.Module,
 	jwt.Module,
 	logger.Module,
 	redis.Module,
+	tlsutil.Module,
 )
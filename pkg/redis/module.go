@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"context"
+
 	"go.uber.org/fx"
 )
 
@@ -9,4 +11,13 @@ var Module = fx.Options(
 	fx.Provide(
 		NewRedisClient,
 	),
+	// 在服务器和后台任务停止后关闭连接
+	fx.Invoke(func(lc fx.Lifecycle, client *RedisClient) {
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				client.logger.Info("关闭Redis连接")
+				return client.Close()
+			},
+		})
+	}),
 )
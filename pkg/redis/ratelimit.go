@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// gcraScript 使用GCRA算法判断请求是否允许，时间取自Redis服务器，多个实例之间不受本地时钟影响。
+// KEYS[1] 限流键；ARGV[1] 两个请求之间的间隔（微秒）；ARGV[2] 允许的突发量换算成的时间（微秒）。
+// 返回 {是否允许, 剩余请求数, 需要等待的时间（微秒）, 完全恢复的时间（微秒）}
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local key = KEYS[1]
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", key))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+local reset_after = new_tat - now
+redis.call("SET", key, new_tat, "PX", math.ceil(reset_after / 1000))
+return {1, math.floor((now - allow_at) / emission), 0, reset_after}
+`)
+
+// RateLimit 限额：每个周期最多Limit个请求，最多允许连续Burst个请求，Burst为0时等于Limit
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// burst 允许的突发请求数
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Limit
+}
+
+// RateLimitResult 限流结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // 允许的突发请求数
+	Remaining  int           // 本次请求之后还能立即发出的请求数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+	ResetAfter time.Duration // 恢复到完整突发量需要的时间
+}
+
+// AllowRate 按限额消耗一次请求
+func (r *RedisClient) AllowRate(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	emission := limit.Period / time.Duration(limit.Limit)
+	burst := limit.burst()
+	values, err := gcraScript.Run(ctx, r.client, []string{key},
+		emission.Microseconds(), emission.Microseconds()*int64(burst)).Int64Slice()
+	if err != nil {
+		r.logger.Error("Redis限流脚本执行失败", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// newTestClient 返回连接到miniredis的客户端，miniredis的TIME命令返回手动设置的时间
+func newTestClient(t *testing.T) (*RedisClient, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	server.SetTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	rdb := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return &RedisClient{client: rdb, logger: zap.NewNop()}, server
+}
+
+func TestAllowRate(t *testing.T) {
+	type step struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		limit RateLimit
+		steps []step
+	}{
+		{
+			name:  "burst defaults to limit",
+			limit: RateLimit{Limit: 3, Period: 30 * time.Second},
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRemaining: 0, wantRetry: 10 * time.Second},
+				{advance: 4 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 6 * time.Second},
+				{advance: 6 * time.Second, wantAllowed: true, wantRemaining: 0},
+				{advance: 30 * time.Second, wantAllowed: true, wantRemaining: 2},
+			},
+		},
+		{
+			name:  "explicit burst",
+			limit: RateLimit{Limit: 60, Period: time.Minute, Burst: 2},
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRemaining: 0, wantRetry: time.Second},
+				{advance: time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name:  "denied requests are not counted",
+			limit: RateLimit{Limit: 1, Period: 10 * time.Second},
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRetry: 10 * time.Second},
+				{wantAllowed: false, wantRetry: 10 * time.Second},
+				{advance: 10 * time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				server.SetTime(now)
+				server.FastForward(s.advance)
+
+				result, err := client.AllowRate(context.Background(), "ratelimit:test", tt.limit)
+				if err != nil {
+					t.Fatalf("step %d: AllowRate() error = %v", i, err)
+				}
+				if result.Allowed != s.wantAllowed || result.Remaining != s.wantRemaining || result.RetryAfter != s.wantRetry {
+					t.Fatalf("step %d: AllowRate() = allowed %v, remaining %d, retry after %v; want %v, %d, %v",
+						i, result.Allowed, result.Remaining, result.RetryAfter, s.wantAllowed, s.wantRemaining, s.wantRetry)
+				}
+				if result.Limit != tt.limit.burst() {
+					t.Fatalf("step %d: Limit = %d, want %d", i, result.Limit, tt.limit.burst())
+				}
+			}
+		})
+	}
+}
+
+func TestAllowRateResetAfterAndExpiry(t *testing.T) {
+	client, server := newTestClient(t)
+	limit := RateLimit{Limit: 4, Period: 20 * time.Second}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.AllowRate(context.Background(), "ratelimit:test", limit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := client.AllowRate(context.Background(), "ratelimit:test", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ResetAfter != 15*time.Second {
+		t.Fatalf("ResetAfter = %v, want 15s", result.ResetAfter)
+	}
+	if ttl := server.TTL("ratelimit:test"); ttl != 15*time.Second {
+		t.Fatalf("key TTL = %v, want 15s", ttl)
+	}
+
+	server.FastForward(15 * time.Second)
+	if server.Exists("ratelimit:test") {
+		t.Fatal("key must expire once the full burst is restored")
+	}
+}
+
+func TestAllowRateKeysAreIndependent(t *testing.T) {
+	client, _ := newTestClient(t)
+	limit := RateLimit{Limit: 1, Period: time.Minute}
+
+	for _, key := range []string{"ratelimit:a", "ratelimit:b"} {
+		result, err := client.AllowRate(context.Background(), key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Fatalf("%s: first request denied", key)
+		}
+	}
+}
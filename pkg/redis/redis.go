@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrKeyNotFound 键不存在
+var ErrKeyNotFound = errors.New("key not found")
+
 // RedisClient Redis客户端封装
 type RedisClient struct {
 	client *redis.Client
@@ -56,7 +60,7 @@ func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	val, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return "", fmt.Errorf("key not found: %s", key)
+			return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 		}
 		r.logger.Error("Redis Get失败", zap.String("key", key), zap.Error(err))
 		return "", err
@@ -64,6 +68,16 @@ func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	return val, nil
 }
 
+// SetNX 键不存在时设置键值对，返回是否设置成功
+func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, key, value, expiration).Result()
+	if err != nil {
+		r.logger.Error("Redis SetNX失败", zap.String("key", key), zap.Error(err))
+		return false, err
+	}
+	return ok, nil
+}
+
 // Delete 删除键
 func (r *RedisClient) Delete(ctx context.Context, key string) error {
 	err := r.client.Del(ctx, key).Err()
@@ -96,3 +110,18 @@ func (r *RedisClient) DeleteCaptcha(ctx context.Context, email string) error {
 	key := fmt.Sprintf("captcha:%s", email)
 	return r.Delete(ctx, key)
 }
+
+// Ping 检查Redis连接
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// PoolStats 获取连接池统计信息
+func (r *RedisClient) PoolStats() *redis.PoolStats {
+	return r.client.PoolStats()
+}
+
+// Client 获取底层的go-redis客户端，用于注册钩子
+func (r *RedisClient) Client() *redis.Client {
+	return r.client
+}
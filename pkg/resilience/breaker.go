@@ -0,0 +1,216 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于断开状态，或半开状态的探测请求已满
+var ErrCircuitOpen = errors.New("resilience: circuit breaker is open")
+
+// State 熔断器状态
+type State int
+
+const (
+	// StateClosed 闭合，请求正常通过并统计失败率
+	StateClosed State = iota
+	// StateOpen 断开，请求直接失败，经过OpenTimeout后进入半开
+	StateOpen
+	// StateHalfOpen 半开，只放行少量探测请求，全部成功后闭合，任一失败重新断开
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// windowBuckets 滑动窗口的分桶数量
+const windowBuckets = 10
+
+// BreakerOptions 熔断器选项
+type BreakerOptions struct {
+	Window           time.Duration // 统计失败率的滑动窗口
+	MinRequests      int           // 窗口内的请求数达到该值后才按失败率判断，避免少量请求误触发
+	FailureRate      float64       // 失败率达到该值时断开，0~1
+	OpenTimeout      time.Duration // 断开后经过该时间进入半开
+	HalfOpenRequests int           // 半开状态允许的探测请求数
+	// OnStateChange 状态变化时调用，调用时不持有熔断器的锁
+	OnStateChange func(name string, from, to State)
+}
+
+// bucket 窗口中一个分桶的计数
+type bucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+// Breaker 按失败率断开的熔断器，并发安全
+type Breaker struct {
+	name string
+	opts BreakerOptions
+	now  func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // 每次状态变化加一，丢弃旧状态下发出的请求结果
+	buckets    [windowBuckets]bucket
+	openedAt   time.Time
+	probes     int // 半开状态已放行的探测请求数
+	probesOK   int // 半开状态成功的探测请求数
+}
+
+// NewBreaker 创建熔断器
+func NewBreaker(name string, opts BreakerOptions) *Breaker {
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	return &Breaker{name: name, opts: opts, now: time.Now}
+}
+
+// Name 熔断器名称
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 当前状态，断开时间已超过OpenTimeout时返回半开
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.opts.OpenTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Allow 判断请求是否可以通过。通过时返回done，调用方必须在请求结束后以请求是否成功调用一次；
+// 不通过时返回ErrCircuitOpen
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	now := b.now()
+	var change func()
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.opts.OpenTimeout {
+		change = b.setStateLocked(StateHalfOpen, now)
+	}
+
+	switch b.state {
+	case StateOpen:
+		b.mu.Unlock()
+		return nil, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.opts.HalfOpenRequests {
+			b.mu.Unlock()
+			runChange(change)
+			return nil, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+	runChange(change)
+
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.record(generation, success) })
+	}, nil
+}
+
+// record 记录请求结果并按需切换状态
+func (b *Breaker) record(generation uint64, success bool) {
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	now := b.now()
+	var change func()
+	switch b.state {
+	case StateClosed:
+		current := b.bucketLocked(now)
+		if success {
+			current.successes++
+		} else {
+			current.failures++
+		}
+		if !success && b.tripLocked(now) {
+			change = b.setStateLocked(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if !success {
+			change = b.setStateLocked(StateOpen, now)
+		} else if b.probesOK++; b.probesOK >= b.opts.HalfOpenRequests {
+			change = b.setStateLocked(StateClosed, now)
+		}
+	}
+	b.mu.Unlock()
+	runChange(change)
+}
+
+// bucketLocked 返回当前时间所在的分桶，过期的分桶先清零
+func (b *Breaker) bucketLocked(now time.Time) *bucket {
+	width := b.bucketWidth()
+	start := now.Truncate(width)
+	current := &b.buckets[(start.UnixNano()/int64(width))%windowBuckets]
+	if !current.start.Equal(start) {
+		*current = bucket{start: start}
+	}
+	return current
+}
+
+// tripLocked 窗口内的请求数和失败率是否达到断开条件
+func (b *Breaker) tripLocked(now time.Time) bool {
+	var successes, failures int
+	for _, item := range b.buckets {
+		if now.Sub(item.start) < b.opts.Window {
+			successes += item.successes
+			failures += item.failures
+		}
+	}
+	total := successes + failures
+	return total >= b.opts.MinRequests && float64(failures)/float64(total) >= b.opts.FailureRate
+}
+
+// bucketWidth 每个分桶的时长
+func (b *Breaker) bucketWidth() time.Duration {
+	width := b.opts.Window / windowBuckets
+	if width <= 0 {
+		width = time.Millisecond
+	}
+	return width
+}
+
+// setStateLocked 切换状态并重置计数，返回需要在释放锁之后执行的回调
+func (b *Breaker) setStateLocked(state State, now time.Time) func() {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.probes = 0
+	b.probesOK = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.buckets = [windowBuckets]bucket{}
+	}
+	if b.opts.OnStateChange == nil || from == state {
+		return nil
+	}
+	return func() { b.opts.OnStateChange(b.name, from, state) }
+}
+
+// runChange 执行状态变化回调
+func runChange(change func()) {
+	if change != nil {
+		change()
+	}
+}
@@ -0,0 +1,314 @@
+package resilience
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock 测试中手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// testBreakerOptions 10秒窗口（每个分桶1秒），至少4个请求且失败率达到50%时断开
+var testBreakerOptions = BreakerOptions{
+	Window:           10 * time.Second,
+	MinRequests:      4,
+	FailureRate:      0.5,
+	OpenTimeout:      5 * time.Second,
+	HalfOpenRequests: 2,
+}
+
+func newTestBreaker(t *testing.T) (*Breaker, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewBreaker("test", testBreakerOptions)
+	breaker.now = clock.Now
+	return breaker, clock
+}
+
+// call 放行一个请求并立即以success结束
+func call(t *testing.T, breaker *Breaker, success bool) {
+	t.Helper()
+	done, err := breaker.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v, want nil", err)
+	}
+	done(success)
+}
+
+// trip 连续失败直到熔断器断开
+func trip(t *testing.T, breaker *Breaker) {
+	t.Helper()
+	for i := 0; i < testBreakerOptions.MinRequests; i++ {
+		call(t, breaker, false)
+	}
+	if state := breaker.State(); state != StateOpen {
+		t.Fatalf("State() after failures = %v, want open", state)
+	}
+}
+
+func TestBreakerClosedToOpen(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		success bool
+		want    State
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "trips once min requests reached at failure rate",
+			steps: []step{
+				{success: true, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateOpen},
+			},
+		},
+		{
+			name: "stays closed below min requests",
+			steps: []step{
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+			},
+		},
+		{
+			name: "stays closed below failure rate",
+			steps: []step{
+				{success: true, want: StateClosed},
+				{success: true, want: StateClosed},
+				{success: true, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+			},
+		},
+		{
+			name: "exactly failure rate trips",
+			steps: []step{
+				{success: true, want: StateClosed},
+				{success: true, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateOpen},
+			},
+		},
+		{
+			name: "failures in expired buckets are not counted",
+			steps: []step{
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{advance: 11 * time.Second, success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateOpen},
+			},
+		},
+		{
+			name: "failures spread across buckets inside the window are counted",
+			steps: []step{
+				{success: false, want: StateClosed},
+				{advance: 3 * time.Second, success: false, want: StateClosed},
+				{advance: 3 * time.Second, success: false, want: StateClosed},
+				{advance: 3 * time.Second, success: false, want: StateOpen},
+			},
+		},
+		{
+			name: "success does not trip even above failure rate",
+			steps: []step{
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: false, want: StateClosed},
+				{success: true, want: StateClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker, clock := newTestBreaker(t)
+			for i, s := range tt.steps {
+				clock.Advance(s.advance)
+				call(t, breaker, s.success)
+				if state := breaker.State(); state != s.want {
+					t.Fatalf("step %d: State() = %v, want %v", i, state, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerOpenToHalfOpen(t *testing.T) {
+	breaker, clock := newTestBreaker(t)
+	trip(t, breaker)
+
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() while open error = %v, want ErrCircuitOpen", err)
+	}
+	clock.Advance(testBreakerOptions.OpenTimeout - time.Nanosecond)
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() before open timeout error = %v, want ErrCircuitOpen", err)
+	}
+
+	clock.Advance(time.Nanosecond)
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Fatalf("State() after open timeout = %v, want half_open", state)
+	}
+	if _, err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() after open timeout error = %v, want nil", err)
+	}
+}
+
+func TestBreakerHalfOpenProbeLimit(t *testing.T) {
+	breaker, clock := newTestBreaker(t)
+	trip(t, breaker)
+	clock.Advance(testBreakerOptions.OpenTimeout)
+
+	for i := 0; i < testBreakerOptions.HalfOpenRequests; i++ {
+		if _, err := breaker.Allow(); err != nil {
+			t.Fatalf("probe %d: Allow() error = %v, want nil", i, err)
+		}
+	}
+	if _, err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() beyond probe limit error = %v, want ErrCircuitOpen", err)
+	}
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Fatalf("State() = %v, want half_open", state)
+	}
+}
+
+func TestBreakerHalfOpenResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []bool
+		want    State
+	}{
+		{name: "all probes succeed", results: []bool{true, true}, want: StateClosed},
+		{name: "first probe fails", results: []bool{false}, want: StateOpen},
+		{name: "second probe fails", results: []bool{true, false}, want: StateOpen},
+		{name: "waits for every probe", results: []bool{true}, want: StateHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []State
+			breaker, clock := newTestBreaker(t)
+			breaker.opts.OnStateChange = func(name string, from, to State) {
+				changes = append(changes, to)
+			}
+			trip(t, breaker)
+			clock.Advance(testBreakerOptions.OpenTimeout)
+
+			for _, success := range tt.results {
+				call(t, breaker, success)
+			}
+			if state := breaker.State(); state != tt.want {
+				t.Fatalf("State() = %v, want %v", state, tt.want)
+			}
+			if last := changes[len(changes)-1]; tt.want != StateHalfOpen && last != tt.want {
+				t.Fatalf("last OnStateChange = %v, want %v", last, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerReopenRestartsTimeout(t *testing.T) {
+	breaker, clock := newTestBreaker(t)
+	trip(t, breaker)
+	clock.Advance(testBreakerOptions.OpenTimeout)
+	call(t, breaker, false)
+
+	clock.Advance(testBreakerOptions.OpenTimeout - time.Nanosecond)
+	if state := breaker.State(); state != StateOpen {
+		t.Fatalf("State() before second open timeout = %v, want open", state)
+	}
+	clock.Advance(time.Nanosecond)
+	if state := breaker.State(); state != StateHalfOpen {
+		t.Fatalf("State() after second open timeout = %v, want half_open", state)
+	}
+}
+
+func TestBreakerDiscardsResultsFromOldGeneration(t *testing.T) {
+	t.Run("late failure from closed state is ignored in half open", func(t *testing.T) {
+		breaker, clock := newTestBreaker(t)
+		late, err := breaker.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		trip(t, breaker)
+		clock.Advance(testBreakerOptions.OpenTimeout)
+
+		probe, err := breaker.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		late(false)
+		if state := breaker.State(); state != StateHalfOpen {
+			t.Fatalf("State() after late failure = %v, want half_open", state)
+		}
+		probe(true)
+		call(t, breaker, true)
+		if state := breaker.State(); state != StateClosed {
+			t.Fatalf("State() after probes succeed = %v, want closed", state)
+		}
+	})
+
+	t.Run("late success from closed state does not count as a probe", func(t *testing.T) {
+		breaker, clock := newTestBreaker(t)
+		late, err := breaker.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		trip(t, breaker)
+		clock.Advance(testBreakerOptions.OpenTimeout)
+
+		call(t, breaker, true)
+		late(true)
+		if state := breaker.State(); state != StateHalfOpen {
+			t.Fatalf("State() after late success = %v, want half_open", state)
+		}
+	})
+
+	t.Run("closing resets the failure window", func(t *testing.T) {
+		breaker, clock := newTestBreaker(t)
+		trip(t, breaker)
+		clock.Advance(testBreakerOptions.OpenTimeout)
+
+		call(t, breaker, true)
+		call(t, breaker, true)
+		if state := breaker.State(); state != StateClosed {
+			t.Fatalf("State() = %v, want closed", state)
+		}
+		for i := 0; i < testBreakerOptions.MinRequests-1; i++ {
+			call(t, breaker, false)
+		}
+		if state := breaker.State(); state != StateClosed {
+			t.Fatalf("State() = %v, want closed: window must be reset when closing", state)
+		}
+	})
+
+	t.Run("done records only once", func(t *testing.T) {
+		breaker, _ := newTestBreaker(t)
+		done, err := breaker.Allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < testBreakerOptions.MinRequests; i++ {
+			done(false)
+		}
+		if state := breaker.State(); state != StateClosed {
+			t.Fatalf("State() = %v, want closed", state)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBulkheadFull 并发数已达上限且在等待时间内没有空位
+var ErrBulkheadFull = errors.New("resilience: bulkhead is full")
+
+// Bulkhead 限制同时调用某个依赖的请求数，避免依赖变慢时占满所有处理能力
+type Bulkhead struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+// NewBulkhead 创建并发隔离，maxConcurrency为最大并发数，maxWait为没有空位时的最长等待时间，0表示不等待
+func NewBulkhead(maxConcurrency int, maxWait time.Duration) *Bulkhead {
+	return &Bulkhead{
+		slots:   make(chan struct{}, maxConcurrency),
+		maxWait: maxWait,
+	}
+}
+
+// Acquire 占用一个空位，成功后必须调用返回的release释放
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	default:
+	}
+	if b.maxWait <= 0 {
+		return nil, ErrBulkheadFull
+	}
+
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	case <-timer.C:
+		return nil, ErrBulkheadFull
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release 释放一个空位
+func (b *Bulkhead) release() {
+	<-b.slots
+}
+
+// InFlight 当前占用的空位数
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
+
+// Capacity 最大并发数
+func (b *Bulkhead) Capacity() int {
+	return cap(b.slots)
+}
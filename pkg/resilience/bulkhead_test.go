@@ -0,0 +1,73 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBulkheadAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxWait  time.Duration
+		cancel   bool
+		wantErr  error
+		minDelay time.Duration
+		maxDelay time.Duration
+	}{
+		{name: "full without wait fails immediately", maxWait: 0, wantErr: ErrBulkheadFull, maxDelay: 50 * time.Millisecond},
+		{name: "full times out after max wait", maxWait: 50 * time.Millisecond, wantErr: ErrBulkheadFull, minDelay: 50 * time.Millisecond, maxDelay: time.Second},
+		{name: "cancelled ctx stops waiting", maxWait: time.Minute, cancel: true, wantErr: context.Canceled, maxDelay: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bulkhead := NewBulkhead(1, tt.maxWait)
+			release, err := bulkhead.Acquire(context.Background())
+			if err != nil {
+				t.Fatalf("first Acquire() error = %v", err)
+			}
+			defer release()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(20*time.Millisecond, cancel)
+			}
+			start := time.Now()
+			_, err = bulkhead.Acquire(ctx)
+			elapsed := time.Since(start)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Acquire() error = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed < tt.minDelay || elapsed > tt.maxDelay {
+				t.Fatalf("Acquire() returned after %v, want between %v and %v", elapsed, tt.minDelay, tt.maxDelay)
+			}
+			if inFlight := bulkhead.InFlight(); inFlight != 1 {
+				t.Fatalf("InFlight() = %d, want 1", inFlight)
+			}
+		})
+	}
+}
+
+func TestBulkheadWaiterGetsReleasedSlot(t *testing.T) {
+	bulkhead := NewBulkhead(1, time.Second)
+	release, err := bulkhead.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(20*time.Millisecond, release)
+
+	second, err := bulkhead.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() after release error = %v, want nil", err)
+	}
+	second()
+	if inFlight := bulkhead.InFlight(); inFlight != 0 {
+		t.Fatalf("InFlight() = %d, want 0", inFlight)
+	}
+	if capacity := bulkhead.Capacity(); capacity != 1 {
+		t.Fatalf("Capacity() = %d, want 1", capacity)
+	}
+}
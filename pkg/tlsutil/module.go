@@ -0,0 +1,54 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/cheel98/flashcard-backend/internal/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module TLS模块
+var Module = fx.Options(
+	fx.Provide(
+		NewCertReloaderFromConfig,
+		NewClientTLSFromConfig,
+	),
+)
+
+// NewCertReloaderFromConfig 从配置创建服务端证书热加载器，未开启TLS时返回nil
+func NewCertReloaderFromConfig(cfg *config.Config, logger *zap.Logger) (*CertReloader, error) {
+	if !cfg.TLS.Enabled {
+		return nil, nil
+	}
+	return NewCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile, reloadInterval(cfg), logger)
+}
+
+// ClientTLS 连接本服务使用的客户端TLS配置，Config为nil表示使用明文连接
+type ClientTLS struct {
+	Config *tls.Config
+}
+
+// NewClientTLSFromConfig 从配置创建连接本服务的客户端TLS配置
+func NewClientTLSFromConfig(cfg *config.Config, logger *zap.Logger) (*ClientTLS, error) {
+	if !cfg.TLS.Enabled {
+		return &ClientTLS{}, nil
+	}
+	tlsConfig, err := ClientConfig(ClientOptions{
+		CAFile:         cfg.TLS.CAFile,
+		CertFile:       cfg.TLS.ClientCertFile,
+		KeyFile:        cfg.TLS.ClientKeyFile,
+		ServerName:     cfg.TLS.ServerName,
+		ReloadInterval: reloadInterval(cfg),
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientTLS{Config: tlsConfig}, nil
+}
+
+// reloadInterval 检查证书文件变化的间隔
+func reloadInterval(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.TLS.ReloadInterval) * time.Second
+}
@@ -0,0 +1,194 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CertReloader 证书热加载器。每次TLS握手时按间隔检查证书文件的修改时间，
+// 文件变化后重新加载，加载失败时继续使用旧证书
+type CertReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	interval     time.Duration
+	logger       *zap.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader 创建证书热加载器并立即加载证书。clientCAFile为校验客户端证书（mTLS）的CA，
+// 是否要求客户端证书由ServerConfig按端口决定
+func NewCertReloader(certFile, keyFile, clientCAFile string, interval time.Duration, logger *zap.Logger) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		interval:     interval,
+		logger:       logger,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerConfig 返回服务端TLS配置，nextProtos为ALPN协议，例如gRPC使用h2。
+// requireClientCert为true且配置了clientCAFile时要求客户端提供由该CA签发的证书
+func (r *CertReloader) ServerConfig(requireClientCert bool, nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.reloadIfChanged()
+
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*r.cert},
+			}
+			if requireClientCert && r.clientCAs != nil {
+				cfg.ClientCAs = r.clientCAs
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// GetClientCertificate 作为客户端连接开启了mTLS的服务时提供证书，用于tls.Config.GetClientCertificate
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.reloadIfChanged()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reloadIfChanged 距离上次检查超过间隔且证书文件有变化时重新加载
+func (r *CertReloader) reloadIfChanged() {
+	r.mu.Lock()
+	if time.Since(r.lastCheck) < r.interval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = time.Now()
+	changed := false
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(r.modTimes[path]) {
+			changed = true
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := r.load(); err != nil {
+		r.logger.Warn("Failed to reload TLS certificate, keeping the current one", zap.Error(err))
+		return
+	}
+	r.logger.Info("TLS certificate reloaded", zap.String("cert_file", r.certFile))
+}
+
+// load 读取证书、私钥和客户端CA
+func (r *CertReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, path := range r.files() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		clientCAs, err = LoadCertPool(r.clientCAFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.lastCheck = time.Now()
+	return nil
+}
+
+// files 需要监听变化的文件
+func (r *CertReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+// LoadCertPool 从PEM文件读取CA证书
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errors.New("no valid certificate found in CA file " + path)
+	}
+	return pool, nil
+}
+
+// ClientOptions 客户端TLS选项
+type ClientOptions struct {
+	CAFile         string        // 校验服务端证书的CA，为空时使用系统根证书
+	CertFile       string        // 服务端要求mTLS时提供的客户端证书
+	KeyFile        string        // 客户端证书的私钥
+	ServerName     string        // 校验的服务端名称，为空时使用连接地址中的主机名
+	ReloadInterval time.Duration // 检查客户端证书文件变化的间隔
+}
+
+// ClientConfig 创建客户端TLS配置，客户端证书同样支持热加载
+func ClientConfig(opts ClientOptions, logger *zap.Logger) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: opts.ServerName,
+	}
+	if opts.CAFile != "" {
+		pool, err := LoadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.CertFile != "" {
+		reloader, err := NewCertReloader(opts.CertFile, opts.KeyFile, "", opts.ReloadInterval, logger)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = reloader.GetClientCertificate
+	}
+	return cfg, nil
+}
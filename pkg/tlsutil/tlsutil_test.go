@@ -0,0 +1,247 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// testCA 测试时生成的自签名CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发服务端或客户端证书，返回PEM格式的证书和私钥
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile 写入文件并把修改时间设置为modTime，保证重新写入后修改时间一定变化
+func writeFile(t *testing.T, path string, content []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// handshake 在内存连接上完成一次TLS握手，返回客户端看到的服务端证书和服务端的握手错误
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (*x509.Certificate, error) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	serverErr := make(chan error, 1)
+	go func() {
+		// 关闭底层连接而不是tls.Conn，避免发送close_notify时在内存连接上阻塞
+		err := tls.Server(serverConn, serverConfig).Handshake()
+		_ = serverConn.Close()
+		serverErr <- err
+	}()
+
+	client := tls.Client(clientConn, clientConfig)
+	clientErr := client.Handshake()
+	var peer *x509.Certificate
+	if clientErr == nil {
+		peer = client.ConnectionState().PeerCertificates[0]
+	}
+	_ = clientConn.Close()
+
+	if err := <-serverErr; err != nil {
+		return nil, err
+	}
+	if clientErr != nil {
+		t.Fatalf("client handshake failed: %v", clientErr)
+	}
+	return peer, nil
+}
+
+func clientConfigFor(ca *testCA) *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return &tls.Config{RootCAs: pool, ServerName: "localhost", MinVersion: tls.VersionTLS12}
+}
+
+func TestCertReloaderReloadsChangedCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	modTime := time.Now().Add(-time.Minute)
+
+	certPEM, keyPEM := ca.issue(t, 100, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+
+	reloader, err := NewCertReloader(certFile, keyFile, "", 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+	serverConfig := reloader.ServerConfig(false)
+
+	peer, err := handshake(t, serverConfig, clientConfigFor(ca))
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if peer.SerialNumber.Int64() != 100 {
+		t.Fatalf("got serial %d, want 100", peer.SerialNumber)
+	}
+
+	// 证书轮换后下一次握手使用新证书
+	modTime = modTime.Add(time.Second)
+	certPEM, keyPEM = ca.issue(t, 200, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+
+	peer, err = handshake(t, serverConfig, clientConfigFor(ca))
+	if err != nil {
+		t.Fatalf("handshake after rotation: %v", err)
+	}
+	if peer.SerialNumber.Int64() != 200 {
+		t.Fatalf("got serial %d after rotation, want 200", peer.SerialNumber)
+	}
+
+	// 新证书无法加载时继续使用旧证书
+	modTime = modTime.Add(time.Second)
+	writeFile(t, certFile, []byte("not a certificate"), modTime)
+
+	peer, err = handshake(t, serverConfig, clientConfigFor(ca))
+	if err != nil {
+		t.Fatalf("handshake with broken certificate file: %v", err)
+	}
+	if peer.SerialNumber.Int64() != 200 {
+		t.Fatalf("got serial %d with broken certificate file, want 200", peer.SerialNumber)
+	}
+}
+
+func TestServerConfigClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	dir := t.TempDir()
+	modTime := time.Now()
+
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	certPEM, keyPEM := ca.issue(t, 100, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, modTime)
+	writeFile(t, keyFile, keyPEM, modTime)
+
+	clientCAFile := filepath.Join(dir, "client-ca.crt")
+	writeFile(t, clientCAFile, ca.pem, modTime)
+
+	clientCertFile := filepath.Join(dir, "client.crt")
+	clientKeyFile := filepath.Join(dir, "client.key")
+	certPEM, keyPEM = ca.issue(t, 300, x509.ExtKeyUsageClientAuth)
+	writeFile(t, clientCertFile, certPEM, modTime)
+	writeFile(t, clientKeyFile, keyPEM, modTime)
+
+	untrustedCertFile := filepath.Join(dir, "untrusted.crt")
+	untrustedKeyFile := filepath.Join(dir, "untrusted.key")
+	certPEM, keyPEM = other.issue(t, 400, x509.ExtKeyUsageClientAuth)
+	writeFile(t, untrustedCertFile, certPEM, modTime)
+	writeFile(t, untrustedKeyFile, keyPEM, modTime)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, caFile, ca.pem, modTime)
+
+	reloader, err := NewCertReloader(certFile, keyFile, clientCAFile, time.Minute, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	newClient := func(certFile, keyFile string) *tls.Config {
+		cfg, err := ClientConfig(ClientOptions{
+			CAFile:     caFile,
+			CertFile:   certFile,
+			KeyFile:    keyFile,
+			ServerName: "localhost",
+		}, zap.NewNop())
+		if err != nil {
+			t.Fatalf("ClientConfig: %v", err)
+		}
+		return cfg
+	}
+
+	tests := []struct {
+		name              string
+		requireClientCert bool
+		client            *tls.Config
+		wantErr           bool
+	}{
+		{"mtls with trusted client certificate", true, newClient(clientCertFile, clientKeyFile), false},
+		{"mtls without client certificate", true, newClient("", ""), true},
+		{"mtls with untrusted client certificate", true, newClient(untrustedCertFile, untrustedKeyFile), true},
+		{"tls only without client certificate", false, newClient("", ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handshake(t, reloader.ServerConfig(tt.requireClientCert), tt.client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,5 +1,9 @@
+// Package swagger 内嵌由 buf generate 生成的OpenAPI文档，所有proto文件合并为一个文档
 package swagger
 
-func init() {
-	swag
-}
+import _ "embed"
+
+// Spec 合并后的OpenAPI v2文档，修改proto后执行 make proto 重新生成
+//
+//go:embed flashcard.swagger.json
+var Spec []byte